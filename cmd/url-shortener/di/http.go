@@ -2,7 +2,11 @@ package di
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -10,26 +14,45 @@ import (
 	"github.com/h3isenbug/url-shortener/internal/monitoring"
 	presentation "github.com/h3isenbug/url-shortener/internal/presentation/http"
 	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/service/export"
 	"github.com/h3isenbug/url-shortener/internal/service/url"
+	"github.com/h3isenbug/url-shortener/internal/service/visit"
+	"github.com/h3isenbug/url-shortener/internal/service/visitor"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/botdetect"
+	"github.com/h3isenbug/url-shortener/pkg/geoip"
 	"github.com/h3isenbug/url-shortener/pkg/log"
 )
 
-func provideHTTPServer(logger log.Logger, router *mux.Router) (*http.Server, func()) {
+func provideHTTPServer(logger log.Logger, router *mux.Router, visitRecorder visit.Recorder) (*http.Server, func()) {
 	server := &http.Server{
 		Addr:    ":" + config.Config.HTTPPort,
 		Handler: router,
 	}
 	return server, func() {
-		ctx, cancel := context.WithTimeout(
+		shutdownCtx, cancelShutdown := context.WithTimeout(
 			context.Background(),
 			time.Duration(config.Config.GracefulShutdownPeriodSeconds)*time.Second,
 		)
-		defer cancel()
-		if err := server.Shutdown(ctx); err != nil {
+		defer cancelShutdown()
+		if err := server.Shutdown(shutdownCtx); err != nil {
 			logger.Warn("error while shutting server down", map[string]interface{}{
 				"errorMessage": err.Error(),
 			})
 		}
+
+		// in-flight requests are done by now, so nothing is recorded after the buffer is drained. draining gets a
+		// period of its own, since a slow shutdown may have used up the one above.
+		drainCtx, cancelDrain := context.WithTimeout(
+			context.Background(),
+			time.Duration(config.Config.GracefulShutdownPeriodSeconds)*time.Second,
+		)
+		defer cancelDrain()
+		if err := visitRecorder.Drain(drainCtx); err != nil {
+			logger.Warn("error while draining visit buffer", map[string]interface{}{
+				"errorMessage": err.Error(),
+			})
+		}
 	}
 }
 
@@ -38,36 +61,197 @@ func provideMuxRouter(
 	authenticationService authentication.Service,
 	authHandler presentation.AuthenticationAPI,
 	urlHandler presentation.UrlAPI,
+	exportHandler presentation.ExportAPI,
 	metricCollector monitoring.MetricCollector,
-) *mux.Router {
+) (*mux.Router, error) {
+	trustedProxies, err := parseTrustedProxies(config.Config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
 	router := mux.NewRouter()
+	router.Use(presentation.ClientIPMiddleware(trustedProxies))
 	router.Use(presentation.GorillaMuxURLParamMiddleware)
 	router.Use(presentation.GorillaHttpMetricsMiddleware(metricCollector))
 
+	router.Host(config.Config.DashboardHost).Methods("GET").Path("/.well-known/jwks.json").
+		HandlerFunc(authHandler.GetJSONWebKeySet)
+
 	dashboardRouter := router.Host(config.Config.DashboardHost).PathPrefix("/api").Subrouter()
+	authMiddleware := presentation.NewAuthMiddlewareV1(logger, authenticationService)
 
 	urlRouter := dashboardRouter.PathPrefix("/url").Subrouter()
-	urlRouter.Use(presentation.NewAuthMiddlewareV1(logger, authenticationService).Intercept)
+	urlRouter.Use(authMiddleware.Intercept)
 
-	urlRouter.Methods("GET").HandlerFunc(urlHandler.GetMyUrls)
-	urlRouter.Methods("POST").HandlerFunc(urlHandler.CreateShortUrl)
-	urlRouter.Methods("PATCH").Path("/{slug:[0-9A-Za-z]+}").HandlerFunc(urlHandler.SetUrlState)
-	urlRouter.Methods("GET").HandlerFunc(urlHandler.GetMyUrls)
+	urlRouter.Methods("GET").Path("").Handler(
+		authMiddleware.RequireScopes(urlHandler.GetMyUrls, types.ScopeUrlsRead),
+	)
+	urlRouter.Methods("POST").Path("").Handler(
+		authMiddleware.RequireScopes(urlHandler.CreateShortUrl, types.ScopeUrlsCreate),
+	)
+	urlRouter.Methods("PATCH").Path("/{slug:[0-9A-Za-z]+}").Handler(
+		authMiddleware.RequireScopes(urlHandler.UpdateUrl, types.ScopeUrlsWrite),
+	)
+	urlRouter.Methods("GET").Path("/{slug:[0-9A-Za-z]+}/revisions").Handler(
+		authMiddleware.RequireScopes(urlHandler.GetUrlRevisions, types.ScopeUrlsRead),
+	)
+	urlRouter.Methods("POST").Path("/{slug:[0-9A-Za-z]+}/revisions/{revisionID:[0-9]+}/rollback").Handler(
+		authMiddleware.RequireScopes(urlHandler.RollbackUrl, types.ScopeUrlsWrite),
+	)
+	urlRouter.Methods("GET").Path("/{slug:[0-9A-Za-z]+}/visits").Handler(
+		authMiddleware.RequireScopes(urlHandler.GetUrlVisits, types.ScopeStatsRead),
+	)
+	urlRouter.Methods("GET").Path("/{slug:[0-9A-Za-z]+}/stats").Handler(
+		authMiddleware.RequireScopes(urlHandler.GetUrlStats, types.ScopeStatsRead),
+	)
+	urlRouter.Methods("GET").Path("/{slug:[0-9A-Za-z]+}/breakdown/{dimension}").Handler(
+		authMiddleware.RequireScopes(urlHandler.GetUrlBreakdown, types.ScopeStatsRead),
+	)
+	urlRouter.Methods("GET").Path("/{slug:[0-9A-Za-z]+}/geo").Handler(
+		authMiddleware.RequireScopes(urlHandler.GetUrlGeoBreakdown, types.ScopeStatsRead),
+	)
+	urlRouter.Methods("GET").Path("/breakdown/{dimension}").Handler(
+		authMiddleware.RequireScopes(urlHandler.GetAccountBreakdown, types.ScopeStatsRead),
+	)
 
 	authRouter := dashboardRouter.PathPrefix("/auth").Subrouter()
 	authRouter.Path("/login").Methods("POST").HandlerFunc(authHandler.Login)
+	authRouter.Path("/login/two-factor").Methods("POST").HandlerFunc(authHandler.CompleteTwoFactorLogin)
+	authRouter.Path("/sso/start").Methods("POST").HandlerFunc(authHandler.BeginSSOLogin)
+	authRouter.Path("/sso/callback").Methods("POST").HandlerFunc(authHandler.CompleteSSOLogin)
 	authRouter.Path("/register").Methods("POST").HandlerFunc(authHandler.Register)
 	authRouter.Path("/renew").Methods("POST").HandlerFunc(authHandler.RenewAccessToken)
+	authRouter.Path("/verify").Methods("POST").HandlerFunc(authHandler.VerifyEMail)
+	authRouter.Path("/verify/resend").Methods("POST").Handler(
+		authMiddleware.Intercept(authMiddleware.RequireSession(http.HandlerFunc(authHandler.ResendVerificationEMail))),
+	)
+	authRouter.Path("/password/forgot").Methods("POST").HandlerFunc(authHandler.RequestPasswordReset)
+	authRouter.Path("/password/reset").Methods("POST").HandlerFunc(authHandler.ResetPassword)
+	authRouter.Path("/logout").Methods("POST").Handler(
+		authMiddleware.Intercept(authMiddleware.RequireSession(http.HandlerFunc(authHandler.Logout))),
+	)
+
+	authRouter.Path("/email/confirm").Methods("POST").HandlerFunc(authHandler.ConfirmEMailChange)
 
-	router.Host(config.Config.ShortUrlHost).Methods("GET").Path("/{slug:[0-9A-Za-z]+}").HandlerFunc(urlHandler.GetOriginalUrl)
+	accountRouter := authRouter.PathPrefix("/account").Subrouter()
+	accountRouter.Use(authMiddleware.Intercept, authMiddleware.RequireSession)
+	accountRouter.Methods("POST").Path("/password").HandlerFunc(authHandler.ChangePassword)
+	accountRouter.Methods("POST").Path("/email").HandlerFunc(authHandler.RequestEMailChange)
+	accountRouter.Methods("DELETE").Path("").HandlerFunc(authHandler.DeleteAccount)
 
-	return router
+	sessionRouter := authRouter.PathPrefix("/sessions").Subrouter()
+	sessionRouter.Use(authMiddleware.Intercept, authMiddleware.RequireSession)
+	sessionRouter.Methods("GET").Path("").HandlerFunc(authHandler.GetSessions)
+	sessionRouter.Methods("DELETE").Path("").HandlerFunc(authHandler.RevokeAllSessions)
+	sessionRouter.Methods("DELETE").Path("/{sessionID:[0-9]+}").HandlerFunc(authHandler.RevokeSession)
+
+	apiKeyRouter := authRouter.PathPrefix("/api-keys").Subrouter()
+	apiKeyRouter.Use(authMiddleware.Intercept, authMiddleware.RequireSession)
+	apiKeyRouter.Methods("GET").Path("").HandlerFunc(authHandler.GetAPIKeys)
+	apiKeyRouter.Methods("POST").Path("").HandlerFunc(authHandler.CreateAPIKey)
+	apiKeyRouter.Methods("DELETE").Path("/{apiKeyID:[0-9]+}").HandlerFunc(authHandler.RevokeAPIKey)
+
+	twoFactorRouter := authRouter.PathPrefix("/two-factor").Subrouter()
+	twoFactorRouter.Use(authMiddleware.Intercept, authMiddleware.RequireSession)
+	twoFactorRouter.Methods("POST").Path("/enrollment").HandlerFunc(authHandler.BeginTwoFactorEnrollment)
+	twoFactorRouter.Methods("POST").Path("/enrollment/confirm").HandlerFunc(authHandler.ConfirmTwoFactorEnrollment)
+	twoFactorRouter.Methods("DELETE").Path("").HandlerFunc(authHandler.DisableTwoFactor)
+
+	exportRouter := dashboardRouter.PathPrefix("/export").Subrouter()
+	// download links are signed, so that they work without logging in
+	exportRouter.Methods("GET").Path("/download").HandlerFunc(exportHandler.DownloadExport)
+	exportRouter.Methods("POST").Path("").Handler(
+		authMiddleware.Intercept(authMiddleware.RequireSession(http.HandlerFunc(exportHandler.RequestExport))),
+	)
+	exportRouter.Methods("GET").Path("/{exportID:[0-9]+}").Handler(
+		authMiddleware.Intercept(authMiddleware.RequireSession(http.HandlerFunc(exportHandler.GetExport))),
+	)
+
+	shortUrlRouter := router.Host(config.Config.ShortUrlHost).Subrouter()
+	shortUrlRouter.Methods("GET", "HEAD").Path("/{slug:[0-9A-Za-z]+}").HandlerFunc(urlHandler.GetOriginalUrl)
+	shortUrlRouter.Methods("POST").Path("/{slug:[0-9A-Za-z]+}").HandlerFunc(urlHandler.UnlockUrl)
+
+	return router, nil
+}
+
+// parseTrustedProxies accepts addresses as well as CIDR ranges.
+func parseTrustedProxies(list string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, proxy := range strings.Split(list, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted proxy %q is not an ip address or CIDR range", proxy)
+			}
+			if v4 := ip.To4(); v4 != nil {
+				ip = v4
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q is not an ip address or CIDR range", proxy)
+		}
+		proxies = append(proxies, network)
+	}
+
+	return proxies, nil
 }
 
 func provideAuthenticationAPI(logger log.Logger, authenticationService authentication.Service) presentation.AuthenticationAPI {
 	return presentation.NewAuthenticationAPIV1(logger, authenticationService)
 }
 
-func provideUrlAPI(logger log.Logger, urlService url.Service) presentation.UrlAPI {
-	return presentation.NewUrlAPIV1(logger, urlService)
+func provideExportAPI(logger log.Logger, exportService export.Service) presentation.ExportAPI {
+	return presentation.NewExportAPIV1(logger, exportService)
+}
+
+func provideUrlAPI(
+	logger log.Logger,
+	urlService url.Service,
+	visitorService visitor.Service,
+	locator geoip.Locator,
+	botDetector botdetect.Detector,
+) presentation.UrlAPI {
+	return presentation.NewUrlAPIV1(logger, urlService, visitorService, locator, botDetector)
+}
+
+func provideBotDetector() botdetect.Detector {
+	return botdetect.NewDetectorV1(strings.Split(config.Config.BotUserAgentSignatures, ","))
+}
+
+func provideGeoIPLocator(logger log.Logger) (geoip.Locator, func()) {
+	if config.Config.GeoIPDatabasePath == "" {
+		return geoip.NewNoopLocator(), func() {}
+	}
+
+	locator, err := geoip.NewMaxMindLocatorV1(config.Config.GeoIPDatabasePath)
+	if errors.Is(err, geoip.ErrDatabaseNotFound) {
+		logger.Warn("geoip database is missing. visits will not be located", map[string]interface{}{
+			"path": config.Config.GeoIPDatabasePath,
+		})
+		return geoip.NewNoopLocator(), func() {}
+	}
+	if err != nil {
+		logger.Error("failed to load geoip database. visits will not be located", map[string]interface{}{
+			"path":         config.Config.GeoIPDatabasePath,
+			"errorMessage": err.Error(),
+		})
+		return geoip.NewNoopLocator(), func() {}
+	}
+
+	return locator, func() {
+		if err := locator.Close(); err != nil {
+			logger.Warn("error while closing geoip database", map[string]interface{}{
+				"errorMessage": err.Error(),
+			})
+		}
+	}
 }
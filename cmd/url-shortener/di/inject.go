@@ -53,16 +53,26 @@ func Inject() (*App, func(), error) {
 
 		provideHTTPServer, provideMuxRouter,
 		provideAuthenticationAPI,
-		provideUrlAPI,
+		provideUrlAPI, provideGeoIPLocator, provideBotDetector,
+		provideExportAPI,
 
-		provideAuthenticationService, provideAccessTokenSecrets,
+		provideAuthenticationService, provideAccessTokenKeyring, provideRefreshTokenHashKey, provideMailer,
+		provideOIDCProvider, provideBreachedPasswordSource,
 		provideUrlService,
+		provideVisitRecorder,
+		provideVisitorService,
+		provideExportService,
 
 		provideLogger,
 
 		provideSQLXConnection,
-		provideAccountRepository, provideRefreshTokenRepository,
-		provideUrlRepository,
+		provideAccountRepository, provideRefreshTokenRepository, provideAccountTokenRepository,
+		provideAPIKeyRepository,
+		provideTwoFactorRepository,
+		provideLoginAttemptRepository,
+		provideRevocationRepository,
+		provideUrlRepository, provideVisitRepository, provideVisitorRepository,
+		provideDataExportRepository,
 
 		provideRedisClient,
 	)
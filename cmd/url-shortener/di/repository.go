@@ -8,8 +8,16 @@ import (
 	"github.com/h3isenbug/url-shortener/internal/config"
 	"github.com/h3isenbug/url-shortener/internal/monitoring"
 	"github.com/h3isenbug/url-shortener/internal/repository/account"
+	"github.com/h3isenbug/url-shortener/internal/repository/accountToken"
+	"github.com/h3isenbug/url-shortener/internal/repository/apiKey"
+	"github.com/h3isenbug/url-shortener/internal/repository/dataExport"
+	"github.com/h3isenbug/url-shortener/internal/repository/loginAttempt"
 	"github.com/h3isenbug/url-shortener/internal/repository/refreshToken"
+	"github.com/h3isenbug/url-shortener/internal/repository/revocation"
+	"github.com/h3isenbug/url-shortener/internal/repository/twoFactor"
 	"github.com/h3isenbug/url-shortener/internal/repository/url"
+	"github.com/h3isenbug/url-shortener/internal/repository/visit"
+	"github.com/h3isenbug/url-shortener/internal/repository/visitor"
 	"github.com/h3isenbug/url-shortener/internal/types"
 	"github.com/h3isenbug/url-shortener/pkg/log"
 	"github.com/jmoiron/sqlx"
@@ -61,6 +69,30 @@ func provideAccountRepository(connection *sqlx.DB, metricCollector monitoring.Me
 	)
 }
 
+func provideAccountTokenRepository(connection *sqlx.DB, metricCollector monitoring.MetricCollector) accountToken.Repository {
+	return accountToken.NewMetricWrapper(
+		accountToken.NewPostgresRepositoryV1(connection),
+		metricCollector,
+		"AccountTokenRepositoryPostgres",
+	)
+}
+
+func provideAPIKeyRepository(connection *sqlx.DB, metricCollector monitoring.MetricCollector) apiKey.Repository {
+	return apiKey.NewMetricWrapper(
+		apiKey.NewPostgresRepositoryV1(connection),
+		metricCollector,
+		"APIKeyRepositoryPostgres",
+	)
+}
+
+func provideTwoFactorRepository(connection *sqlx.DB, metricCollector monitoring.MetricCollector) twoFactor.Repository {
+	return twoFactor.NewMetricWrapper(
+		twoFactor.NewPostgresRepositoryV1(connection),
+		metricCollector,
+		"TwoFactorRepositoryPostgres",
+	)
+}
+
 func provideRefreshTokenRepository(connection *sqlx.DB, metricCollector monitoring.MetricCollector) refreshToken.Repository {
 	return refreshToken.NewMetricWrapper(
 		refreshToken.NewPostgresRepositoryV1(connection),
@@ -69,6 +101,35 @@ func provideRefreshTokenRepository(connection *sqlx.DB, metricCollector monitori
 	)
 }
 
+func provideRevocationRepository(redisClient *redis.Client, metricCollector monitoring.MetricCollector) revocation.Repository {
+	redisLayer := revocation.NewMetricWrapper(
+		revocation.NewRedisRepositoryV1(redisClient),
+		metricCollector,
+		"RevocationRepositoryRedis",
+	)
+
+	return revocation.NewInProcessCacheV1(
+		time.Second*time.Duration(config.Config.AccessTokenRevocationCacheTTLSeconds),
+		redisLayer,
+	)
+}
+
+func provideLoginAttemptRepository(redisClient *redis.Client, metricCollector monitoring.MetricCollector) loginAttempt.Repository {
+	return loginAttempt.NewMetricWrapper(
+		loginAttempt.NewRedisRepositoryV1(redisClient),
+		metricCollector,
+		"LoginAttemptRepositoryRedis",
+	)
+}
+
+func provideDataExportRepository(connection *sqlx.DB, metricCollector monitoring.MetricCollector) dataExport.Repository {
+	return dataExport.NewMetricWrapper(
+		dataExport.NewPostgresRepositoryV1(connection),
+		metricCollector,
+		"DataExportRepositoryPostgres",
+	)
+}
+
 func provideUrlRepository(
 	logger log.Logger, connection *sqlx.DB, redisClient *redis.Client,
 	metricCollector monitoring.MetricCollector,
@@ -90,3 +151,19 @@ func provideUrlRepository(
 		"UrlRepositoryRedis",
 	)
 }
+
+func provideVisitRepository(connection *sqlx.DB, metricCollector monitoring.MetricCollector) visit.Repository {
+	return visit.NewMetricWrapper(
+		visit.NewPostgresRepositoryV1(connection, config.Config.ItemsPerPage),
+		metricCollector,
+		"VisitRepositoryPostgres",
+	)
+}
+
+func provideVisitorRepository(redisClient *redis.Client, metricCollector monitoring.MetricCollector) visitor.Repository {
+	return visitor.NewMetricWrapper(
+		visitor.NewRedisRepositoryV1(redisClient),
+		metricCollector,
+		"VisitorRepositoryRedis",
+	)
+}
@@ -2,65 +2,210 @@ package di
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
-	"os"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/h3isenbug/url-shortener/internal/config"
 	"github.com/h3isenbug/url-shortener/internal/repository/account"
+	accountTokenRepository "github.com/h3isenbug/url-shortener/internal/repository/accountToken"
+	apiKeyRepository "github.com/h3isenbug/url-shortener/internal/repository/apiKey"
+	loginAttemptRepository "github.com/h3isenbug/url-shortener/internal/repository/loginAttempt"
 	refreshTokenRepository "github.com/h3isenbug/url-shortener/internal/repository/refreshToken"
+	revocationRepository "github.com/h3isenbug/url-shortener/internal/repository/revocation"
+	twoFactorRepository "github.com/h3isenbug/url-shortener/internal/repository/twoFactor"
+	urlRepository "github.com/h3isenbug/url-shortener/internal/repository/url"
 	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/breach"
+	"github.com/h3isenbug/url-shortener/pkg/jwk"
 	"github.com/h3isenbug/url-shortener/pkg/log"
-	"gopkg.in/yaml.v2"
+	"github.com/h3isenbug/url-shortener/pkg/mail"
+	"github.com/h3isenbug/url-shortener/pkg/oidc"
 )
 
-type accessTokenSecretsType map[string][]byte
-
-func provideAccessTokenSecrets() (accessTokenSecretsType, error) {
-	file, err := os.Open(config.Config.AccessTokenSecretFile)
+func provideAccessTokenKeyring(logger log.Logger) (jwk.Keyring, func(), error) {
+	keyring, err := jwk.NewFileKeyring(
+		logger,
+		config.Config.AccessTokenSecretFile,
+		config.Config.AccessTokenCurrentKID,
+		time.Duration(config.Config.AccessTokenSecretFilePollIntervalSeconds)*time.Second,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read auth token secrets: %w", err)
-	}
-	defer file.Close()
-
-	var rawSecretsFile struct {
-		Secrets []struct {
-			Name  string `yaml:"name"`
-			Value string `yaml:"value"`
-		} `yaml:"secrets"`
+		return nil, nil, fmt.Errorf("failed to load auth token secrets: %w", err)
 	}
 
-	if err := yaml.NewDecoder(file).Decode(&rawSecretsFile); err != nil {
-		return nil, fmt.Errorf("auth token secret file is not a valid yaml file: %w", err)
-	}
+	return keyring, keyring.Close, nil
+}
 
-	secrets := make(accessTokenSecretsType)
+type refreshTokenHashKeyType []byte
 
-	for _, secret := range rawSecretsFile.Secrets {
-		decoded, err := base64.StdEncoding.DecodeString(secret.Value)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse secret %s: %w", secret.Name, err)
-		}
+const minRefreshTokenHashKeyLength = 32
 
-		secrets[secret.Name] = decoded
+func provideRefreshTokenHashKey() (refreshTokenHashKeyType, error) {
+	key, err := base64.StdEncoding.DecodeString(config.Config.RefreshTokenHashKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse refresh token hash key: %w", err)
+	}
+	if len(key) < minRefreshTokenHashKeyLength {
+		return nil, fmt.Errorf("refresh token hash key must be at least %d bytes long", minRefreshTokenHashKeyLength)
 	}
 
-	return secrets, nil
+	return key, nil
+}
+
+func loginThrottlePolicy(freeAttempts, lockoutThreshold int) types.LoginThrottlePolicy {
+	return types.LoginThrottlePolicy{
+		FreeAttempts:     freeAttempts,
+		BaseDelay:        time.Duration(config.Config.LoginBaseDelaySeconds) * time.Second,
+		LockoutThreshold: lockoutThreshold,
+		LockoutDuration:  time.Duration(config.Config.LoginLockoutSeconds) * time.Second,
+		FailureWindow:    time.Duration(config.Config.LoginFailureWindowSeconds) * time.Second,
+	}
 }
+
 func provideAuthenticationService(
 	logger log.Logger,
 	accountRepository account.Repository,
 	refreshTokenRepository refreshTokenRepository.Repository,
-	accessTokenSecrets accessTokenSecretsType,
-) authentication.Service {
+	accountTokenRepository accountTokenRepository.Repository,
+	revocationRepository revocationRepository.Repository,
+	apiKeyRepository apiKeyRepository.Repository,
+	twoFactorRepository twoFactorRepository.Repository,
+	loginAttemptRepository loginAttemptRepository.Repository,
+	urlRepository urlRepository.Repository,
+	mailer mail.Mailer,
+	oidcProvider oidc.Provider,
+	accessTokenKeyring jwk.Keyring,
+	refreshTokenHashKey refreshTokenHashKeyType,
+	breachedPasswords breach.Source,
+) (authentication.Service, error) {
+	deletionPolicy := types.AccountDeletionPolicy(config.Config.AccountDeletionPolicy)
+	if !deletionPolicy.IsValid() {
+		return nil, fmt.Errorf("unknown account deletion policy %q", config.Config.AccountDeletionPolicy)
+	}
+	if deletionPolicy == types.AccountDeletionPolicyTransfer && config.Config.AccountDeletionTransferAccountID <= 0 {
+		return nil, fmt.Errorf("account deletion transfer account id is required for the transfer policy")
+	}
+
+	passwordPolicy, err := providePasswordPolicy()
+	if err != nil {
+		return nil, err
+	}
+
 	return authentication.NewAuthenticationServiceV1(
 		logger,
 		accountRepository,
 		refreshTokenRepository,
-		config.Config.RefreshTokenLength,
-		time.Duration(config.Config.RefreshTokenLifespanSeconds)*time.Second,
-		time.Duration(config.Config.AccessTokenLifespanSeconds)*time.Second,
-		accessTokenSecrets,
-		config.Config.AccessTokenCurrentKID,
+		accountTokenRepository,
+		revocationRepository,
+		apiKeyRepository,
+		twoFactorRepository,
+		loginAttemptRepository,
+		urlRepository,
+		mailer,
+		refreshTokenHashKey,
+		accessTokenKeyring,
+		oidcProvider,
+		breachedPasswords,
+		authentication.Config{
+			RefreshTokenLength:         config.Config.RefreshTokenLength,
+			RefreshTokenLifespan:       time.Duration(config.Config.RefreshTokenLifespanSeconds) * time.Second,
+			AccessTokenLifespan:        time.Duration(config.Config.AccessTokenLifespanSeconds) * time.Second,
+			VerificationTokenLifespan:  time.Duration(config.Config.EMailVerificationTokenLifespanSeconds) * time.Second,
+			VerificationURL:            config.Config.EMailVerificationURL,
+			PasswordResetTokenLifespan: time.Duration(config.Config.PasswordResetTokenLifespanSeconds) * time.Second,
+			PasswordResetURL:           config.Config.PasswordResetURL,
+			EMailChangeURL:             config.Config.EMailChangeURL,
+			TwoFactorIssuer:            config.Config.TwoFactorIssuer,
+			TwoFactorChallengeLifespan: time.Duration(config.Config.TwoFactorChallengeLifespanSeconds) * time.Second,
+			SSOFlowLifespan:            time.Duration(config.Config.OIDCFlowLifespanSeconds) * time.Second,
+			AccountLoginThrottle: loginThrottlePolicy(
+				config.Config.LoginAccountFreeAttempts, config.Config.LoginAccountLockoutThreshold,
+			),
+			ClientIPLoginThrottle: loginThrottlePolicy(
+				config.Config.LoginClientIPFreeAttempts, config.Config.LoginClientIPLockoutThreshold,
+			),
+			AccountDeletionPolicy:     deletionPolicy,
+			AccountDeletionTransferTo: uint64(config.Config.AccountDeletionTransferAccountID),
+			PasswordPolicy:            passwordPolicy,
+		},
+	), nil
+}
+
+func providePasswordPolicy() (types.PasswordPolicy, error) {
+	policy := types.PasswordPolicy{
+		MinLength: config.Config.PasswordMinLength,
+		MaxLength: config.Config.PasswordMaxLength,
+	}
+	if policy.MinLength < 1 || policy.MinLength > policy.MaxLength {
+		return types.PasswordPolicy{}, fmt.Errorf("password min length must be between 1 and the max length")
+	}
+	if policy.MaxLength > types.MaxPasswordLength {
+		return types.PasswordPolicy{}, fmt.Errorf("password max length can not be more than %d bytes", types.MaxPasswordLength)
+	}
+
+	for _, password := range strings.Split(config.Config.PasswordBlocklist, ",") {
+		if password = strings.TrimSpace(password); password != "" {
+			policy.Blocklist = append(policy.Blocklist, password)
+		}
+	}
+
+	return policy, nil
+}
+
+func provideBreachedPasswordSource(logger log.Logger) (breach.Source, func()) {
+	if config.Config.BreachedPasswordsPath == "" {
+		return breach.NewNoopSource(), func() {}
+	}
+
+	source, err := breach.NewFileSourceV1(config.Config.BreachedPasswordsPath)
+	if errors.Is(err, breach.ErrListNotFound) {
+		logger.Warn("breached password list is missing. passwords will not be checked against it", map[string]interface{}{
+			"path": config.Config.BreachedPasswordsPath,
+		})
+		return breach.NewNoopSource(), func() {}
+	}
+	if err != nil {
+		logger.Error("failed to load breached password list. passwords will not be checked against it", map[string]interface{}{
+			"path":         config.Config.BreachedPasswordsPath,
+			"errorMessage": err.Error(),
+		})
+		return breach.NewNoopSource(), func() {}
+	}
+
+	return source, func() {
+		if err := source.Close(); err != nil {
+			logger.Warn("error while closing breached password list", map[string]interface{}{
+				"errorMessage": err.Error(),
+			})
+		}
+	}
+}
+
+func provideMailer() mail.Mailer {
+	return mail.NewSMTPMailerV1(
+		config.Config.SMTPHost,
+		config.Config.SMTPPort,
+		config.Config.SMTPUsername,
+		config.Config.SMTPPassword,
+		config.Config.MailFrom,
+	)
+}
+
+// provideOIDCProvider returns nil when single sign-on is disabled.
+func provideOIDCProvider() oidc.Provider {
+	if !config.Config.OIDCEnabled {
+		return nil
+	}
+
+	return oidc.NewProviderV1(
+		config.Config.OIDCIssuer,
+		config.Config.OIDCClientID,
+		config.Config.OIDCClientSecret,
+		config.Config.OIDCRedirectURL,
+		&http.Client{Timeout: time.Duration(config.Config.OIDCRequestTimeoutSeconds) * time.Second},
 	)
 }
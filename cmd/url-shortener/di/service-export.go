@@ -0,0 +1,66 @@
+package di
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/config"
+	accountRepository "github.com/h3isenbug/url-shortener/internal/repository/account"
+	dataExportRepository "github.com/h3isenbug/url-shortener/internal/repository/dataExport"
+	refreshTokenRepository "github.com/h3isenbug/url-shortener/internal/repository/refreshToken"
+	urlRepository "github.com/h3isenbug/url-shortener/internal/repository/url"
+	visitRepository "github.com/h3isenbug/url-shortener/internal/repository/visit"
+	"github.com/h3isenbug/url-shortener/internal/service/export"
+	"github.com/h3isenbug/url-shortener/pkg/log"
+	"github.com/h3isenbug/url-shortener/pkg/signer"
+)
+
+// publishedDataExportSecret shipped in the sample configuration, so download links signed with it can be made up by
+// anyone.
+var publishedDataExportSecret = []byte("export-secret-for-download-links")
+
+func provideExportService(
+	logger log.Logger,
+	dataExportRepository dataExportRepository.Repository,
+	accountRepository accountRepository.Repository,
+	urlRepository urlRepository.Repository,
+	visitRepository visitRepository.Repository,
+	refreshTokenRepository refreshTokenRepository.Repository,
+) (export.Service, func(), error) {
+	downloadTokenSigner, err := signer.NewHMACSignerV1(config.Config.DataExportSecret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid data export secret: %w", err)
+	}
+	if bytes.Equal(config.Config.DataExportSecret, publishedDataExportSecret) {
+		return nil, nil, fmt.Errorf("data export secret must not be the one from the sample configuration")
+	}
+
+	service := export.NewExportServiceV1(
+		logger,
+		dataExportRepository,
+		accountRepository,
+		urlRepository,
+		visitRepository,
+		refreshTokenRepository,
+		downloadTokenSigner,
+		config.Config.DataExportDownloadURL,
+		time.Duration(config.Config.DataExportLifespanSeconds)*time.Second,
+		time.Duration(config.Config.DataExportPollIntervalSeconds)*time.Second,
+	)
+
+	return service, func() {
+		ctx, cancel := context.WithTimeout(
+			context.Background(),
+			time.Duration(config.Config.GracefulShutdownPeriodSeconds)*time.Second,
+		)
+		defer cancel()
+
+		if err := service.Drain(ctx); err != nil {
+			logger.Warn("error while waiting for data export to be built", map[string]interface{}{
+				"errorMessage": err.Error(),
+			})
+		}
+	}, nil
+}
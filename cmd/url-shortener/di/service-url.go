@@ -1,19 +1,63 @@
 package di
 
 import (
+	"bytes"
+	"fmt"
+	"time"
+
 	"github.com/h3isenbug/url-shortener/internal/config"
+	accountRepository "github.com/h3isenbug/url-shortener/internal/repository/account"
+	loginAttemptRepository "github.com/h3isenbug/url-shortener/internal/repository/loginAttempt"
 	urlRepository "github.com/h3isenbug/url-shortener/internal/repository/url"
+	visitRepository "github.com/h3isenbug/url-shortener/internal/repository/visit"
 	"github.com/h3isenbug/url-shortener/internal/service/url"
+	"github.com/h3isenbug/url-shortener/internal/service/visit"
+	"github.com/h3isenbug/url-shortener/internal/service/visitor"
+	"github.com/h3isenbug/url-shortener/internal/types"
 	"github.com/h3isenbug/url-shortener/pkg/log"
+	"github.com/h3isenbug/url-shortener/pkg/signer"
 )
 
+// publishedUrlUnlockSecret shipped in the sample configuration, so unlock tokens signed with it can be made up by
+// anyone.
+var publishedUrlUnlockSecret = []byte("cGFcfWyFvsbOdrwmMIYpfKqtqpaMwbRI")
+
 func provideUrlService(
 	logger log.Logger,
+	accountRepository accountRepository.Repository,
 	urlRepository urlRepository.Repository,
-) url.Service {
+	visitRepository visitRepository.Repository,
+	visitRecorder visit.Recorder,
+	visitorService visitor.Service,
+	loginAttemptRepository loginAttemptRepository.Repository,
+) (url.Service, error) {
+	botPolicy := types.BotPolicy(config.Config.BotVisitPolicy)
+	if !botPolicy.IsValid() {
+		return nil, fmt.Errorf("unknown bot visit policy: %s", config.Config.BotVisitPolicy)
+	}
+
+	unlockTokenSigner, err := signer.NewHMACSignerV1(config.Config.UrlUnlockSecret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url unlock secret: %w", err)
+	}
+	if bytes.Equal(config.Config.UrlUnlockSecret, publishedUrlUnlockSecret) {
+		return nil, fmt.Errorf("url unlock secret must not be the one from the sample configuration")
+	}
+
 	return url.NewUrlServiceV1(
 		logger,
+		accountRepository,
 		urlRepository,
+		visitRepository,
+		visitRecorder,
+		visitorService,
 		config.Config.RandomSlugLength,
-	)
+		unlockTokenSigner,
+		time.Duration(config.Config.UrlUnlockLifespanSeconds)*time.Second,
+		loginAttemptRepository,
+		loginThrottlePolicy(config.Config.UrlUnlockFreeAttempts, config.Config.UrlUnlockLockoutThreshold),
+		config.Config.VisitIPAnonymizationEnabled,
+		botPolicy,
+		config.Config.EMailVerificationRequired,
+	), nil
 }
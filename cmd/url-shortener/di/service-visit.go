@@ -0,0 +1,23 @@
+package di
+
+import (
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/config"
+	visitRepository "github.com/h3isenbug/url-shortener/internal/repository/visit"
+	"github.com/h3isenbug/url-shortener/internal/service/visit"
+	"github.com/h3isenbug/url-shortener/pkg/log"
+)
+
+func provideVisitRecorder(
+	logger log.Logger,
+	visitRepository visitRepository.Repository,
+) visit.Recorder {
+	return visit.NewAsyncRecorderV1(
+		logger,
+		visitRepository,
+		config.Config.VisitBufferSize,
+		config.Config.VisitBatchSize,
+		time.Duration(config.Config.VisitFlushIntervalMilliseconds)*time.Millisecond,
+	)
+}
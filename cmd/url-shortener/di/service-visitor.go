@@ -0,0 +1,30 @@
+package di
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/config"
+	visitorRepository "github.com/h3isenbug/url-shortener/internal/repository/visitor"
+	"github.com/h3isenbug/url-shortener/internal/service/visitor"
+	"github.com/h3isenbug/url-shortener/pkg/signer"
+)
+
+func provideVisitorService(visitorRepository visitorRepository.Repository) (visitor.Service, error) {
+	mode := visitor.Mode(config.Config.VisitorTrackingMode)
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("unknown visitor tracking mode: %s", config.Config.VisitorTrackingMode)
+	}
+
+	cookieSigner, err := signer.NewHMACSignerV1(config.Config.VisitorCookieSecret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid visitor cookie secret: %w", err)
+	}
+
+	return visitor.NewVisitorServiceV1(
+		visitorRepository,
+		mode,
+		cookieSigner,
+		time.Duration(config.Config.VisitorCookieLifespanSeconds)*time.Second,
+	), nil
+}
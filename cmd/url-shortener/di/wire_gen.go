@@ -26,22 +26,88 @@ func Inject() (*App, func(), error) {
 	metricCollector, cleanup2 := provideMetricCollector()
 	repository := provideAccountRepository(db, metricCollector)
 	refreshTokenRepository := provideRefreshTokenRepository(db, metricCollector)
-	diAccessTokenSecretsType, err := provideAccessTokenSecrets()
+	accountTokenRepository := provideAccountTokenRepository(db, metricCollector)
+	client := provideRedisClient()
+	revocationRepository := provideRevocationRepository(client, metricCollector)
+	apiKeyRepository := provideAPIKeyRepository(db, metricCollector)
+	twoFactorRepository := provideTwoFactorRepository(db, metricCollector)
+	loginAttemptRepository := provideLoginAttemptRepository(client, metricCollector)
+	urlRepository := provideUrlRepository(logger, db, client, metricCollector)
+	mailer := provideMailer()
+	provider := provideOIDCProvider()
+	keyring, cleanup3, err := provideAccessTokenKeyring(logger)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	diRefreshTokenHashKeyType, err := provideRefreshTokenHashKey()
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	source, cleanup4 := provideBreachedPasswordSource(logger)
+	service, err := provideAuthenticationService(logger, repository, refreshTokenRepository, accountTokenRepository, revocationRepository, apiKeyRepository, twoFactorRepository, loginAttemptRepository, urlRepository, mailer, provider, keyring, diRefreshTokenHashKeyType, source)
 	if err != nil {
+		cleanup4()
+		cleanup3()
 		cleanup2()
 		cleanup()
 		return nil, nil, err
 	}
-	service := provideAuthenticationService(logger, repository, refreshTokenRepository, diAccessTokenSecretsType)
 	authenticationAPI := provideAuthenticationAPI(logger, service)
-	client := provideRedisClient()
-	urlRepository := provideUrlRepository(logger, db, client, metricCollector)
-	urlService := provideUrlService(logger, urlRepository)
-	urlAPI := provideUrlAPI(logger, urlService)
-	router := provideMuxRouter(logger, service, authenticationAPI, urlAPI, metricCollector)
-	server, cleanup3 := provideHTTPServer(logger, router)
+	visitRepository := provideVisitRepository(db, metricCollector)
+	recorder := provideVisitRecorder(logger, visitRepository)
+	visitorRepository := provideVisitorRepository(client, metricCollector)
+	visitorService, err := provideVisitorService(visitorRepository)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	urlService, err := provideUrlService(logger, repository, urlRepository, visitRepository, recorder, visitorService, loginAttemptRepository)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	locator, cleanup5 := provideGeoIPLocator(logger)
+	detector := provideBotDetector()
+	urlAPI := provideUrlAPI(logger, urlService, visitorService, locator, detector)
+	dataExportRepository := provideDataExportRepository(db, metricCollector)
+	exportService, cleanup6, err := provideExportService(logger, dataExportRepository, repository, urlRepository, visitRepository, refreshTokenRepository)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	exportAPI := provideExportAPI(logger, exportService)
+	router, err := provideMuxRouter(logger, service, authenticationAPI, urlAPI, exportAPI, metricCollector)
+	if err != nil {
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	server, cleanup7 := provideHTTPServer(logger, router, recorder)
 	app := provideApp(logger, server, metricCollector)
 	return app, func() {
+		cleanup7()
+		cleanup6()
+		cleanup5()
+		cleanup4()
 		cleanup3()
 		cleanup2()
 		cleanup()
@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	// the runtime image has no zoneinfo, and stats can be requested in any timezone.
+	_ "time/tzdata"
 
 	"github.com/h3isenbug/url-shortener/cmd/url-shortener/di"
 )
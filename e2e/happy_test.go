@@ -9,12 +9,22 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/h3isenbug/url-shortener/cmd/url-shortener/di"
 	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/jwk"
 	"github.com/stretchr/testify/suite"
 )
 
+// visitFlushGracePeriod gives the visit recorder time to flush buffered visits. it must be longer than
+// VISIT_FLUSH_INTERVAL_MILLISECONDS in test.env.
+const visitFlushGracePeriod = 500 * time.Millisecond
+
+// requests are made like a browser would make them, so that visits are not taken for those of a bot.
+const browserUserAgent = "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:93.0) Gecko/20100101 Firefox/93.0"
+
 type HappyTestSuite struct {
 	suite.Suite
 
@@ -56,6 +66,8 @@ func (s *HappyTestSuite) sendRequest(method, path, host, accessToken string, bod
 	if accessToken != "" {
 		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 	}
+	request.Header.Set("User-Agent", browserUserAgent)
+	request.Header.Set("Accept", "text/html,application/json")
 
 	return s.client.Do(request)
 }
@@ -206,6 +218,8 @@ func (s *HappyTestSuite) Test_04_VisitShortUrl() {
 }
 
 func (s *HappyTestSuite) Test_05_CheckUrlInDashboard() {
+	time.Sleep(visitFlushGracePeriod)
+
 	response, err := s.sendRequest(
 		"GET", "/api/url",
 		"short.ir", s.accessToken, nil,
@@ -377,6 +391,278 @@ func (s *HappyTestSuite) Test_10_CreateShortLinkWithoutRecommendedSlug() {
 	s.Equal(s.originalUrl, parsedResponse.OriginalUrl)
 }
 
+func (s *HappyTestSuite) Test_11_VisitLimitedShortUrl() {
+	body, err := json.Marshal(map[string]interface{}{
+		"originalUrl": s.originalUrl,
+		"maxVisits":   1,
+	})
+	s.Require().NoError(err)
+
+	response, err := s.sendRequest(
+		"POST", "/api/url",
+		"short.ir", s.accessToken, bytes.NewBuffer(body),
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusCreated, response.StatusCode)
+
+	var parsedResponse struct {
+		Slug string `json:"slug"`
+	}
+	s.Require().NoError(json.NewDecoder(response.Body).Decode(&parsedResponse))
+
+	response, err = s.sendRequest("GET", "/"+parsedResponse.Slug, "s3t.ir", "", nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusFound, response.StatusCode)
+
+	response, err = s.sendRequest("GET", "/"+parsedResponse.Slug, "s3t.ir", "", nil)
+	s.Require().NoError(err)
+	s.Require().Equal(
+		http.StatusGone,
+		response.StatusCode,
+		fmt.Sprintf(
+			"request failed with status code: %d",
+			response.StatusCode,
+		),
+	)
+}
+
+func (s *HappyTestSuite) Test_12_UpdateOriginalUrl() {
+	body, err := json.Marshal(map[string]interface{}{
+		"originalUrl": "https://duckduckgo.com/",
+	})
+	s.Require().NoError(err)
+
+	response, err := s.sendRequest(
+		"PATCH", "/api/url/"+s.slug,
+		"short.ir", s.accessToken, bytes.NewBuffer(body),
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	response, err = s.sendRequest("GET", "/"+s.slug, "s3t.ir", "", nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusFound, response.StatusCode)
+
+	location, err := response.Location()
+	s.Require().NoError(err)
+	s.Require().Equal("https://duckduckgo.com/", location.String())
+}
+
+func (s *HappyTestSuite) Test_13_RollbackOriginalUrl() {
+	response, err := s.sendRequest(
+		"GET", "/api/url/"+s.slug+"/revisions",
+		"short.ir", s.accessToken, nil,
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var parsedResponse struct {
+		Items      []types.UrlRevision `json:"items"`
+		NextCursor string              `json:"nextCursor"`
+	}
+	s.Require().NoError(json.NewDecoder(response.Body).Decode(&parsedResponse))
+	s.Require().Len(parsedResponse.Items, 1)
+	s.Equal(s.originalUrl, parsedResponse.Items[0].OriginalUrl)
+
+	response, err = s.sendRequest(
+		"POST", fmt.Sprintf("/api/url/%s/revisions/%d/rollback", s.slug, parsedResponse.Items[0].ID),
+		"short.ir", s.accessToken, nil,
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	response, err = s.sendRequest("GET", "/"+s.slug, "s3t.ir", "", nil)
+	s.Require().NoError(err)
+
+	location, err := response.Location()
+	s.Require().NoError(err)
+	s.Require().Equal(s.originalUrl, location.String())
+}
+
+func (s *HappyTestSuite) Test_14_BotVisitIsCountedSeparately() {
+	request, err := http.NewRequest("GET", fmt.Sprintf("http://s3t.ir:%s/%s", s.serverPort, s.slug), nil)
+	s.Require().NoError(err)
+	request.Header.Set("User-Agent", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)")
+	request.Header.Set("Accept", "*/*")
+
+	response, err := s.client.Do(request)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusFound, response.StatusCode)
+
+	time.Sleep(visitFlushGracePeriod)
+
+	response, err = s.sendRequest("GET", "/api/url", "short.ir", s.accessToken, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var parsedResponse struct {
+		Items []types.Url `json:"items"`
+	}
+	s.Require().NoError(json.NewDecoder(response.Body).Decode(&parsedResponse))
+
+	for _, item := range parsedResponse.Items {
+		if item.Slug == s.slug {
+			s.EqualValues(1, item.BotVisits)
+			return
+		}
+	}
+	s.Fail("url is missing from the dashboard")
+}
+
+func (s *HappyTestSuite) Test_15_VerifyEMailWithInvalidToken() {
+	response, err := s.sendRequest(
+		"POST", "/api/auth/verify", "short.ir", "", strings.NewReader(`{"token":"not-a-real-token"}`),
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func (s *HappyTestSuite) Test_16_ResendVerificationEMail() {
+	response, err := s.sendRequest("POST", "/api/auth/verify/resend", "short.ir", s.accessToken, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	response, err = s.sendRequest("POST", "/api/auth/verify/resend", "short.ir", "", nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+}
+
+func (s *HappyTestSuite) Test_17_PasswordReset() {
+	response, err := s.sendRequest(
+		"POST", "/api/auth/password/forgot", "short.ir", "", strings.NewReader(`{"email":"nobody@short.ir"}`),
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	response, err = s.sendRequest(
+		"POST", "/api/auth/password/reset", "short.ir", "",
+		strings.NewReader(`{"token":"not-a-real-token","password":"what does the fox say now?"}`),
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func (s *HappyTestSuite) Test_18_ListSessions() {
+	response, err := s.sendRequest("GET", "/api/auth/sessions", "short.ir", s.accessToken, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var parsedResponse struct {
+		Items []struct {
+			ID        uint64 `json:"id"`
+			UserAgent string `json:"userAgent"`
+			Current   bool   `json:"current"`
+		} `json:"items"`
+	}
+	s.Require().NoError(json.NewDecoder(response.Body).Decode(&parsedResponse))
+
+	for _, item := range parsedResponse.Items {
+		if item.Current {
+			s.Equal(browserUserAgent, item.UserAgent)
+			return
+		}
+	}
+	s.Fail("current session is missing from the session list")
+}
+
+func (s *HappyTestSuite) Test_19_APIKeys() {
+	body, err := json.Marshal(map[string]interface{}{
+		"name":   "ci",
+		"scopes": []string{"urls:read"},
+	})
+	s.Require().NoError(err)
+
+	response, err := s.sendRequest("POST", "/api/auth/api-keys", "short.ir", s.accessToken, bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusCreated, response.StatusCode)
+
+	var created struct {
+		ID  uint64 `json:"id"`
+		Key string `json:"key"`
+	}
+	s.Require().NoError(json.NewDecoder(response.Body).Decode(&created))
+
+	response, err = s.sendRequest("GET", "/api/url", "short.ir", created.Key, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	// the key was only granted read access to urls
+	response, err = s.sendRequest("GET", fmt.Sprintf("/api/url/%s/stats", s.slug), "short.ir", created.Key, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusForbidden, response.StatusCode)
+
+	var parsedResponse struct {
+		Message string `json:"message"`
+	}
+	s.Require().NoError(json.NewDecoder(response.Body).Decode(&parsedResponse))
+	s.Require().Equal("missing scope stats:read", parsedResponse.Message)
+
+	body, err = json.Marshal(map[string]string{"originalUrl": s.originalUrl})
+	s.Require().NoError(err)
+	response, err = s.sendRequest("POST", "/api/url", "short.ir", created.Key, bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusForbidden, response.StatusCode)
+
+	// keys can not mint other keys
+	response, err = s.sendRequest("GET", "/api/auth/api-keys", "short.ir", created.Key, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusForbidden, response.StatusCode)
+
+	response, err = s.sendRequest(
+		"DELETE", fmt.Sprintf("/api/auth/api-keys/%d", created.ID), "short.ir", s.accessToken, nil,
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	response, err = s.sendRequest("GET", "/api/url", "short.ir", created.Key, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+}
+
+func (s *HappyTestSuite) Test_20_VerifyAccessTokenWithPublishedKeys() {
+	response, err := s.sendRequest("GET", "/.well-known/jwks.json", "short.ir", "", nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	var keySet jwk.JSONWebKeySet
+	s.Require().NoError(json.NewDecoder(response.Body).Decode(&keySet))
+
+	publicKeys := make(map[string]interface{})
+	for _, key := range keySet.Keys {
+		publicKey, err := key.PublicKey()
+		s.Require().NoError(err)
+		publicKeys[key.KeyID] = publicKey
+	}
+
+	token, err := jwt.Parse(s.accessToken, func(token *jwt.Token) (interface{}, error) {
+		return publicKeys[token.Header["kid"].(string)], nil
+	})
+	s.Require().NoError(err)
+	s.Require().True(token.Valid)
+}
+
+// Test_21_Logout must stay the last test that needs a refresh token.
+func (s *HappyTestSuite) Test_21_Logout() {
+	response, err := s.sendRequest("POST", "/api/auth/logout", "short.ir", s.accessToken, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, response.StatusCode)
+
+	body, err := json.Marshal(map[string]string{
+		"oldAccessToken": s.accessToken,
+		"refreshToken":   s.refreshToken,
+	})
+	s.Require().NoError(err)
+
+	response, err = s.sendRequest("POST", "/api/auth/renew", "short.ir", "", bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+
+	// the access token dies with its session instead of living until it expires
+	response, err = s.sendRequest("GET", "/api/url", "short.ir", s.accessToken, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusUnauthorized, response.StatusCode)
+}
+
 func (s *HappyTestSuite) TearDownSuite() {
 	s.cleanup()
 	s.server.Close()
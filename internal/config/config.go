@@ -11,6 +11,9 @@ import (
 
 type config struct {
 	HTTPPort string `env:"HTTP_PORT"`
+	// TrustedProxies is a comma separated list of the addresses or CIDR ranges of reverse proxies in front of the
+	// service. X-Forwarded-For is only read from them, the client ip is the address of the peer otherwise.
+	TrustedProxies string `env:"TRUSTED_PROXIES"`
 
 	DatabaseHost       string `env:"DB_HOST"`
 	DatabasePort       int    `env:"DB_PORT"`
@@ -23,14 +26,111 @@ type config struct {
 
 	SentryDSN string `env:"SENTRY_DSN"`
 
-	RefreshTokenLength          int    `env:"REFRESH_TOKEN_LENGTH"`
-	RefreshTokenLifespanSeconds int    `env:"REFRESH_TOKEN_LIFESPAN_SECONDS"`
-	AccessTokenLifespanSeconds  int    `env:"ACCESS_TOKEN_LIFESPAN_SECONDS"`
-	AccessTokenSecretFile       string `env:"ACCESS_TOKEN_SECRET_FILE"`
-	AccessTokenCurrentKID       string `env:"ACCESS_TOKEN_CURRENT_KID"`
+	RefreshTokenLength          int `env:"REFRESH_TOKEN_LENGTH"`
+	RefreshTokenLifespanSeconds int `env:"REFRESH_TOKEN_LIFESPAN_SECONDS"`
+	// RefreshTokenHashKey is base64 encoded. refresh tokens hashed with another key can no longer be used.
+	RefreshTokenHashKey        string `env:"REFRESH_TOKEN_HASH_KEY"`
+	AccessTokenLifespanSeconds int    `env:"ACCESS_TOKEN_LIFESPAN_SECONDS"`
+	AccessTokenSecretFile      string `env:"ACCESS_TOKEN_SECRET_FILE"`
+	// AccessTokenCurrentKID is signed with unless the secret file names another key as current.
+	AccessTokenCurrentKID string `env:"ACCESS_TOKEN_CURRENT_KID"`
+	// the secret file is also reloaded on SIGHUP.
+	AccessTokenSecretFilePollIntervalSeconds int `env:"ACCESS_TOKEN_SECRET_FILE_POLL_INTERVAL_SECONDS"`
+	// AccessTokenRevocationCacheTTLSeconds bounds how long an instance may take to see revocations made by others.
+	AccessTokenRevocationCacheTTLSeconds int `env:"ACCESS_TOKEN_REVOCATION_CACHE_TTL_SECONDS"`
+
+	// SMTPUsername may be left empty for servers that do not require authentication(e.g. a local test server).
+	SMTPHost     string `env:"SMTP_HOST"`
+	SMTPPort     int    `env:"SMTP_PORT"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+	MailFrom     string `env:"MAIL_FROM"`
+
+	// EMailVerificationRequired bars accounts with unverified email addresses from creating short urls.
+	EMailVerificationRequired             bool `env:"EMAIL_VERIFICATION_REQUIRED"`
+	EMailVerificationTokenLifespanSeconds int  `env:"EMAIL_VERIFICATION_TOKEN_LIFESPAN_SECONDS"`
+	// EMailVerificationURL is the page verification links point to. the token is passed in the token query parameter.
+	EMailVerificationURL string `env:"EMAIL_VERIFICATION_URL"`
+
+	PasswordResetTokenLifespanSeconds int `env:"PASSWORD_RESET_TOKEN_LIFESPAN_SECONDS"`
+	// PasswordResetURL is the page reset links point to. the token is passed in the token query parameter.
+	PasswordResetURL string `env:"PASSWORD_RESET_URL"`
+	// EMailChangeURL is the page email change links point to. like the others, it gets the token as a parameter.
+	EMailChangeURL string `env:"EMAIL_CHANGE_URL"`
+
+	// AccountDeletionPolicy is one of anonymize, transfer and delete. the urls of deleted accounts are transferred
+	// to AccountDeletionTransferAccountID, which is only read for the transfer policy.
+	AccountDeletionPolicy            string `env:"ACCOUNT_DELETION_POLICY"`
+	AccountDeletionTransferAccountID int    `env:"ACCOUNT_DELETION_TRANSFER_ACCOUNT_ID"`
+
+	// DataExportSecret signs download links of data exports, which stay valid for DataExportLifespanSeconds.
+	DataExportSecret              []byte `env:"DATA_EXPORT_SECRET"`
+	DataExportDownloadURL         string `env:"DATA_EXPORT_DOWNLOAD_URL"`
+	DataExportLifespanSeconds     int    `env:"DATA_EXPORT_LIFESPAN_SECONDS"`
+	DataExportPollIntervalSeconds int    `env:"DATA_EXPORT_POLL_INTERVAL_SECONDS"`
+
+	// PasswordMinLength is in characters, PasswordMaxLength in bytes and can not be more than the 72 bcrypt uses.
+	// PasswordBlocklist is a comma separated list of common passwords.
+	PasswordMinLength int    `env:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength int    `env:"PASSWORD_MAX_LENGTH"`
+	PasswordBlocklist string `env:"PASSWORD_BLOCKLIST"`
+	// BreachedPasswordsPath points to a list of SHA-1 hashes of breached passwords, which new passwords are checked
+	// against. they are not checked when it is empty or the file is missing.
+	BreachedPasswordsPath string `env:"BREACHED_PASSWORDS_PATH"`
+
+	// TwoFactorIssuer names the service in authenticator apps.
+	TwoFactorIssuer                   string `env:"TWO_FACTOR_ISSUER"`
+	TwoFactorChallengeLifespanSeconds int    `env:"TWO_FACTOR_CHALLENGE_LIFESPAN_SECONDS"`
+
+	// failed logins are throttled per email address and per client ip, wrong two-factor codes per account. the
+	// delays start after the free attempts, and double with every failure until the lockout threshold is reached.
+	LoginFailureWindowSeconds     int `env:"LOGIN_FAILURE_WINDOW_SECONDS"`
+	LoginBaseDelaySeconds         int `env:"LOGIN_BASE_DELAY_SECONDS"`
+	LoginLockoutSeconds           int `env:"LOGIN_LOCKOUT_SECONDS"`
+	LoginAccountFreeAttempts      int `env:"LOGIN_ACCOUNT_FREE_ATTEMPTS"`
+	LoginAccountLockoutThreshold  int `env:"LOGIN_ACCOUNT_LOCKOUT_THRESHOLD"`
+	LoginClientIPFreeAttempts     int `env:"LOGIN_CLIENT_IP_FREE_ATTEMPTS"`
+	LoginClientIPLockoutThreshold int `env:"LOGIN_CLIENT_IP_LOCKOUT_THRESHOLD"`
+
+	// single sign-on is only offered when OIDCEnabled is set. the provider is configured through discovery of
+	// OIDCIssuer.
+	OIDCEnabled      bool   `env:"OIDC_ENABLED"`
+	OIDCIssuer       string `env:"OIDC_ISSUER"`
+	OIDCClientID     string `env:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string `env:"OIDC_CLIENT_SECRET"`
+	// OIDCRedirectURL is the page the provider sends users back to, it must hand code and state to the callback api.
+	OIDCRedirectURL           string `env:"OIDC_REDIRECT_URL"`
+	OIDCFlowLifespanSeconds   int    `env:"OIDC_FLOW_LIFESPAN_SECONDS"`
+	OIDCRequestTimeoutSeconds int    `env:"OIDC_REQUEST_TIMEOUT_SECONDS"`
 
 	RandomSlugLength int `env:"RANDOM_SLUG_LENGTH"`
 
+	UrlUnlockSecret          []byte `env:"URL_UNLOCK_SECRET"`
+	UrlUnlockLifespanSeconds int    `env:"URL_UNLOCK_LIFESPAN_SECONDS"`
+	// failed unlock attempts of a client ip on a url are throttled like failed logins, the delays and lockout are the
+	// ones of logins.
+	UrlUnlockFreeAttempts     int `env:"URL_UNLOCK_FREE_ATTEMPTS"`
+	UrlUnlockLockoutThreshold int `env:"URL_UNLOCK_LOCKOUT_THRESHOLD"`
+
+	VisitIPAnonymizationEnabled    bool `env:"VISIT_IP_ANONYMIZATION_ENABLED"`
+	VisitBufferSize                int  `env:"VISIT_BUFFER_SIZE"`
+	VisitBatchSize                 int  `env:"VISIT_BATCH_SIZE"`
+	VisitFlushIntervalMilliseconds int  `env:"VISIT_FLUSH_INTERVAL_MILLISECONDS"`
+
+	// GeoIPDatabasePath points to a MaxMind-format (.mmdb) database. visits are not located when it is empty
+	// or the file is missing.
+	GeoIPDatabasePath string `env:"GEOIP_DATABASE_PATH"`
+
+	// VisitorTrackingMode is one of cookie, hash and none. visitors sending DNT or Sec-GPC are never tracked.
+	VisitorTrackingMode          string `env:"VISITOR_TRACKING_MODE"`
+	VisitorCookieSecret          []byte `env:"VISITOR_COOKIE_SECRET"`
+	VisitorCookieLifespanSeconds int    `env:"VISITOR_COOKIE_LIFESPAN_SECONDS"`
+
+	// BotUserAgentSignatures is a comma separated list of user agent fragments that mark a visit as made by a bot.
+	BotUserAgentSignatures string `env:"BOT_USER_AGENT_SIGNATURES"`
+	// BotVisitPolicy is one of count, separate and ignore.
+	BotVisitPolicy string `env:"BOT_VISIT_POLICY"`
+
 	Hostname  string `env:"HOSTNAME"`
 	DeployTag string `env:"DEPLOY_TAG"`
 
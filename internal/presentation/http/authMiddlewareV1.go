@@ -3,10 +3,12 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
 	"github.com/h3isenbug/url-shortener/pkg/log"
 )
 
@@ -25,19 +27,28 @@ func NewAuthMiddlewareV1(logger log.Logger, authenticationService authentication
 
 func (m AuthMiddlewareV1) Intercept(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		accessToken := strings.TrimPrefix(r.Header.Get(headerAccessToken), "Bearer ")
-		if accessToken == "" {
+		credential := strings.TrimPrefix(r.Header.Get(headerAccessToken), "Bearer ")
+		if credential == "" {
 			m.sendResponseWithDefaultMessage(w, http.StatusUnauthorized)
 			return
 		}
 
-		accountInfo, err := m.authenticationService.GetAccountInfoFromAccessToken(r.Context(), accessToken)
+		var accountInfo *types.AccountInfo
+		var err error
+		if authentication.IsAPIKey(credential) {
+			accountInfo, err = m.authenticationService.GetAccountInfoFromAPIKey(r.Context(), credential)
+		} else {
+			accountInfo, err = m.authenticationService.GetAccountInfoFromAccessToken(r.Context(), credential)
+		}
 		if errors.Is(err, authentication.ErrExpiredToken) || errors.Is(err, authentication.ErrWrongCredentials) {
 			m.sendResponseWithDefaultMessage(w, http.StatusUnauthorized)
 			return
 		}
 
 		if err != nil {
+			m.logger.Error("failed to authenticate request", map[string]interface{}{
+				"errorMessage": err.Error(),
+			})
 			m.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
 			return
 		}
@@ -51,3 +62,32 @@ func (m AuthMiddlewareV1) Intercept(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctxWithAccountInfo))
 	})
 }
+
+// RequireScopes wraps a handler of a route behind Intercept, answering with 403 unless the credential of the request
+// was granted all of the scopes.
+func (m AuthMiddlewareV1) RequireScopes(next http.HandlerFunc, scopes ...types.Scope) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accountInfo := getAccountInfo(r)
+		for _, scope := range scopes {
+			if !accountInfo.HasScope(scope) {
+				m.sendResponseWithCustomMessage(w, http.StatusForbidden, fmt.Sprintf("missing scope %s", scope))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireSession answers with 403 if the request was authenticated with an api key. credentials and sessions are
+// only managed by logged in users, so that a leaked key can not be used to mint more keys.
+func (m AuthMiddlewareV1) RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if getAccountInfo(r).APIKeyID != 0 {
+			m.sendResponseWithCustomMessage(w, http.StatusForbidden, "api keys can not be used here")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -3,9 +3,13 @@ package http
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
 	"github.com/h3isenbug/url-shortener/pkg/log"
 )
 
@@ -36,7 +40,13 @@ func (p authenticationV1) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokens, err := p.authenticationService.Login(r.Context(), request.EMail, request.Password)
+	result, err := p.authenticationService.Login(
+		r.Context(), request.EMail, request.Password,
+		types.ClientInfo{UserAgent: r.UserAgent(), ClientIP: getClientIP(r)},
+	)
+	if p.sendTooManyAttempts(w, err) {
+		return
+	}
 	if errors.Is(err, authentication.ErrWrongCredentials) {
 		p.sendResponseWithDefaultMessage(w, http.StatusUnauthorized)
 		return
@@ -50,6 +60,87 @@ func (p authenticationV1) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	p.sendLoginResult(w, result)
+}
+
+// sendTooManyAttempts answers attempts refused by throttling, it returns false for any other error.
+func (p authenticationV1) sendTooManyAttempts(w http.ResponseWriter, err error) bool {
+	var tooManyAttempts *authentication.TooManyAttemptsError
+	if !errors.As(err, &tooManyAttempts) {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(tooManyAttempts.RetryAfter.Seconds()))))
+
+	if errors.Is(err, authentication.ErrAccountLocked) {
+		p.sendResponseWithCustomMessage(w, http.StatusTooManyRequests, "account is temporarily locked")
+		return true
+	}
+
+	p.sendResponseWithCustomMessage(w, http.StatusTooManyRequests, "too many failed attempts, try again later")
+	return true
+}
+
+// sendFieldErrors answers requests with rejected fields, listing every one of them. it returns false for any other
+// error.
+func (p authenticationV1) sendFieldErrors(w http.ResponseWriter, err error) bool {
+	var fieldErr *authentication.FieldValidationError
+	if !errors.As(err, &fieldErr) {
+		return false
+	}
+
+	p.sendResponse(w, http.StatusBadRequest, struct {
+		Message string                      `json:"message"`
+		Errors  []authentication.FieldError `json:"errors"`
+	}{Message: "request has invalid fields", Errors: fieldErr.Fields})
+	return true
+}
+
+func (p authenticationV1) sendLoginResult(w http.ResponseWriter, result *types.LoginResult) {
+	if result.TokenPair == nil {
+		p.sendResponse(w, http.StatusOK, struct {
+			TwoFactorRequired bool   `json:"twoFactorRequired"`
+			ChallengeToken    string `json:"challengeToken"`
+		}{TwoFactorRequired: true, ChallengeToken: result.ChallengeToken})
+		return
+	}
+
+	p.sendResponse(w, http.StatusOK, struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}{AccessToken: result.TokenPair.AccessToken, RefreshToken: result.TokenPair.RefreshToken})
+}
+
+func (p authenticationV1) CompleteTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ChallengeToken string `json:"challengeToken"`
+		Code           string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := p.authenticationService.CompleteTwoFactorLogin(
+		r.Context(), request.ChallengeToken, request.Code,
+		types.ClientInfo{UserAgent: r.UserAgent(), ClientIP: getClientIP(r)},
+	)
+	if p.sendTooManyAttempts(w, err) {
+		return
+	}
+	if errors.Is(err, authentication.ErrValidationFailed) {
+		p.sendResponseWithDefaultMessage(w, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while completing two-factor login", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
 	p.sendResponse(w, http.StatusOK, struct {
 		AccessToken  string `json:"accessToken"`
 		RefreshToken string `json:"refreshToken"`
@@ -102,6 +193,9 @@ func (p authenticationV1) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := p.authenticationService.Register(r.Context(), request.EMail, request.Password)
+	if p.sendFieldErrors(w, err) {
+		return
+	}
 	if errors.Is(err, authentication.ErrEMailAlreadyUsed) {
 		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "provided email address is already used")
 		return
@@ -117,3 +211,604 @@ func (p authenticationV1) Register(w http.ResponseWriter, r *http.Request) {
 
 	p.sendResponseWithDefaultMessage(w, http.StatusOK)
 }
+
+func (p authenticationV1) VerifyEMail(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token string `json:"token"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	err := p.authenticationService.VerifyEMail(r.Context(), request.Token)
+	if errors.Is(err, authentication.ErrInvalidVerificationToken) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "verification token is invalid, expired or already used")
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while handling email verification", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+func (p authenticationV1) ResendVerificationEMail(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	err := p.authenticationService.ResendVerificationEMail(r.Context(), accountInfo.ID)
+	if errors.Is(err, authentication.ErrEMailAlreadyVerified) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "email address is already verified")
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while resending verification email", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+func (p authenticationV1) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		EMail string `json:"email"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	if err := p.authenticationService.RequestPasswordReset(r.Context(), request.EMail); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while handling password reset request", map[string]interface{}{
+			"email":        request.EMail,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	// the response is the same whether or not an account uses the email address
+	p.sendResponseWithCustomMessage(
+		w, http.StatusOK, "if an account uses this email address, a password reset link is sent to it",
+	)
+}
+
+func (p authenticationV1) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	err := p.authenticationService.ResetPassword(r.Context(), request.Token, request.Password)
+	if p.sendFieldErrors(w, err) {
+		return
+	}
+	if errors.Is(err, authentication.ErrInvalidResetToken) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "password reset token is invalid, expired or already used")
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while resetting password", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+// sendAccountChangeError answers errors shared by the changes that need the current password. it returns false for
+// any other error.
+func (p authenticationV1) sendAccountChangeError(w http.ResponseWriter, err error) bool {
+	if p.sendTooManyAttempts(w, err) || p.sendFieldErrors(w, err) {
+		return true
+	}
+	// the session is fine, so this is not a 401
+	if errors.Is(err, authentication.ErrWrongCredentials) {
+		p.sendResponseWithCustomMessage(w, http.StatusForbidden, "current password is wrong")
+		return true
+	}
+	if errors.Is(err, authentication.ErrPasswordRequired) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, err.Error())
+		return true
+	}
+
+	return false
+}
+
+func (p authenticationV1) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	var request struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := p.authenticationService.ChangePassword(
+		r.Context(), accountInfo.ID, request.CurrentPassword, request.NewPassword,
+		types.ClientInfo{UserAgent: r.UserAgent(), ClientIP: getClientIP(r)},
+	)
+	if p.sendAccountChangeError(w, err) {
+		return
+	}
+	if errors.Is(err, authentication.ErrValidationFailed) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while changing password", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	// every other session has ended, the client continues with these tokens
+	p.sendResponse(w, http.StatusOK, struct {
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+	}{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken})
+}
+
+func (p authenticationV1) RequestEMailChange(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	var request struct {
+		CurrentPassword string `json:"currentPassword"`
+		EMail           string `json:"email"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	err := p.authenticationService.RequestEMailChange(r.Context(), accountInfo.ID, request.CurrentPassword, request.EMail)
+	if p.sendAccountChangeError(w, err) {
+		return
+	}
+	if errors.Is(err, authentication.ErrEMailAlreadyUsed) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "provided email address is already used")
+		return
+	}
+	if errors.Is(err, authentication.ErrValidationFailed) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while requesting email change", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithCustomMessage(w, http.StatusOK, "a confirmation link is sent to the new email address")
+}
+
+func (p authenticationV1) ConfirmEMailChange(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token string `json:"token"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	err := p.authenticationService.ConfirmEMailChange(r.Context(), request.Token)
+	if errors.Is(err, authentication.ErrInvalidEMailChangeToken) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "email change token is invalid, expired or already used")
+		return
+	}
+	if errors.Is(err, authentication.ErrEMailAlreadyUsed) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "provided email address is already used")
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while confirming email change", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+func (p authenticationV1) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	var request struct {
+		CurrentPassword string `json:"currentPassword"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	err := p.authenticationService.DeleteAccount(r.Context(), accountInfo.ID, request.CurrentPassword)
+	if p.sendAccountChangeError(w, err) {
+		return
+	}
+	if errors.Is(err, authentication.ErrAccountNotDeletable) {
+		p.sendResponseWithCustomMessage(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while deleting account", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+func (p authenticationV1) Logout(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	err := p.authenticationService.RevokeSession(r.Context(), accountInfo.ID, accountInfo.SessionID)
+	if errors.Is(err, authentication.ErrSessionNotFound) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "access token does not belong to a session")
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while logging out", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+func (p authenticationV1) GetSessions(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	sessions, err := p.authenticationService.GetSessions(r.Context(), accountInfo.ID)
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while getting sessions", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	type session struct {
+		ID         uint64    `json:"id"`
+		UserAgent  string    `json:"userAgent"`
+		ClientIP   string    `json:"clientIP"`
+		CreatedAt  time.Time `json:"createdAt"`
+		LastUsedAt time.Time `json:"lastUsedAt"`
+		Current    bool      `json:"current"`
+	}
+
+	items := make([]session, 0, len(sessions))
+	for _, s := range sessions {
+		items = append(items, session{
+			ID: s.ID, UserAgent: s.UserAgent, ClientIP: s.ClientIP, CreatedAt: s.CreatedAt, LastUsedAt: s.LastUsedAt,
+			Current: s.ID == accountInfo.SessionID,
+		})
+	}
+
+	p.sendResponse(w, http.StatusOK, struct {
+		Items []session `json:"items"`
+	}{Items: items})
+}
+
+func (p authenticationV1) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	sessionID, err := strconv.ParseUint(getURLParams(r)["sessionID"], 10, 64)
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	err = p.authenticationService.RevokeSession(r.Context(), accountInfo.ID, sessionID)
+	if errors.Is(err, authentication.ErrSessionNotFound) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while revoking session", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"sessionID":    sessionID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+func (p authenticationV1) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	if err := p.authenticationService.RevokeAllSessions(r.Context(), accountInfo.ID); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while revoking all sessions", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+func (p authenticationV1) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	var request struct {
+		Name      string       `json:"name"`
+		Scopes    types.Scopes `json:"scopes"`
+		ExpiresAt *time.Time   `json:"expiresAt"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	key, apiKey, err := p.authenticationService.CreateAPIKey(
+		r.Context(), accountInfo.ID, request.Name, request.Scopes, request.ExpiresAt,
+	)
+	if errors.Is(err, authentication.ErrValidationFailed) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while creating api key", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponse(w, http.StatusCreated, struct {
+		Key string `json:"key"`
+		*types.APIKey
+	}{Key: key, APIKey: apiKey})
+}
+
+func (p authenticationV1) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	apiKeys, err := p.authenticationService.GetAPIKeys(r.Context(), accountInfo.ID)
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while getting api keys", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponse(w, http.StatusOK, struct {
+		Items []types.APIKey `json:"items"`
+	}{Items: apiKeys})
+}
+
+func (p authenticationV1) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	apiKeyID, err := strconv.ParseUint(getURLParams(r)["apiKeyID"], 10, 64)
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	err = p.authenticationService.RevokeAPIKey(r.Context(), accountInfo.ID, apiKeyID)
+	if errors.Is(err, authentication.ErrAPIKeyNotFound) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while revoking api key", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"apiKeyID":     apiKeyID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+func (p authenticationV1) BeginTwoFactorEnrollment(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	secret, provisioningURI, err := p.authenticationService.BeginTwoFactorEnrollment(r.Context(), accountInfo.ID)
+	if errors.Is(err, authentication.ErrTwoFactorAlreadyEnabled) {
+		p.sendResponseWithCustomMessage(w, http.StatusConflict, "two-factor authentication is already enabled")
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while beginning two-factor enrollment", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponse(w, http.StatusOK, struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioningURI"`
+	}{Secret: secret, ProvisioningURI: provisioningURI})
+}
+
+func (p authenticationV1) ConfirmTwoFactorEnrollment(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	var request struct {
+		Code string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	recoveryCodes, err := p.authenticationService.ConfirmTwoFactorEnrollment(r.Context(), accountInfo.ID, request.Code)
+	if p.sendTooManyAttempts(w, err) {
+		return
+	}
+	if errors.Is(err, authentication.ErrTwoFactorAlreadyEnabled) {
+		p.sendResponseWithCustomMessage(w, http.StatusConflict, "two-factor authentication is already enabled")
+		return
+	}
+	if errors.Is(err, authentication.ErrValidationFailed) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while confirming two-factor enrollment", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponse(w, http.StatusOK, struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}{RecoveryCodes: recoveryCodes})
+}
+
+func (p authenticationV1) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	var request struct {
+		Code string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	err := p.authenticationService.DisableTwoFactor(r.Context(), accountInfo.ID, request.Code)
+	if p.sendTooManyAttempts(w, err) {
+		return
+	}
+	if errors.Is(err, authentication.ErrValidationFailed) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while disabling two-factor authentication", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+func (p authenticationV1) BeginSSOLogin(w http.ResponseWriter, r *http.Request) {
+	authorizationURL, flowToken, err := p.authenticationService.BeginSSOLogin(r.Context())
+	if errors.Is(err, authentication.ErrSSODisabled) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while beginning single sign-on", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponse(w, http.StatusOK, struct {
+		AuthorizationURL string `json:"authorizationURL"`
+		FlowToken        string `json:"flowToken"`
+	}{AuthorizationURL: authorizationURL, FlowToken: flowToken})
+}
+
+func (p authenticationV1) CompleteSSOLogin(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		FlowToken string `json:"flowToken"`
+		State     string `json:"state"`
+		Code      string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	result, err := p.authenticationService.CompleteSSOLogin(
+		r.Context(), request.FlowToken, request.State, request.Code,
+		types.ClientInfo{UserAgent: r.UserAgent(), ClientIP: getClientIP(r)},
+	)
+	if errors.Is(err, authentication.ErrSSODisabled) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, authentication.ErrSSOEMailNotVerified) {
+		p.sendResponseWithCustomMessage(w, http.StatusForbidden, "email address is not verified by the provider")
+		return
+	}
+	if errors.Is(err, authentication.ErrSSOAccountNotVerified) {
+		p.sendResponseWithCustomMessage(
+			w, http.StatusForbidden, "verify the email address of your account before signing in with the provider",
+		)
+		return
+	}
+	if errors.Is(err, authentication.ErrValidationFailed) {
+		p.sendResponseWithDefaultMessage(w, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while completing single sign-on", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendLoginResult(w, result)
+}
+
+func (p authenticationV1) GetJSONWebKeySet(w http.ResponseWriter, r *http.Request) {
+	// keys are rotated with an overlap, a verifier that misses a new kid fetches the set again anyway
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	p.sendResponse(w, http.StatusOK, p.authenticationService.GetJSONWebKeySet())
+}
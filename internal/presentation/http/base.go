@@ -3,7 +3,9 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -56,6 +58,70 @@ func getAccountInfo(r *http.Request) *types.AccountInfo {
 	return r.Context().Value(contextKeyAccountInfo).(*types.AccountInfo)
 }
 
+// ClientIPMiddleware finds the ip address of the client. X-Forwarded-For is only read when the peer is one of
+// trustedProxies, and only as far back as the proxies in it are trusted too, since anything before them is made up
+// by the client.
+func ClientIPMiddleware(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientIP := resolveClientIP(r, trustedProxies)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKeyClientIP, clientIP)))
+		})
+	}
+}
+
+func resolveClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+	if !isTrustedProxy(clientIP, trustedProxies) {
+		return clientIP
+	}
+
+	// every proxy appends the address it got the request from, so the closest hops are the last ones
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+
+		clientIP = hop
+		if !isTrustedProxy(hop, trustedProxies) {
+			break
+		}
+	}
+
+	return clientIP
+}
+
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getClientIP(r *http.Request) string {
+	if clientIP, ok := r.Context().Value(contextKeyClientIP).(string); ok {
+		return clientIP
+	}
+
+	return resolveClientIP(r, nil)
+}
+
 type teeResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/h3isenbug/url-shortener/internal/service/export"
+	"github.com/h3isenbug/url-shortener/pkg/log"
+)
+
+type exportV1 struct {
+	basePresentationHandler
+
+	exportService export.Service
+}
+
+func NewExportAPIV1(logger log.Logger, exportService export.Service) ExportAPI {
+	return &exportV1{
+		basePresentationHandler: basePresentationHandler{
+			logger: logger,
+		},
+
+		exportService: exportService,
+	}
+}
+
+func (p exportV1) RequestExport(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	dataExport, err := p.exportService.RequestExport(r.Context(), accountInfo.ID)
+	if errors.Is(err, export.ErrExportInProgress) {
+		p.sendResponseWithCustomMessage(w, http.StatusConflict, "an export of the account is already being built")
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while requesting data export", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponse(w, http.StatusAccepted, dataExport)
+}
+
+func (p exportV1) GetExport(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	exportID, err := strconv.ParseUint(getURLParams(r)["exportID"], 10, 64)
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
+
+	dataExport, err := p.exportService.GetExport(r.Context(), accountInfo.ID, exportID)
+	if errors.Is(err, export.ErrExportNotFound) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while getting data export", map[string]interface{}{
+			"accountID":    accountInfo.ID,
+			"exportID":     exportID,
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	p.sendResponse(w, http.StatusOK, dataExport)
+}
+
+func (p exportV1) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	fileName, archive, err := p.exportService.GetArchive(r.Context(), r.URL.Query().Get("token"))
+	if errors.Is(err, export.ErrInvalidDownloadToken) {
+		p.sendResponseWithCustomMessage(w, http.StatusNotFound, "download link is invalid or expired")
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while downloading data export", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(archive); err != nil {
+		p.logger.Warn("could not write data export archive", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+	}
+}
@@ -5,18 +5,60 @@ import "net/http"
 const (
 	contextKeyAccountInfo = iota + 1
 	contextKeyURLParams
+	contextKeyClientIP
 )
 
 type UrlAPI interface {
 	CreateShortUrl(w http.ResponseWriter, r *http.Request)
-	SetUrlState(w http.ResponseWriter, r *http.Request)
+	UpdateUrl(w http.ResponseWriter, r *http.Request)
+	RollbackUrl(w http.ResponseWriter, r *http.Request)
 
 	GetMyUrls(w http.ResponseWriter, r *http.Request)
+	GetUrlRevisions(w http.ResponseWriter, r *http.Request)
+	GetUrlVisits(w http.ResponseWriter, r *http.Request)
+	GetUrlStats(w http.ResponseWriter, r *http.Request)
+	GetUrlBreakdown(w http.ResponseWriter, r *http.Request)
+	GetAccountBreakdown(w http.ResponseWriter, r *http.Request)
+	GetUrlGeoBreakdown(w http.ResponseWriter, r *http.Request)
 	GetOriginalUrl(w http.ResponseWriter, r *http.Request)
+	UnlockUrl(w http.ResponseWriter, r *http.Request)
 }
 
 type AuthenticationAPI interface {
 	Login(w http.ResponseWriter, r *http.Request)
+	CompleteTwoFactorLogin(w http.ResponseWriter, r *http.Request)
+	BeginSSOLogin(w http.ResponseWriter, r *http.Request)
+	CompleteSSOLogin(w http.ResponseWriter, r *http.Request)
 	RenewAccessToken(w http.ResponseWriter, r *http.Request)
 	Register(w http.ResponseWriter, r *http.Request)
+	VerifyEMail(w http.ResponseWriter, r *http.Request)
+	ResendVerificationEMail(w http.ResponseWriter, r *http.Request)
+	RequestPasswordReset(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+
+	ChangePassword(w http.ResponseWriter, r *http.Request)
+	RequestEMailChange(w http.ResponseWriter, r *http.Request)
+	ConfirmEMailChange(w http.ResponseWriter, r *http.Request)
+	DeleteAccount(w http.ResponseWriter, r *http.Request)
+
+	Logout(w http.ResponseWriter, r *http.Request)
+	GetSessions(w http.ResponseWriter, r *http.Request)
+	RevokeSession(w http.ResponseWriter, r *http.Request)
+	RevokeAllSessions(w http.ResponseWriter, r *http.Request)
+
+	CreateAPIKey(w http.ResponseWriter, r *http.Request)
+	GetAPIKeys(w http.ResponseWriter, r *http.Request)
+	RevokeAPIKey(w http.ResponseWriter, r *http.Request)
+
+	BeginTwoFactorEnrollment(w http.ResponseWriter, r *http.Request)
+	ConfirmTwoFactorEnrollment(w http.ResponseWriter, r *http.Request)
+	DisableTwoFactor(w http.ResponseWriter, r *http.Request)
+
+	GetJSONWebKeySet(w http.ResponseWriter, r *http.Request)
+}
+
+type ExportAPI interface {
+	RequestExport(w http.ResponseWriter, r *http.Request)
+	GetExport(w http.ResponseWriter, r *http.Request)
+	DownloadExport(w http.ResponseWriter, r *http.Request)
 }
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"html/template"
+	"net/http"
+)
+
+var unlockPageTemplate = template.Must(template.New("unlock").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <meta name="robots" content="noindex">
+    <title>Protected link</title>
+    <style>
+        body { font-family: sans-serif; display: flex; justify-content: center; margin-top: 10vh; }
+        form { display: flex; flex-direction: column; gap: .75rem; min-width: 18rem; }
+        .error { color: #b00020; }
+    </style>
+</head>
+<body>
+<form method="POST" action="/{{.Slug}}">
+    <h1>This link is protected</h1>
+    <label for="password">Enter the passphrase to continue</label>
+    <input id="password" name="password" type="password" autocomplete="off" autofocus required>
+    {{if .ErrorMessage}}<p class="error">{{.ErrorMessage}}</p>{{end}}
+    <button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+func (p basePresentationHandler) sendUnlockPage(w http.ResponseWriter, statusCode int, slug, errorMessage string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+
+	err := unlockPageTemplate.Execute(w, struct {
+		Slug         string
+		ErrorMessage string
+	}{Slug: slug, ErrorMessage: errorMessage})
+	if err != nil {
+		p.logger.Error("could not render or write unlock page", map[string]interface{}{
+			"slug":         slug,
+			"errorMessage": err.Error(),
+		})
+	}
+}
@@ -1,47 +1,158 @@
 package http
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/h3isenbug/url-shortener/internal/repository"
 	"github.com/h3isenbug/url-shortener/internal/service/url"
+	"github.com/h3isenbug/url-shortener/internal/service/visitor"
 	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/botdetect"
+	"github.com/h3isenbug/url-shortener/pkg/geoip"
 	"github.com/h3isenbug/url-shortener/pkg/log"
+	"github.com/h3isenbug/url-shortener/pkg/useragent"
+)
+
+const (
+	visitorCookieName  = "visitor"
+	unlockCookiePrefix = "unlock-"
+	maxUnlockFormSize  = 4 << 10
+
+	defaultStatsPeriod    = 7 * 24 * time.Hour
+	defaultBreakdownLimit = 10
 )
 
 type urlV1 struct {
 	basePresentationHandler
 
-	urlService url.Service
+	urlService     url.Service
+	visitorService visitor.Service
+	locator        geoip.Locator
+	botDetector    botdetect.Detector
 }
 
-func NewUrlAPIV1(logger log.Logger, urlService url.Service) UrlAPI {
+func NewUrlAPIV1(
+	logger log.Logger,
+	urlService url.Service,
+	visitorService visitor.Service,
+	locator geoip.Locator,
+	botDetector botdetect.Detector,
+) UrlAPI {
 	return &urlV1{
 		basePresentationHandler: basePresentationHandler{logger: logger},
 		urlService:              urlService,
+		visitorService:          visitorService,
+		locator:                 locator,
+		botDetector:             botDetector,
 	}
 }
 
-func (p urlV1) GetOriginalUrl(w http.ResponseWriter, r *http.Request) {
-	// ETag is used as a tracking mechanism here.
+// getReferrerDomain returns the host of a referrer without its www. prefix, or an empty string for direct visits.
+func getReferrerDomain(referrer string) string {
+	parsed, err := neturl.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// identifyVisitor hands out a visitor cookie if needed and returns the identity of the visitor, whose id is empty if
+// the visitor is not tracked.
+func (p urlV1) identifyVisitor(w http.ResponseWriter, r *http.Request) visitor.Identity {
+	request := visitor.Request{
+		ClientIP:   getClientIP(r),
+		UserAgent:  r.UserAgent(),
+		DoNotTrack: r.Header.Get("DNT") == "1" || r.Header.Get("Sec-GPC") == "1",
+	}
+	if cookie, err := r.Cookie(visitorCookieName); err == nil {
+		request.Cookie = cookie.Value
+	}
+
+	identity, err := p.visitorService.Identify(r.Context(), request)
+	if err != nil {
+		p.logger.Warn("failed to identify visitor", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+		return visitor.Identity{}
+	}
+
+	if identity.Cookie != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     visitorCookieName,
+			Value:    identity.Cookie,
+			Path:     "/",
+			Expires:  identity.CookieExpiresAt,
+			Secure:   r.TLS != nil,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
 
+	return identity
+}
+
+func (p urlV1) GetOriginalUrl(w http.ResponseWriter, r *http.Request) {
 	slug := getURLParams(r)["slug"]
-	newVisit := r.Header.Get("If-None-Match") == ""
 
-	originalUrl, err := p.urlService.GetOriginalUrl(r.Context(), slug, newVisit)
+	var unlockToken string
+	if cookie, err := r.Cookie(unlockCookiePrefix + slug); err == nil {
+		unlockToken = cookie.Value
+	}
+
+	clientIP := getClientIP(r)
+	bot := p.botDetector.IsBot(r)
+	userAgent := useragent.Parse(r.UserAgent(), bot)
+	// the location is looked up here, since the service may anonymize the ip address.
+	location := p.locator.Lookup(clientIP)
+	visit := &types.Visit{
+		Referrer:       r.Referer(),
+		UserAgent:      r.UserAgent(),
+		ClientIP:       clientIP,
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+		ReferrerDomain: getReferrerDomain(r.Referer()),
+		Device:         userAgent.Device,
+		Browser:        userAgent.Browser,
+		OS:             userAgent.OS,
+		Country:        location.Country,
+		City:           location.City,
+		Bot:            bot,
+	}
+	if !visit.Bot {
+		identity := p.identifyVisitor(w, r)
+		visit.VisitorID, visit.VisitorHashed = identity.ID, identity.Hashed
+	}
+
+	originalUrl, err := p.urlService.GetOriginalUrl(r.Context(), slug, visit, unlockToken)
 	if errors.Is(err, repository.ErrNotFound) {
 		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
 		return
 	}
+	if errors.Is(err, url.ErrUrlGone) {
+		p.sendResponseWithDefaultMessage(w, http.StatusGone)
+		return
+	}
+	if errors.Is(err, url.ErrPasswordRequired) {
+		p.sendUnlockPage(w, http.StatusUnauthorized, slug, "")
+		return
+	}
 	if err != nil {
 		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while redirecting short url", map[string]interface{}{
+			"errorMessage": err.Error(),
+			"slug":         slug,
+		})
 		return
 	}
 
-	w.Header().Set("ETag", base64.URLEncoding.EncodeToString([]byte(r.URL.String())))
 	/*
 	 *  If analytics is needed, use 302(client asks everytime), otherwise use 301
 	 *    for better client-side performance.
@@ -52,10 +163,65 @@ func (p urlV1) GetOriginalUrl(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+func (p urlV1) UnlockUrl(w http.ResponseWriter, r *http.Request) {
+	slug := getURLParams(r)["slug"]
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUnlockFormSize)
+	if err := r.ParseForm(); err != nil {
+		p.sendUnlockPage(w, http.StatusBadRequest, slug, "invalid form submission")
+		return
+	}
+
+	unlockToken, validUntil, err := p.urlService.UnlockUrl(r.Context(), slug, r.PostForm.Get("password"), getClientIP(r))
+	if errors.Is(err, repository.ErrNotFound) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, url.ErrUrlGone) {
+		p.sendResponseWithDefaultMessage(w, http.StatusGone)
+		return
+	}
+	if errors.Is(err, url.ErrWrongPassword) {
+		p.sendUnlockPage(w, http.StatusUnauthorized, slug, "wrong passphrase, please try again")
+		return
+	}
+	var tooManyAttempts *url.TooManyUnlockAttemptsError
+	if errors.As(err, &tooManyAttempts) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(tooManyAttempts.RetryAfter.Seconds()))))
+		p.sendUnlockPage(w, http.StatusTooManyRequests, slug, "too many wrong passphrases, try again later")
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while unlocking short url", map[string]interface{}{
+			"errorMessage": err.Error(),
+			"slug":         slug,
+		})
+		return
+	}
+
+	if unlockToken != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     unlockCookiePrefix + slug,
+			Value:    unlockToken,
+			Path:     "/" + slug,
+			Expires:  validUntil,
+			Secure:   r.TLS != nil,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	http.Redirect(w, r, "/"+slug, http.StatusSeeOther)
+}
+
 func (p urlV1) CreateShortUrl(w http.ResponseWriter, r *http.Request) {
 	var request struct {
-		OriginalUrl string `json:"originalUrl"`
-		Slug        string `json:"slug,omitempty"`
+		OriginalUrl string     `json:"originalUrl"`
+		Slug        string     `json:"slug,omitempty"`
+		ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+		MaxVisits   *uint64    `json:"maxVisits,omitempty"`
+		Password    string     `json:"password,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -63,11 +229,30 @@ func (p urlV1) CreateShortUrl(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slug, err := p.urlService.CreateShortUrl(r.Context(), request.OriginalUrl, request.Slug, getAccountInfo(r).ID)
+	slug, err := p.urlService.CreateShortUrl(
+		r.Context(), request.OriginalUrl, request.Slug, getAccountInfo(r).ID,
+		url.ShortUrlOptions{ExpiresAt: request.ExpiresAt, MaxVisits: request.MaxVisits, Password: request.Password},
+	)
 	if errors.Is(err, repository.ErrUniquenessViolated) {
 		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "requested slug is unavailable")
 		return
 	}
+	if errors.Is(err, url.ErrExpirationInPast) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "expiration time must be in the future")
+		return
+	}
+	if errors.Is(err, url.ErrInvalidVisitLimit) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "visit limit must be greater than zero")
+		return
+	}
+	if errors.Is(err, url.ErrPasswordTooLong) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "password must not be longer than 72 bytes")
+		return
+	}
+	if errors.Is(err, url.ErrEMailNotVerified) {
+		p.sendResponseWithCustomMessage(w, http.StatusForbidden, "email address must be verified before creating short urls")
+		return
+	}
 	if err != nil {
 		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
 		p.logger.Error("internal server error while saving new short url", map[string]interface{}{
@@ -79,24 +264,362 @@ func (p urlV1) CreateShortUrl(w http.ResponseWriter, r *http.Request) {
 	}
 
 	p.sendResponse(w, http.StatusCreated, struct {
-		OriginalUrl string `json:"originalUrl"`
-		Slug        string `json:"slug"`
-	}{OriginalUrl: request.OriginalUrl, Slug: slug})
+		OriginalUrl string     `json:"originalUrl"`
+		Slug        string     `json:"slug"`
+		ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+		MaxVisits   *uint64    `json:"maxVisits,omitempty"`
+		Protected   bool       `json:"protected"`
+	}{
+		OriginalUrl: request.OriginalUrl, Slug: slug, ExpiresAt: request.ExpiresAt, MaxVisits: request.MaxVisits,
+		Protected: request.Password != "",
+	})
 }
 
-func (p urlV1) SetUrlState(w http.ResponseWriter, r *http.Request) {
+func (p urlV1) UpdateUrl(w http.ResponseWriter, r *http.Request) {
 	accountInfo := getAccountInfo(r)
 	slug := getURLParams(r)["slug"]
 
 	var request struct {
-		Disabled bool `json:"disabled"`
+		Disabled    *bool   `json:"disabled,omitempty"`
+		OriginalUrl *string `json:"originalUrl,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
 		return
 	}
+	if request.Disabled == nil && request.OriginalUrl == nil {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "nothing to update")
+		return
+	}
+
+	err := p.urlService.UpdateUrl(r.Context(), accountInfo.ID, slug, url.UrlUpdate{
+		OriginalUrl: request.OriginalUrl, Disabled: request.Disabled,
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, url.ErrNotAuthorized) {
+		p.sendResponseWithDefaultMessage(w, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, url.ErrEmptyOriginalUrl) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "original url must not be empty")
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("failed to update short url", map[string]interface{}{
+			"errorMessage": err.Error(),
+			"accountID":    accountInfo.ID,
+			"slug":         slug,
+		})
+		return
+	}
+
+	p.sendResponseWithDefaultMessage(w, http.StatusOK)
+}
+
+func (p urlV1) GetUrlRevisions(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+	slug := getURLParams(r)["slug"]
+	cursor := r.URL.Query().Get("cursor")
+
+	revisions, nextCursor, err := p.urlService.GetUrlRevisions(r.Context(), accountInfo.ID, slug, cursor)
+	if errors.Is(err, repository.ErrNotFound) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, url.ErrNotAuthorized) {
+		p.sendResponseWithDefaultMessage(w, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while getting url revisions", map[string]interface{}{
+			"errorMessage": err.Error(),
+			"accountID":    accountInfo.ID,
+			"slug":         slug,
+		})
+		return
+	}
+
+	p.sendResponse(w, http.StatusOK, &struct {
+		Items      []types.UrlRevision `json:"items"`
+		NextCursor string              `json:"nextCursor"`
+	}{Items: revisions, NextCursor: nextCursor})
+}
+
+func (p urlV1) GetUrlVisits(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+	slug := getURLParams(r)["slug"]
+	cursor := r.URL.Query().Get("cursor")
+
+	visits, nextCursor, err := p.urlService.GetUrlVisits(r.Context(), accountInfo.ID, slug, cursor)
+	if errors.Is(err, repository.ErrNotFound) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, url.ErrNotAuthorized) {
+		p.sendResponseWithDefaultMessage(w, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while getting url visits", map[string]interface{}{
+			"errorMessage": err.Error(),
+			"accountID":    accountInfo.ID,
+			"slug":         slug,
+		})
+		return
+	}
+
+	p.sendResponse(w, http.StatusOK, &struct {
+		Items      []types.Visit `json:"items"`
+		NextCursor string        `json:"nextCursor"`
+	}{Items: visits, NextCursor: nextCursor})
+}
+
+// parseStatsTime accepts either a full RFC 3339 timestamp or a date, which is taken as midnight in the given location.
+func parseStatsTime(value string, location *time.Location) (time.Time, error) {
+	if parsed, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		return parsed, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseLocation reads the timezone query parameter, defaulting to UTC. visits are rolled up per UTC hour, so
+// timezones whose offset is not a whole number of hours, such as Asia/Kolkata, are refused rather than having some
+// of their visits counted in the wrong hour or day.
+func parseLocation(r *http.Request) (*time.Location, error) {
+	timezone := r.URL.Query().Get("timezone")
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	// "Local" would be the timezone of this host, which means nothing to the client.
+	location, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		return nil, errors.New("unknown timezone")
+	}
+
+	// offsets are checked around the year, daylight saving time may move a zone off the hour for half of it.
+	now := time.Now()
+	for _, t := range []time.Time{now, now.AddDate(0, -6, 0), now.AddDate(0, 6, 0)} {
+		if _, offset := t.In(location).Zone(); offset%int(time.Hour/time.Second) != 0 {
+			return nil, errors.New("timezones not a whole number of hours away from UTC are not supported")
+		}
+	}
+
+	return location, nil
+}
+
+// parseTimeRange reads the from and to query parameters, defaulting to the week before now.
+// errors are meant to be shown to the client.
+func parseTimeRange(r *http.Request, location *time.Location) (from, to time.Time, err error) {
+	query := r.URL.Query()
+
+	to = time.Now().UTC()
+	if value := query.Get("to"); value != "" {
+		if to, err = parseStatsTime(value, location); err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be a date or an RFC 3339 timestamp")
+		}
+	}
+
+	from = to.Add(-defaultStatsPeriod)
+	if value := query.Get("from"); value != "" {
+		if from, err = parseStatsTime(value, location); err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be a date or an RFC 3339 timestamp")
+		}
+	}
+
+	return from, to, nil
+}
+
+func (p urlV1) GetUrlStats(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+	slug := getURLParams(r)["slug"]
+
+	location, err := parseLocation(r)
+	if err != nil {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from, to, err := parseTimeRange(r, location)
+	if err != nil {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	granularity := types.StatsGranularity(r.URL.Query().Get("granularity"))
+	if granularity == "" {
+		granularity = types.StatsGranularityDay
+	}
+
+	buckets, err := p.urlService.GetUrlStats(r.Context(), accountInfo.ID, slug, from, to, granularity, location)
+	if errors.Is(err, url.ErrInvalidGranularity) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "granularity must be either hour or day")
+		return
+	}
+	if errors.Is(err, url.ErrInvalidStatsRange) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	if errors.Is(err, url.ErrStatsRangeTooLarge) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "requested range is too large for the given granularity")
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, url.ErrNotAuthorized) {
+		p.sendResponseWithDefaultMessage(w, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		p.logger.Error("internal server error while getting url stats", map[string]interface{}{
+			"errorMessage": err.Error(),
+			"accountID":    accountInfo.ID,
+			"slug":         slug,
+		})
+		return
+	}
+
+	p.sendResponse(w, http.StatusOK, &struct {
+		Granularity types.StatsGranularity   `json:"granularity"`
+		Timezone    string                   `json:"timezone"`
+		Items       []types.VisitStatsBucket `json:"items"`
+	}{Granularity: granularity, Timezone: location.String(), Items: buckets})
+}
+
+func (p urlV1) parseBreakdownRequest(
+	w http.ResponseWriter, r *http.Request,
+) (dimension types.BreakdownDimension, from, to time.Time, limit int, ok bool) {
+	location, err := parseLocation(r)
+	if err != nil {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, err.Error())
+		return "", time.Time{}, time.Time{}, 0, false
+	}
+
+	from, to, err = parseTimeRange(r, location)
+	if err != nil {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, err.Error())
+		return "", time.Time{}, time.Time{}, 0, false
+	}
+
+	limit = defaultBreakdownLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "limit must be a number")
+			return "", time.Time{}, time.Time{}, 0, false
+		}
+	}
+
+	return types.BreakdownDimension(getURLParams(r)["dimension"]), from, to, limit, true
+}
+
+func (p urlV1) sendBreakdown(w http.ResponseWriter, items interface{}, err error, extras map[string]interface{}) {
+	if errors.Is(err, url.ErrInvalidDimension) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "dimension must be one of referrer, device, browser, os and country")
+		return
+	}
+	if errors.Is(err, url.ErrInvalidBreakdownLimit) {
+		p.sendResponseWithCustomMessage(
+			w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", url.MaxBreakdownLimit),
+		)
+		return
+	}
+	if errors.Is(err, url.ErrInvalidStatsRange) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	if errors.Is(err, url.ErrStatsRangeTooLarge) {
+		p.sendResponseWithCustomMessage(w, http.StatusBadRequest, "requested range is too large")
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, url.ErrNotAuthorized) {
+		p.sendResponseWithDefaultMessage(w, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
+		extras["errorMessage"] = err.Error()
+		p.logger.Error("internal server error while getting visit breakdown", extras)
+		return
+	}
+
+	p.sendResponse(w, http.StatusOK, &struct {
+		Items interface{} `json:"items"`
+	}{Items: items})
+}
+
+func (p urlV1) GetUrlBreakdown(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+	slug := getURLParams(r)["slug"]
+
+	dimension, from, to, limit, ok := p.parseBreakdownRequest(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := p.urlService.GetUrlBreakdown(r.Context(), accountInfo.ID, slug, dimension, from, to, limit)
+	p.sendBreakdown(w, items, err, map[string]interface{}{
+		"accountID": accountInfo.ID,
+		"slug":      slug,
+		"dimension": dimension,
+	})
+}
+
+func (p urlV1) GetAccountBreakdown(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+
+	dimension, from, to, limit, ok := p.parseBreakdownRequest(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := p.urlService.GetAccountBreakdown(r.Context(), accountInfo.ID, dimension, from, to, limit)
+	p.sendBreakdown(w, items, err, map[string]interface{}{
+		"accountID": accountInfo.ID,
+		"dimension": dimension,
+	})
+}
+
+func (p urlV1) GetUrlGeoBreakdown(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+	slug := getURLParams(r)["slug"]
+
+	_, from, to, limit, ok := p.parseBreakdownRequest(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := p.urlService.GetUrlGeoBreakdown(r.Context(), accountInfo.ID, slug, from, to, limit)
+	p.sendBreakdown(w, items, err, map[string]interface{}{
+		"accountID": accountInfo.ID,
+		"slug":      slug,
+	})
+}
+
+func (p urlV1) RollbackUrl(w http.ResponseWriter, r *http.Request) {
+	accountInfo := getAccountInfo(r)
+	slug := getURLParams(r)["slug"]
+
+	revisionID, err := strconv.ParseUint(getURLParams(r)["revisionID"], 10, 64)
+	if err != nil {
+		p.sendResponseWithDefaultMessage(w, http.StatusBadRequest)
+		return
+	}
 
-	err := p.urlService.SetUrlState(r.Context(), accountInfo.ID, slug, request.Disabled)
+	err = p.urlService.RollbackUrl(r.Context(), accountInfo.ID, slug, revisionID)
 	if errors.Is(err, repository.ErrNotFound) {
 		p.sendResponseWithDefaultMessage(w, http.StatusNotFound)
 		return
@@ -107,10 +630,11 @@ func (p urlV1) SetUrlState(w http.ResponseWriter, r *http.Request) {
 	}
 	if err != nil {
 		p.sendResponseWithDefaultMessage(w, http.StatusInternalServerError)
-		p.logger.Error("failed to disable short url", map[string]interface{}{
+		p.logger.Error("failed to rollback short url", map[string]interface{}{
 			"errorMessage": err.Error(),
 			"accountID":    accountInfo.ID,
 			"slug":         slug,
+			"revisionID":   revisionID,
 		})
 		return
 	}
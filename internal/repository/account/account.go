@@ -12,7 +12,26 @@ import (
 type Repository interface {
 	Get(ctx context.Context, id uint64) (*types.Account, error)
 	GetByEMail(ctx context.Context, email string) (*types.Account, error)
-	Create(ctx context.Context, email, password string) error
+	Create(ctx context.Context, email, password string) (*types.Account, error)
+	SetEMailVerified(ctx context.Context, id uint64) error
+	SetPasswordHash(ctx context.Context, id uint64, passwordHash string) error
+	// SetPendingEMail keeps the address the account is changing its email to, until it is confirmed.
+	SetPendingEMail(ctx context.Context, id uint64, email string) error
+	// ApplyPendingEMail replaces the email of the account with its pending one, which is then verified. it returns
+	// repository.ErrNotFound when no change is pending.
+	ApplyPendingEMail(ctx context.Context, id uint64) (email string, err error)
+	// Delete deletes the account with its sessions, tokens, keys, two-factor secrets and linked identities, and
+	// deals with its urls as policy says, all in one transaction. transferTo is only used by
+	// AccountDeletionPolicyTransfer. it returns the slugs of the urls it changed.
+	Delete(
+		ctx context.Context, id uint64, policy types.AccountDeletionPolicy, transferTo uint64,
+	) (slugs []string, err error)
+
+	// GetByIdentity returns the account linked to the subject of an OpenID Connect provider.
+	GetByIdentity(ctx context.Context, issuer, subject string) (*types.Account, error)
+	LinkIdentity(ctx context.Context, id uint64, issuer, subject string) error
+	// CreateWithIdentity creates an account without a password, with a verified email, linked to the subject.
+	CreateWithIdentity(ctx context.Context, email, issuer, subject string) (*types.Account, error)
 }
 
 type metricWrapper struct {
@@ -44,10 +63,76 @@ func (w metricWrapper) GetByEMail(ctx context.Context, email string) (*types.Acc
 	return account, err
 }
 
-func (w metricWrapper) Create(ctx context.Context, email, password string) error {
+func (w metricWrapper) Create(ctx context.Context, email, password string) (*types.Account, error) {
 	startedAt := time.Now()
-	err := w.wrapped.Create(ctx, email, password)
+	account, err := w.wrapped.Create(ctx, email, password)
 	w.RecordMetrics("Create", time.Now().Sub(startedAt), err == nil)
 
+	return account, err
+}
+
+func (w metricWrapper) SetEMailVerified(ctx context.Context, id uint64) error {
+	startedAt := time.Now()
+	err := w.wrapped.SetEMailVerified(ctx, id)
+	w.RecordMetrics("SetEMailVerified", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) SetPasswordHash(ctx context.Context, id uint64, passwordHash string) error {
+	startedAt := time.Now()
+	err := w.wrapped.SetPasswordHash(ctx, id, passwordHash)
+	w.RecordMetrics("SetPasswordHash", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) SetPendingEMail(ctx context.Context, id uint64, email string) error {
+	startedAt := time.Now()
+	err := w.wrapped.SetPendingEMail(ctx, id, email)
+	w.RecordMetrics("SetPendingEMail", time.Now().Sub(startedAt), err == nil)
+
 	return err
 }
+
+func (w metricWrapper) ApplyPendingEMail(ctx context.Context, id uint64) (string, error) {
+	startedAt := time.Now()
+	email, err := w.wrapped.ApplyPendingEMail(ctx, id)
+	w.RecordMetrics("ApplyPendingEMail", time.Now().Sub(startedAt), err == nil)
+
+	return email, err
+}
+
+func (w metricWrapper) Delete(
+	ctx context.Context, id uint64, policy types.AccountDeletionPolicy, transferTo uint64,
+) ([]string, error) {
+	startedAt := time.Now()
+	slugs, err := w.wrapped.Delete(ctx, id, policy, transferTo)
+	w.RecordMetrics("Delete", time.Now().Sub(startedAt), err == nil)
+
+	return slugs, err
+}
+
+func (w metricWrapper) GetByIdentity(ctx context.Context, issuer, subject string) (*types.Account, error) {
+	startedAt := time.Now()
+	account, err := w.wrapped.GetByIdentity(ctx, issuer, subject)
+	w.RecordMetrics("GetByIdentity", time.Now().Sub(startedAt), err == nil)
+
+	return account, err
+}
+
+func (w metricWrapper) LinkIdentity(ctx context.Context, id uint64, issuer, subject string) error {
+	startedAt := time.Now()
+	err := w.wrapped.LinkIdentity(ctx, id, issuer, subject)
+	w.RecordMetrics("LinkIdentity", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) CreateWithIdentity(ctx context.Context, email, issuer, subject string) (*types.Account, error) {
+	startedAt := time.Now()
+	account, err := w.wrapped.CreateWithIdentity(ctx, email, issuer, subject)
+	w.RecordMetrics("CreateWithIdentity", time.Now().Sub(startedAt), err == nil)
+
+	return account, err
+}
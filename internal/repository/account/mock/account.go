@@ -35,12 +35,28 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ApplyPendingEMail mocks base method.
+func (m *MockRepository) ApplyPendingEMail(ctx context.Context, id uint64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyPendingEMail", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyPendingEMail indicates an expected call of ApplyPendingEMail.
+func (mr *MockRepositoryMockRecorder) ApplyPendingEMail(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyPendingEMail", reflect.TypeOf((*MockRepository)(nil).ApplyPendingEMail), ctx, id)
+}
+
 // Create mocks base method.
-func (m *MockRepository) Create(ctx context.Context, email, password string) error {
+func (m *MockRepository) Create(ctx context.Context, email, password string) (*types.Account, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, email, password)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(*types.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
@@ -49,6 +65,36 @@ func (mr *MockRepositoryMockRecorder) Create(ctx, email, password interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), ctx, email, password)
 }
 
+// CreateWithIdentity mocks base method.
+func (m *MockRepository) CreateWithIdentity(ctx context.Context, email, issuer, subject string) (*types.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWithIdentity", ctx, email, issuer, subject)
+	ret0, _ := ret[0].(*types.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWithIdentity indicates an expected call of CreateWithIdentity.
+func (mr *MockRepositoryMockRecorder) CreateWithIdentity(ctx, email, issuer, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithIdentity", reflect.TypeOf((*MockRepository)(nil).CreateWithIdentity), ctx, email, issuer, subject)
+}
+
+// Delete mocks base method.
+func (m *MockRepository) Delete(ctx context.Context, id uint64, policy types.AccountDeletionPolicy, transferTo uint64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id, policy, transferTo)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRepositoryMockRecorder) Delete(ctx, id, policy, transferTo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), ctx, id, policy, transferTo)
+}
+
 // Get mocks base method.
 func (m *MockRepository) Get(ctx context.Context, id uint64) (*types.Account, error) {
 	m.ctrl.T.Helper()
@@ -78,3 +124,74 @@ func (mr *MockRepositoryMockRecorder) GetByEMail(ctx, email interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEMail", reflect.TypeOf((*MockRepository)(nil).GetByEMail), ctx, email)
 }
+
+// GetByIdentity mocks base method.
+func (m *MockRepository) GetByIdentity(ctx context.Context, issuer, subject string) (*types.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIdentity", ctx, issuer, subject)
+	ret0, _ := ret[0].(*types.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIdentity indicates an expected call of GetByIdentity.
+func (mr *MockRepositoryMockRecorder) GetByIdentity(ctx, issuer, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIdentity", reflect.TypeOf((*MockRepository)(nil).GetByIdentity), ctx, issuer, subject)
+}
+
+// LinkIdentity mocks base method.
+func (m *MockRepository) LinkIdentity(ctx context.Context, id uint64, issuer, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkIdentity", ctx, id, issuer, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkIdentity indicates an expected call of LinkIdentity.
+func (mr *MockRepositoryMockRecorder) LinkIdentity(ctx, id, issuer, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkIdentity", reflect.TypeOf((*MockRepository)(nil).LinkIdentity), ctx, id, issuer, subject)
+}
+
+// SetEMailVerified mocks base method.
+func (m *MockRepository) SetEMailVerified(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEMailVerified", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEMailVerified indicates an expected call of SetEMailVerified.
+func (mr *MockRepositoryMockRecorder) SetEMailVerified(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEMailVerified", reflect.TypeOf((*MockRepository)(nil).SetEMailVerified), ctx, id)
+}
+
+// SetPasswordHash mocks base method.
+func (m *MockRepository) SetPasswordHash(ctx context.Context, id uint64, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPasswordHash", ctx, id, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPasswordHash indicates an expected call of SetPasswordHash.
+func (mr *MockRepositoryMockRecorder) SetPasswordHash(ctx, id, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPasswordHash", reflect.TypeOf((*MockRepository)(nil).SetPasswordHash), ctx, id, passwordHash)
+}
+
+// SetPendingEMail mocks base method.
+func (m *MockRepository) SetPendingEMail(ctx context.Context, id uint64, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPendingEMail", ctx, id, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPendingEMail indicates an expected call of SetPendingEMail.
+func (mr *MockRepositoryMockRecorder) SetPendingEMail(ctx, id, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPendingEMail", reflect.TypeOf((*MockRepository)(nil).SetPendingEMail), ctx, id, email)
+}
@@ -12,6 +12,9 @@ import (
 	"github.com/lib/pq"
 )
 
+// password_hash is null for accounts that only log in through single sign-on.
+const accountColumns = "id, email, COALESCE(password_hash, '') AS password_hash, email_verified, admin, two_factor_enabled"
+
 type postgresV1 struct {
 	con *sqlx.DB
 }
@@ -22,9 +25,9 @@ func NewPostgresRepositoryV1(connection *sqlx.DB) Repository {
 
 func (r postgresV1) Get(ctx context.Context, id uint64) (*types.Account, error) {
 	var account types.Account
-	err := r.con.GetContext(ctx, &account, "SELECT id, email, password_hash FROM accounts WHERE id=$1", id)
+	err := r.con.GetContext(ctx, &account, "SELECT "+accountColumns+" FROM accounts WHERE id=$1", id)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, fmt.Errorf("an account with id=%d was not found: %w", id, err)
+		return nil, fmt.Errorf("%w: an account with id=%d was not found", repository.ErrNotFound, id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch account by id(%d): %w", id, err)
@@ -35,9 +38,9 @@ func (r postgresV1) Get(ctx context.Context, id uint64) (*types.Account, error)
 
 func (r postgresV1) GetByEMail(ctx context.Context, email string) (*types.Account, error) {
 	var account types.Account
-	err := r.con.GetContext(ctx, &account, "SELECT id, email, password_hash FROM accounts WHERE email=$1", email)
+	err := r.con.GetContext(ctx, &account, "SELECT "+accountColumns+" FROM accounts WHERE email=$1", email)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, fmt.Errorf("an account with email=%s was not found: %w", email, err)
+		return nil, fmt.Errorf("%w: an account with email=%s was not found", repository.ErrNotFound, email)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch account by email(%s): %w", email, err)
@@ -46,15 +49,271 @@ func (r postgresV1) GetByEMail(ctx context.Context, email string) (*types.Accoun
 	return &account, nil
 }
 
-func (r postgresV1) Create(ctx context.Context, email, password string) error {
-	_, err := r.con.ExecContext(ctx, "INSERT INTO accounts (email, password_hash) VALUES($1, $2)", email, password)
+func (r postgresV1) Create(ctx context.Context, email, password string) (*types.Account, error) {
+	var account types.Account
+	err := r.con.GetContext(
+		ctx, &account,
+		`INSERT INTO accounts (email, password_hash) VALUES($1, $2)
+					returning `+accountColumns,
+		email, password,
+	)
 	if err == nil {
-		return nil
+		return &account, nil
+	}
+
+	if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+		return nil, fmt.Errorf("%w: an account with the given email already exists", repository.ErrUniquenessViolated)
+	}
+
+	return nil, fmt.Errorf("failed to insert account(%s): %w", email, err)
+}
+
+func (r postgresV1) SetEMailVerified(ctx context.Context, id uint64) error {
+	result, err := r.con.ExecContext(ctx, "UPDATE accounts SET email_verified=TRUE WHERE id=$1", id)
+	if err != nil {
+		return fmt.Errorf("failed to mark email of account(%d) as verified: %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r postgresV1) SetPasswordHash(ctx context.Context, id uint64, passwordHash string) error {
+	result, err := r.con.ExecContext(ctx, "UPDATE accounts SET password_hash=$2 WHERE id=$1", id, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password of account(%d): %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r postgresV1) SetPendingEMail(ctx context.Context, id uint64, email string) error {
+	result, err := r.con.ExecContext(ctx, "UPDATE accounts SET pending_email=$2 WHERE id=$1", id, email)
+	if err != nil {
+		return fmt.Errorf("failed to set pending email of account(%d): %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r postgresV1) ApplyPendingEMail(ctx context.Context, id uint64) (string, error) {
+	var email string
+	err := r.con.GetContext(
+		ctx, &email,
+		`UPDATE accounts SET email=pending_email, pending_email=NULL, email_verified=TRUE
+					WHERE id=$1 AND pending_email IS NOT NULL RETURNING email`,
+		id,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: account(%d) has no pending email", repository.ErrNotFound, id)
+	}
+	if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+		return "", fmt.Errorf("%w: an account with the pending email already exists", repository.ErrUniquenessViolated)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to apply pending email of account(%d): %w", id, err)
+	}
+
+	return email, nil
+}
+
+func (r postgresV1) Delete(
+	ctx context.Context, id uint64, policy types.AccountDeletionPolicy, transferTo uint64,
+) ([]string, error) {
+	tx, err := r.con.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	slugs, err := releaseUrls(ctx, tx, id, policy, transferTo)
+	if err != nil {
+		return nil, err
+	}
+
+	// refresh tokens go before their families
+	for _, table := range []string{
+		"refresh_tokens", "refresh_token_families", "account_tokens", "api_keys", "recovery_codes",
+		"two_factor_secrets", "account_identities", "data_exports",
+	} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE account_id=$1", id); err != nil {
+			return nil, fmt.Errorf("failed to delete %s of account(%d): %w", table, id, err)
+		}
 	}
 
+	// revisions of urls owned by others stay, without their author
+	if _, err := tx.ExecContext(ctx, "UPDATE url_revisions SET changed_by=NULL WHERE changed_by=$1", id); err != nil {
+		return nil, fmt.Errorf("failed to detach url revisions of account(%d): %w", id, err)
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM accounts WHERE id=$1", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete account(%d): %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return slugs, nil
+}
+
+// releaseUrls deals with the urls of an account about to be deleted as policy says.
+func releaseUrls(
+	ctx context.Context, tx *sqlx.Tx, accountID uint64, policy types.AccountDeletionPolicy, transferTo uint64,
+) ([]string, error) {
+	var slugs []string
+	var err error
+
+	switch policy {
+	case types.AccountDeletionPolicyAnonymize:
+		// revisions hold earlier destinations, which are as personal as the current one
+		_, err = tx.ExecContext(
+			ctx, "DELETE FROM url_revisions WHERE url_id IN (SELECT id FROM urls WHERE account_id=$1)", accountID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete url revisions of account(%d): %w", accountID, err)
+		}
+
+		err = tx.SelectContext(
+			ctx, &slugs,
+			`UPDATE urls SET account_id=NULL, disabled=TRUE, original_url='', password_hash=NULL
+					WHERE account_id=$1 RETURNING slug`,
+			accountID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to anonymize urls of account(%d): %w", accountID, err)
+		}
+
+	case types.AccountDeletionPolicyTransfer:
+		err = tx.SelectContext(
+			ctx, &slugs, "UPDATE urls SET account_id=$2 WHERE account_id=$1 RETURNING slug", accountID, transferTo,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transfer urls of account(%d) to account(%d): %w", accountID, transferTo, err)
+		}
+
+	case types.AccountDeletionPolicyDelete:
+		for _, table := range []string{"url_revisions", "url_visits", "url_visit_rollups"} {
+			_, err = tx.ExecContext(
+				ctx, "DELETE FROM "+table+" WHERE url_id IN (SELECT id FROM urls WHERE account_id=$1)", accountID,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete %s of account(%d): %w", table, accountID, err)
+			}
+		}
+
+		err = tx.SelectContext(ctx, &slugs, "DELETE FROM urls WHERE account_id=$1 RETURNING slug", accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete urls of account(%d): %w", accountID, err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown account deletion policy %q", policy)
+	}
+
+	return slugs, nil
+}
+
+func (r postgresV1) GetByIdentity(ctx context.Context, issuer, subject string) (*types.Account, error) {
+	var account types.Account
+	err := r.con.GetContext(
+		ctx, &account,
+		`SELECT `+accountColumns+` FROM accounts
+					WHERE id=(SELECT account_id FROM account_identities WHERE issuer=$1 AND subject=$2)`,
+		issuer, subject,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: no account is linked to %s of %s", repository.ErrNotFound, subject, issuer)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch account by identity(%s of %s): %w", subject, issuer, err)
+	}
+
+	return &account, nil
+}
+
+func (r postgresV1) LinkIdentity(ctx context.Context, id uint64, issuer, subject string) error {
+	_, err := r.con.ExecContext(
+		ctx,
+		"INSERT INTO account_identities(issuer, subject, account_id) VALUES ($1, $2, $3)",
+		issuer, subject, id,
+	)
 	if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
-		return fmt.Errorf("%w: an account with the given email already exists", repository.ErrUniquenessViolated)
+		return fmt.Errorf("%w: identity is already linked to an account", repository.ErrUniquenessViolated)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to link identity to account(%d): %w", id, err)
+	}
+
+	return nil
+}
+
+func (r postgresV1) CreateWithIdentity(ctx context.Context, email, issuer, subject string) (*types.Account, error) {
+	tx, err := r.con.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	return fmt.Errorf("failed to insert account(%s): %w", email, err)
+	// the provider has verified the email address already
+	var account types.Account
+	err = tx.GetContext(
+		ctx, &account,
+		`INSERT INTO accounts (email, password_hash, email_verified) VALUES($1, NULL, TRUE)
+					returning `+accountColumns,
+		email,
+	)
+	if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+		return nil, fmt.Errorf("%w: an account with the given email already exists", repository.ErrUniquenessViolated)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert account(%s): %w", email, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO account_identities(issuer, subject, account_id) VALUES ($1, $2, $3)",
+		issuer, subject, account.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link identity to account(%d): %w", account.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &account, nil
 }
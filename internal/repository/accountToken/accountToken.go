@@ -0,0 +1,59 @@
+package accountToken
+
+import (
+	"context"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/monitoring"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+// Repository keeps single-use account tokens. only hashes of tokens are stored, so a leaked table can not be used
+// to redeem them.
+type Repository interface {
+	Create(ctx context.Context, accountID uint64, purpose types.AccountTokenPurpose, tokenHash string, lifespan time.Duration) error
+	// Consume marks a valid token as used and returns the account it was issued for. it returns
+	// repository.ErrNotFound if the token does not exist, is expired or is already used.
+	Consume(ctx context.Context, purpose types.AccountTokenPurpose, tokenHash string) (accountID uint64, err error)
+	RevokeAll(ctx context.Context, accountID uint64, purpose types.AccountTokenPurpose) error
+}
+
+type metricWrapper struct {
+	*repository.BaseMetricWrapper
+
+	wrapped Repository
+}
+
+func NewMetricWrapper(wrapped Repository, metricCollector monitoring.MetricCollector, name string) Repository {
+	return &metricWrapper{
+		BaseMetricWrapper: repository.NewBaseMetricWrapper(metricCollector, name),
+		wrapped:           wrapped,
+	}
+}
+
+func (w metricWrapper) Create(
+	ctx context.Context, accountID uint64, purpose types.AccountTokenPurpose, tokenHash string, lifespan time.Duration,
+) error {
+	startedAt := time.Now()
+	err := w.wrapped.Create(ctx, accountID, purpose, tokenHash, lifespan)
+	w.RecordMetrics("Create", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) Consume(ctx context.Context, purpose types.AccountTokenPurpose, tokenHash string) (uint64, error) {
+	startedAt := time.Now()
+	accountID, err := w.wrapped.Consume(ctx, purpose, tokenHash)
+	w.RecordMetrics("Consume", time.Now().Sub(startedAt), err == nil)
+
+	return accountID, err
+}
+
+func (w metricWrapper) RevokeAll(ctx context.Context, accountID uint64, purpose types.AccountTokenPurpose) error {
+	startedAt := time.Now()
+	err := w.wrapped.RevokeAll(ctx, accountID, purpose)
+	w.RecordMetrics("RevokeAll", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
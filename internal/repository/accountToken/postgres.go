@@ -0,0 +1,69 @@
+package accountToken
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type postgresV1 struct {
+	con *sqlx.DB
+}
+
+func NewPostgresRepositoryV1(connection *sqlx.DB) Repository {
+	return &postgresV1{con: connection}
+}
+
+func (r postgresV1) Create(
+	ctx context.Context, accountID uint64, purpose types.AccountTokenPurpose, tokenHash string, lifespan time.Duration,
+) error {
+	_, err := r.con.ExecContext(
+		ctx,
+		"INSERT INTO account_tokens(account_id, purpose, token_hash, valid_until) VALUES ($1, $2, $3, $4)",
+		accountID, purpose, tokenHash, time.Now().UTC().Add(lifespan),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert account token: %w", err)
+	}
+
+	return nil
+}
+
+func (r postgresV1) Consume(ctx context.Context, purpose types.AccountTokenPurpose, tokenHash string) (uint64, error) {
+	// the token is checked and used in the same statement so that it can not be redeemed twice concurrently.
+	var accountID uint64
+	err := r.con.GetContext(
+		ctx, &accountID,
+		`UPDATE account_tokens SET used_at=CURRENT_TIMESTAMP
+					WHERE token_hash=$1 AND purpose=$2 AND used_at IS NULL AND valid_until > CURRENT_TIMESTAMP
+					returning account_id`,
+		tokenHash, purpose,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: account token is unknown, expired or already used", repository.ErrNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume account token: %w", err)
+	}
+
+	return accountID, nil
+}
+
+func (r postgresV1) RevokeAll(ctx context.Context, accountID uint64, purpose types.AccountTokenPurpose) error {
+	_, err := r.con.ExecContext(
+		ctx,
+		"UPDATE account_tokens SET used_at=CURRENT_TIMESTAMP WHERE account_id=$1 AND purpose=$2 AND used_at IS NULL",
+		accountID, purpose,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke account tokens of account(%d): %w", accountID, err)
+	}
+
+	return nil
+}
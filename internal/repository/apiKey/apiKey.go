@@ -0,0 +1,74 @@
+package apiKey
+
+import (
+	"context"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/monitoring"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+// Repository keeps personal api keys. like account tokens, only hashes of keys are stored.
+type Repository interface {
+	Create(ctx context.Context, apiKey *types.APIKey) (*types.APIKey, error)
+	// GetByHash returns repository.ErrNotFound if no usable key has the given hash. expired keys are still returned.
+	GetByHash(ctx context.Context, keyHash string) (*types.APIKey, error)
+	GetByAccountID(ctx context.Context, accountID uint64) ([]types.APIKey, error)
+	Revoke(ctx context.Context, accountID, id uint64) error
+	// Touch records that the key was used. it only writes once in a while, so busy keys do not hammer the database.
+	Touch(ctx context.Context, id uint64) error
+}
+
+type metricWrapper struct {
+	*repository.BaseMetricWrapper
+
+	wrapped Repository
+}
+
+func NewMetricWrapper(wrapped Repository, metricCollector monitoring.MetricCollector, name string) Repository {
+	return &metricWrapper{
+		BaseMetricWrapper: repository.NewBaseMetricWrapper(metricCollector, name),
+		wrapped:           wrapped,
+	}
+}
+
+func (w metricWrapper) Create(ctx context.Context, apiKey *types.APIKey) (*types.APIKey, error) {
+	startedAt := time.Now()
+	created, err := w.wrapped.Create(ctx, apiKey)
+	w.RecordMetrics("Create", time.Now().Sub(startedAt), err == nil)
+
+	return created, err
+}
+
+func (w metricWrapper) GetByHash(ctx context.Context, keyHash string) (*types.APIKey, error) {
+	startedAt := time.Now()
+	apiKey, err := w.wrapped.GetByHash(ctx, keyHash)
+	w.RecordMetrics("GetByHash", time.Now().Sub(startedAt), err == nil)
+
+	return apiKey, err
+}
+
+func (w metricWrapper) GetByAccountID(ctx context.Context, accountID uint64) ([]types.APIKey, error) {
+	startedAt := time.Now()
+	apiKeys, err := w.wrapped.GetByAccountID(ctx, accountID)
+	w.RecordMetrics("GetByAccountID", time.Now().Sub(startedAt), err == nil)
+
+	return apiKeys, err
+}
+
+func (w metricWrapper) Revoke(ctx context.Context, accountID, id uint64) error {
+	startedAt := time.Now()
+	err := w.wrapped.Revoke(ctx, accountID, id)
+	w.RecordMetrics("Revoke", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) Touch(ctx context.Context, id uint64) error {
+	startedAt := time.Now()
+	err := w.wrapped.Touch(ctx, id)
+	w.RecordMetrics("Touch", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
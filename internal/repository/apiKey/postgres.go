@@ -0,0 +1,103 @@
+package apiKey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+const apiKeyColumns = "id, account_id, name, prefix, key_hash, scopes, expires_at, last_used_at, created_at"
+
+type postgresV1 struct {
+	con *sqlx.DB
+}
+
+func NewPostgresRepositoryV1(connection *sqlx.DB) Repository {
+	return &postgresV1{con: connection}
+}
+
+func (r postgresV1) Create(ctx context.Context, apiKey *types.APIKey) (*types.APIKey, error) {
+	var created types.APIKey
+	err := r.con.GetContext(
+		ctx, &created,
+		`INSERT INTO api_keys(account_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6)
+					returning `+apiKeyColumns,
+		apiKey.AccountID, apiKey.Name, apiKey.Prefix, apiKey.KeyHash, apiKey.Scopes, apiKey.ExpiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert api key: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r postgresV1) GetByHash(ctx context.Context, keyHash string) (*types.APIKey, error) {
+	var apiKey types.APIKey
+	err := r.con.GetContext(
+		ctx, &apiKey,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash=$1 AND revoked=FALSE",
+		keyHash,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: api key is unknown or revoked", repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return &apiKey, nil
+}
+
+func (r postgresV1) GetByAccountID(ctx context.Context, accountID uint64) ([]types.APIKey, error) {
+	var apiKeys = make([]types.APIKey, 0)
+	err := r.con.SelectContext(
+		ctx, &apiKeys,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE account_id=$1 AND revoked=FALSE ORDER BY id",
+		accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys of account(%d): %w", accountID, err)
+	}
+
+	return apiKeys, nil
+}
+
+func (r postgresV1) Revoke(ctx context.Context, accountID, id uint64) error {
+	result, err := r.con.ExecContext(
+		ctx,
+		"UPDATE api_keys SET revoked=TRUE WHERE id=$1 AND account_id=$2 AND revoked=FALSE",
+		id, accountID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key(%d): %w", id, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key(%d): %w", id, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: api key(%d) of account(%d)", repository.ErrNotFound, id, accountID)
+	}
+
+	return nil
+}
+
+func (r postgresV1) Touch(ctx context.Context, id uint64) error {
+	_, err := r.con.ExecContext(
+		ctx,
+		`UPDATE api_keys SET last_used_at=CURRENT_TIMESTAMP
+					WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to touch api key(%d): %w", id, err)
+	}
+
+	return nil
+}
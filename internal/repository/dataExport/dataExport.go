@@ -0,0 +1,97 @@
+package dataExport
+
+import (
+	"context"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/monitoring"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+type Repository interface {
+	// Create saves a pending export. it returns repository.ErrUniquenessViolated if the account already has one
+	// waiting to be built.
+	Create(ctx context.Context, accountID uint64, downloadNonce string) (*types.DataExport, error)
+	Get(ctx context.Context, accountID, id uint64) (*types.DataExport, error)
+	// Claim marks the oldest pending export as running and returns it. exports left running for longer than
+	// staleAfter are claimed again, as whoever was building them is assumed to be gone. it returns
+	// repository.ErrNotFound if there is nothing to build.
+	Claim(ctx context.Context, staleAfter time.Duration) (*types.DataExport, error)
+	Complete(ctx context.Context, id uint64, archive []byte, lifespan time.Duration) error
+	Fail(ctx context.Context, id uint64) error
+	// GetArchive returns the archive of a ready export, or repository.ErrNotFound once it is expired or if
+	// downloadNonce is not the one of the export.
+	GetArchive(ctx context.Context, id uint64, downloadNonce string) ([]byte, error)
+	// DeleteExpired deletes the exports which can no longer be downloaded, along with their archives.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type metricWrapper struct {
+	*repository.BaseMetricWrapper
+
+	wrapped Repository
+}
+
+func NewMetricWrapper(wrapped Repository, metricCollector monitoring.MetricCollector, name string) Repository {
+	return &metricWrapper{
+		BaseMetricWrapper: repository.NewBaseMetricWrapper(metricCollector, name),
+		wrapped:           wrapped,
+	}
+}
+
+func (w metricWrapper) Create(ctx context.Context, accountID uint64, downloadNonce string) (*types.DataExport, error) {
+	startedAt := time.Now()
+	export, err := w.wrapped.Create(ctx, accountID, downloadNonce)
+	w.RecordMetrics("Create", time.Now().Sub(startedAt), err == nil)
+
+	return export, err
+}
+
+func (w metricWrapper) Get(ctx context.Context, accountID, id uint64) (*types.DataExport, error) {
+	startedAt := time.Now()
+	export, err := w.wrapped.Get(ctx, accountID, id)
+	w.RecordMetrics("Get", time.Now().Sub(startedAt), err == nil)
+
+	return export, err
+}
+
+func (w metricWrapper) Claim(ctx context.Context, staleAfter time.Duration) (*types.DataExport, error) {
+	startedAt := time.Now()
+	export, err := w.wrapped.Claim(ctx, staleAfter)
+	w.RecordMetrics("Claim", time.Now().Sub(startedAt), err == nil)
+
+	return export, err
+}
+
+func (w metricWrapper) Complete(ctx context.Context, id uint64, archive []byte, lifespan time.Duration) error {
+	startedAt := time.Now()
+	err := w.wrapped.Complete(ctx, id, archive, lifespan)
+	w.RecordMetrics("Complete", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) Fail(ctx context.Context, id uint64) error {
+	startedAt := time.Now()
+	err := w.wrapped.Fail(ctx, id)
+	w.RecordMetrics("Fail", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) GetArchive(ctx context.Context, id uint64, downloadNonce string) ([]byte, error) {
+	startedAt := time.Now()
+	archive, err := w.wrapped.GetArchive(ctx, id, downloadNonce)
+	w.RecordMetrics("GetArchive", time.Now().Sub(startedAt), err == nil)
+
+	return archive, err
+}
+
+func (w metricWrapper) DeleteExpired(ctx context.Context) (int64, error) {
+	startedAt := time.Now()
+	deleted, err := w.wrapped.DeleteExpired(ctx)
+	w.RecordMetrics("DeleteExpired", time.Now().Sub(startedAt), err == nil)
+
+	return deleted, err
+}
@@ -0,0 +1,142 @@
+package dataExport
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const dataExportColumns = "id, account_id, status, created_at, completed_at, expires_at, download_nonce"
+
+type postgresV1 struct {
+	con *sqlx.DB
+}
+
+func NewPostgresRepositoryV1(connection *sqlx.DB) Repository {
+	return &postgresV1{con: connection}
+}
+
+func (r postgresV1) Create(ctx context.Context, accountID uint64, downloadNonce string) (*types.DataExport, error) {
+	var export types.DataExport
+	err := r.con.GetContext(
+		ctx, &export,
+		"INSERT INTO data_exports(account_id, download_nonce) VALUES ($1, $2) RETURNING "+dataExportColumns,
+		accountID, downloadNonce,
+	)
+	if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+		return nil, fmt.Errorf("%w: account(%d) already has an unfinished export", repository.ErrUniquenessViolated, accountID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert data export of account(%d): %w", accountID, err)
+	}
+
+	return &export, nil
+}
+
+func (r postgresV1) Get(ctx context.Context, accountID, id uint64) (*types.DataExport, error) {
+	var export types.DataExport
+	err := r.con.GetContext(
+		ctx, &export,
+		"SELECT "+dataExportColumns+" FROM data_exports WHERE id=$1 AND account_id=$2",
+		id, accountID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: data export(%d) of account(%d)", repository.ErrNotFound, id, accountID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export(%d): %w", id, err)
+	}
+
+	return &export, nil
+}
+
+func (r postgresV1) Claim(ctx context.Context, staleAfter time.Duration) (*types.DataExport, error) {
+	var export types.DataExport
+	// exports being claimed by other instances are skipped instead of waited for.
+	err := r.con.GetContext(
+		ctx, &export,
+		`UPDATE data_exports SET status=$1, started_at=CURRENT_TIMESTAMP
+					WHERE id=(
+						SELECT id FROM data_exports
+						WHERE status=$2 OR (status=$1 AND started_at < $3)
+						ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED
+					)
+					RETURNING `+dataExportColumns,
+		types.DataExportStatusRunning, types.DataExportStatusPending, time.Now().UTC().Add(-staleAfter),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: no data export to build", repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim data export: %w", err)
+	}
+
+	return &export, nil
+}
+
+func (r postgresV1) Complete(ctx context.Context, id uint64, archive []byte, lifespan time.Duration) error {
+	now := time.Now().UTC()
+	_, err := r.con.ExecContext(
+		ctx,
+		"UPDATE data_exports SET status=$2, archive=$3, completed_at=$4, expires_at=$5 WHERE id=$1",
+		id, types.DataExportStatusReady, archive, now, now.Add(lifespan),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete data export(%d): %w", id, err)
+	}
+
+	return nil
+}
+
+func (r postgresV1) Fail(ctx context.Context, id uint64) error {
+	_, err := r.con.ExecContext(
+		ctx,
+		"UPDATE data_exports SET status=$2, completed_at=CURRENT_TIMESTAMP WHERE id=$1",
+		id, types.DataExportStatusFailed,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to fail data export(%d): %w", id, err)
+	}
+
+	return nil
+}
+
+func (r postgresV1) GetArchive(ctx context.Context, id uint64, downloadNonce string) ([]byte, error) {
+	var archive []byte
+	err := r.con.GetContext(
+		ctx, &archive,
+		`SELECT archive FROM data_exports
+					WHERE id=$1 AND download_nonce=$3 AND download_nonce <> '' AND status=$2
+						AND expires_at > CURRENT_TIMESTAMP`,
+		id, types.DataExportStatusReady, downloadNonce,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: data export(%d) is not ready or expired", repository.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive of data export(%d): %w", id, err)
+	}
+
+	return archive, nil
+}
+
+func (r postgresV1) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.con.ExecContext(ctx, "DELETE FROM data_exports WHERE expires_at <= CURRENT_TIMESTAMP")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired data exports: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired data exports: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,61 @@
+package loginAttempt
+
+import (
+	"context"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/monitoring"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+// Repository counts the failed login attempts of a subject, and blocks subjects from attempting again for a while.
+// subjects are opaque keys, such as an email address or a client ip.
+type Repository interface {
+	// AddFailure returns the failures of subject, the one being added included. failures are forgotten window after
+	// the first one. the subject is then blocked for delays[n-1] after its nth failure, in the same step, so that
+	// concurrent failures can not undo each other's blocks. the last delay locks the subject out, and applies to every
+	// failure after it as well.
+	AddFailure(ctx context.Context, subject string, window time.Duration, delays []time.Duration) (int64, error)
+	ResetFailures(ctx context.Context, subject string) error
+	GetBlock(ctx context.Context, subject string) (types.LoginBlock, error)
+}
+
+type metricWrapper struct {
+	*repository.BaseMetricWrapper
+
+	wrapped Repository
+}
+
+func NewMetricWrapper(wrapped Repository, metricCollector monitoring.MetricCollector, name string) Repository {
+	return &metricWrapper{
+		BaseMetricWrapper: repository.NewBaseMetricWrapper(metricCollector, name),
+		wrapped:           wrapped,
+	}
+}
+
+func (w metricWrapper) AddFailure(
+	ctx context.Context, subject string, window time.Duration, delays []time.Duration,
+) (int64, error) {
+	startedAt := time.Now()
+	failures, err := w.wrapped.AddFailure(ctx, subject, window, delays)
+	w.RecordMetrics("AddFailure", time.Now().Sub(startedAt), err == nil)
+
+	return failures, err
+}
+
+func (w metricWrapper) ResetFailures(ctx context.Context, subject string) error {
+	startedAt := time.Now()
+	err := w.wrapped.ResetFailures(ctx, subject)
+	w.RecordMetrics("ResetFailures", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) GetBlock(ctx context.Context, subject string) (types.LoginBlock, error) {
+	startedAt := time.Now()
+	block, err := w.wrapped.GetBlock(ctx, subject)
+	w.RecordMetrics("GetBlock", time.Now().Sub(startedAt), err == nil)
+
+	return block, err
+}
@@ -0,0 +1,108 @@
+package loginAttempt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+const (
+	blockValueDelayed   = "delayed"
+	blockValueLockedOut = "locked-out"
+)
+
+// addFailureScript only sets the expiry on the first failure, so that failing keeps a subject counted for no longer
+// than window. the block is decided from the count it has just made, a failure counted after another one always
+// blocks last.
+var addFailureScript = redis.NewScript(`
+local failures = redis.call("INCR", KEYS[1])
+if failures == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+
+local delays = #ARGV - 3
+if delays > 0 then
+	local delay = tonumber(ARGV[3 + math.min(failures, delays)])
+	if delay > 0 then
+		local value = ARGV[2]
+		if failures >= delays then
+			value = ARGV[3]
+		end
+		redis.call("SET", KEYS[2], value, "PX", delay)
+	end
+end
+
+return failures
+`)
+
+type redisV1 struct {
+	redis *redis.Client
+}
+
+func NewRedisRepositoryV1(redisClient *redis.Client) Repository {
+	return &redisV1{redis: redisClient}
+}
+
+func failuresKey(subject string) string {
+	return fmt.Sprintf("login-failures-%s", subject)
+}
+
+func blockKey(subject string) string {
+	return fmt.Sprintf("login-block-%s", subject)
+}
+
+func (r redisV1) AddFailure(
+	ctx context.Context, subject string, window time.Duration, delays []time.Duration,
+) (int64, error) {
+	args := make([]interface{}, 0, len(delays)+3)
+	args = append(args, window.Milliseconds(), blockValueDelayed, blockValueLockedOut)
+	for _, delay := range delays {
+		args = append(args, delay.Milliseconds())
+	}
+
+	failures, err := addFailureScript.Run(
+		ctx, r.redis, []string{failuresKey(subject), blockKey(subject)}, args...,
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count failed login attempt of %s: %w", subject, err)
+	}
+
+	return failures, nil
+}
+
+func (r redisV1) ResetFailures(ctx context.Context, subject string) error {
+	if err := r.redis.Del(ctx, failuresKey(subject)).Err(); err != nil {
+		return fmt.Errorf("failed to reset failed login attempts of %s: %w", subject, err)
+	}
+
+	return nil
+}
+
+func (r redisV1) GetBlock(ctx context.Context, subject string) (types.LoginBlock, error) {
+	pipe := r.redis.Pipeline()
+	valueCmd := pipe.Get(ctx, blockKey(subject))
+	ttlCmd := pipe.PTTL(ctx, blockKey(subject))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return types.LoginBlock{}, fmt.Errorf("failed to get login block of %s: %w", subject, err)
+	}
+
+	value, err := valueCmd.Result()
+	if errors.Is(err, redis.Nil) {
+		return types.LoginBlock{}, nil
+	}
+	if err != nil {
+		return types.LoginBlock{}, fmt.Errorf("failed to get login block of %s: %w", subject, err)
+	}
+
+	// the block may expire between both commands, a negative ttl means it is already gone
+	ttl := ttlCmd.Val()
+	if ttl <= 0 {
+		return types.LoginBlock{}, nil
+	}
+
+	return types.LoginBlock{LockedOut: value == blockValueLockedOut, RetryAfter: ttl}, nil
+}
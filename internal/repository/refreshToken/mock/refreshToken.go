@@ -37,33 +37,33 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockRepository) Create(ctx context.Context, accountID uint64, token string, lifespan time.Duration) (*types.RefreshToken, error) {
+func (m *MockRepository) Create(ctx context.Context, accountID uint64, tokenHash string, lifespan time.Duration, clientInfo types.ClientInfo) (*types.RefreshToken, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, accountID, token, lifespan)
+	ret := m.ctrl.Call(m, "Create", ctx, accountID, tokenHash, lifespan, clientInfo)
 	ret0, _ := ret[0].(*types.RefreshToken)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockRepositoryMockRecorder) Create(ctx, accountID, token, lifespan interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) Create(ctx, accountID, tokenHash, lifespan, clientInfo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), ctx, accountID, token, lifespan)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), ctx, accountID, tokenHash, lifespan, clientInfo)
 }
 
 // CreateWithFamily mocks base method.
-func (m *MockRepository) CreateWithFamily(ctx context.Context, accountID uint64, token string, lifespan time.Duration, family uint64) (*types.RefreshToken, error) {
+func (m *MockRepository) CreateWithFamily(ctx context.Context, accountID uint64, tokenHash string, lifespan time.Duration, family uint64) (*types.RefreshToken, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateWithFamily", ctx, accountID, token, lifespan, family)
+	ret := m.ctrl.Call(m, "CreateWithFamily", ctx, accountID, tokenHash, lifespan, family)
 	ret0, _ := ret[0].(*types.RefreshToken)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateWithFamily indicates an expected call of CreateWithFamily.
-func (mr *MockRepositoryMockRecorder) CreateWithFamily(ctx, accountID, token, lifespan, family interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CreateWithFamily(ctx, accountID, tokenHash, lifespan, family interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithFamily", reflect.TypeOf((*MockRepository)(nil).CreateWithFamily), ctx, accountID, token, lifespan, family)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithFamily", reflect.TypeOf((*MockRepository)(nil).CreateWithFamily), ctx, accountID, tokenHash, lifespan, family)
 }
 
 // Disable mocks base method.
@@ -80,19 +80,77 @@ func (mr *MockRepositoryMockRecorder) Disable(ctx, id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disable", reflect.TypeOf((*MockRepository)(nil).Disable), ctx, id)
 }
 
-// Get mocks base method.
-func (m *MockRepository) Get(ctx context.Context, token string) (*types.RefreshToken, error) {
+// GetActiveFamilies mocks base method.
+func (m *MockRepository) GetActiveFamilies(ctx context.Context, accountID uint64) ([]types.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", ctx, token)
+	ret := m.ctrl.Call(m, "GetActiveFamilies", ctx, accountID)
+	ret0, _ := ret[0].([]types.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveFamilies indicates an expected call of GetActiveFamilies.
+func (mr *MockRepositoryMockRecorder) GetActiveFamilies(ctx, accountID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveFamilies", reflect.TypeOf((*MockRepository)(nil).GetActiveFamilies), ctx, accountID)
+}
+
+// GetByHash mocks base method.
+func (m *MockRepository) GetByHash(ctx context.Context, tokenHash string) (*types.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHash", ctx, tokenHash)
 	ret0, _ := ret[0].(*types.RefreshToken)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Get indicates an expected call of Get.
-func (mr *MockRepositoryMockRecorder) Get(ctx, token interface{}) *gomock.Call {
+// GetByHash indicates an expected call of GetByHash.
+func (mr *MockRepositoryMockRecorder) GetByHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockRepository)(nil).GetByHash), ctx, tokenHash)
+}
+
+// GetFamilies mocks base method.
+func (m *MockRepository) GetFamilies(ctx context.Context, accountID uint64) ([]types.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFamilies", ctx, accountID)
+	ret0, _ := ret[0].([]types.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFamilies indicates an expected call of GetFamilies.
+func (mr *MockRepositoryMockRecorder) GetFamilies(ctx, accountID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFamilies", reflect.TypeOf((*MockRepository)(nil).GetFamilies), ctx, accountID)
+}
+
+// RevokeAllForAccount mocks base method.
+func (m *MockRepository) RevokeAllForAccount(ctx context.Context, accountID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllForAccount", ctx, accountID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAllForAccount indicates an expected call of RevokeAllForAccount.
+func (mr *MockRepositoryMockRecorder) RevokeAllForAccount(ctx, accountID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllForAccount", reflect.TypeOf((*MockRepository)(nil).RevokeAllForAccount), ctx, accountID)
+}
+
+// RevokeFamily mocks base method.
+func (m *MockRepository) RevokeFamily(ctx context.Context, accountID, family uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFamily", ctx, accountID, family)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeFamily indicates an expected call of RevokeFamily.
+func (mr *MockRepositoryMockRecorder) RevokeFamily(ctx, accountID, family interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRepository)(nil).Get), ctx, token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFamily", reflect.TypeOf((*MockRepository)(nil).RevokeFamily), ctx, accountID, family)
 }
 
 // SetCompromisedState mocks base method.
@@ -20,41 +20,81 @@ func NewPostgresRepositoryV1(connection *sqlx.DB) Repository {
 	return &postgresV1{con: connection}
 }
 
-func (r postgresV1) Create(ctx context.Context, accountID uint64, token string, lifespan time.Duration) (*types.RefreshToken, error) {
+func (r postgresV1) Create(
+	ctx context.Context, accountID uint64, tokenHash string, lifespan time.Duration, clientInfo types.ClientInfo,
+) (*types.RefreshToken, error) {
+	tx, err := r.con.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var family uint64
+	err = tx.GetContext(
+		ctx, &family,
+		`INSERT INTO refresh_token_families(family, account_id, user_agent, client_ip)
+					VALUES (nextval('refresh_token_family'), $1, $2, $3) returning family`,
+		accountID, clientInfo.UserAgent, clientInfo.ClientIP,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert refresh token family: %w", err)
+	}
+
 	var refreshToken types.RefreshToken
-	err := r.con.GetContext(
+	err = tx.GetContext(
 		ctx, &refreshToken,
-		`INSERT INTO refresh_tokens(account_id, token, valid_until) VALUES ($1, $2, $3)
-					returning id, account_id, token, valid_until, compromised, disabled, family, created_at`,
-		accountID, token, time.Now().UTC().Add(lifespan),
+		`INSERT INTO refresh_tokens(account_id, token_hash, valid_until, family) VALUES ($1, $2, $3, $4)
+					returning id, account_id, token_hash, valid_until, compromised, disabled, revoked, family, created_at`,
+		accountID, tokenHash, time.Now().UTC().Add(lifespan), family,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert refresh token: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return &refreshToken, nil
 }
 
-func (r postgresV1) CreateWithFamily(ctx context.Context, accountID uint64, token string, lifespan time.Duration, family uint64) (*types.RefreshToken, error) {
+func (r postgresV1) CreateWithFamily(ctx context.Context, accountID uint64, tokenHash string, lifespan time.Duration, family uint64) (*types.RefreshToken, error) {
+	tx, err := r.con.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	var refreshToken types.RefreshToken
-	err := r.con.GetContext(
+	err = tx.GetContext(
 		ctx, &refreshToken,
-		`INSERT INTO refresh_tokens(account_id, token, valid_until, family) VALUES ($1, $2, $3, $4)
- 					returning id, account_id, token, valid_until, compromised, disabled, family, created_at`,
-		accountID, token, time.Now().UTC().Add(lifespan), family,
+		`INSERT INTO refresh_tokens(account_id, token_hash, valid_until, family) VALUES ($1, $2, $3, $4)
+ 					returning id, account_id, token_hash, valid_until, compromised, disabled, revoked, family, created_at`,
+		accountID, tokenHash, time.Now().UTC().Add(lifespan), family,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert refresh token: %w", err)
 	}
 
+	_, err = tx.ExecContext(
+		ctx, "UPDATE refresh_token_families SET last_used_at=CURRENT_TIMESTAMP WHERE family=$1", family,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update last use of refresh token family: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return &refreshToken, nil
 }
 
-func (r postgresV1) Get(ctx context.Context, tokenString string) (*types.RefreshToken, error) {
+func (r postgresV1) GetByHash(ctx context.Context, tokenHash string) (*types.RefreshToken, error) {
 	var token types.RefreshToken
-	err := r.con.GetContext(ctx, &token, "SELECT id, account_id, token, valid_until, compromised, disabled, family FROM refresh_tokens WHERE token=$1", tokenString)
+	err := r.con.GetContext(ctx, &token, "SELECT id, account_id, token_hash, valid_until, compromised, disabled, revoked, family FROM refresh_tokens WHERE token_hash=$1", tokenHash)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, fmt.Errorf("%w: refresh token not found(by token string)", err)
+		return nil, fmt.Errorf("%w: refresh token not found(by token hash)", repository.ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch refresh token: %w", err)
@@ -96,3 +136,68 @@ func (r postgresV1) SetCompromisedState(ctx context.Context, family uint64) erro
 
 	return nil
 }
+
+func (r postgresV1) RevokeAllForAccount(ctx context.Context, accountID uint64) error {
+	_, err := r.con.ExecContext(
+		ctx, "UPDATE refresh_tokens SET revoked=TRUE WHERE account_id=$1 AND NOT revoked", accountID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens of account(%d): %w", accountID, err)
+	}
+
+	return nil
+}
+
+func (r postgresV1) RevokeFamily(ctx context.Context, accountID, family uint64) error {
+	result, err := r.con.ExecContext(
+		ctx, "UPDATE refresh_tokens SET revoked=TRUE WHERE account_id=$1 AND family=$2", accountID, family,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r postgresV1) GetActiveFamilies(ctx context.Context, accountID uint64) ([]types.Session, error) {
+	sessions := make([]types.Session, 0)
+	err := r.con.SelectContext(
+		ctx, &sessions,
+		`SELECT family, user_agent, client_ip, created_at, last_used_at FROM refresh_token_families f
+					WHERE account_id=$1 AND EXISTS(
+						SELECT 1 FROM refresh_tokens t
+						WHERE t.family=f.family AND NOT t.disabled AND NOT t.compromised AND NOT t.revoked
+						  AND t.valid_until > CURRENT_TIMESTAMP
+					)
+					ORDER BY last_used_at DESC`,
+		accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch active refresh token families: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r postgresV1) GetFamilies(ctx context.Context, accountID uint64) ([]types.Session, error) {
+	sessions := make([]types.Session, 0)
+	err := r.con.SelectContext(
+		ctx, &sessions,
+		`SELECT family, user_agent, client_ip, created_at, last_used_at FROM refresh_token_families
+					WHERE account_id=$1 ORDER BY created_at DESC`,
+		accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch refresh token families: %w", err)
+	}
+
+	return sessions, nil
+}
@@ -10,11 +10,23 @@ import (
 )
 
 type Repository interface {
-	Create(ctx context.Context, accountID uint64, token string, lifespan time.Duration) (*types.RefreshToken, error)
-	CreateWithFamily(ctx context.Context, accountID uint64, token string, lifespan time.Duration, family uint64) (*types.RefreshToken, error)
-	Get(ctx context.Context, token string) (*types.RefreshToken, error)
+	// Create saves the first refresh token of a new family, opened by the given client.
+	// tokens are only ever saved and looked up by their hash.
+	Create(ctx context.Context, accountID uint64, tokenHash string, lifespan time.Duration, clientInfo types.ClientInfo) (*types.RefreshToken, error)
+	// CreateWithFamily saves a refresh token of an existing family and marks the family as used.
+	CreateWithFamily(ctx context.Context, accountID uint64, tokenHash string, lifespan time.Duration, family uint64) (*types.RefreshToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*types.RefreshToken, error)
 	Disable(ctx context.Context, id uint64) error
 	SetCompromisedState(ctx context.Context, family uint64) error
+	// RevokeAllForAccount revokes every refresh token of every family of an account.
+	RevokeAllForAccount(ctx context.Context, accountID uint64) error
+	// RevokeFamily revokes the refresh tokens of a family of an account. it returns repository.ErrNotFound if the
+	// account has no such family.
+	RevokeFamily(ctx context.Context, accountID, family uint64) error
+	// GetActiveFamilies returns the families of an account which still have a usable refresh token.
+	GetActiveFamilies(ctx context.Context, accountID uint64) ([]types.Session, error)
+	// GetFamilies returns every family of an account, including the ended ones.
+	GetFamilies(ctx context.Context, accountID uint64) ([]types.Session, error)
 }
 
 type metricWrapper struct {
@@ -30,26 +42,28 @@ func NewMetricWrapper(wrapped Repository, metricCollector monitoring.MetricColle
 	}
 }
 
-func (w metricWrapper) Create(ctx context.Context, accountID uint64, token string, lifespan time.Duration) (*types.RefreshToken, error) {
+func (w metricWrapper) Create(
+	ctx context.Context, accountID uint64, tokenHash string, lifespan time.Duration, clientInfo types.ClientInfo,
+) (*types.RefreshToken, error) {
 	startedAt := time.Now()
-	refreshToken, err := w.wrapped.Create(ctx, accountID, token, lifespan)
+	refreshToken, err := w.wrapped.Create(ctx, accountID, tokenHash, lifespan, clientInfo)
 	w.RecordMetrics("Create", time.Now().Sub(startedAt), err == nil)
 
 	return refreshToken, err
 }
 
-func (w metricWrapper) CreateWithFamily(ctx context.Context, accountID uint64, token string, lifespan time.Duration, family uint64) (*types.RefreshToken, error) {
+func (w metricWrapper) CreateWithFamily(ctx context.Context, accountID uint64, tokenHash string, lifespan time.Duration, family uint64) (*types.RefreshToken, error) {
 	startedAt := time.Now()
-	refreshToken, err := w.wrapped.CreateWithFamily(ctx, accountID, token, lifespan, family)
+	refreshToken, err := w.wrapped.CreateWithFamily(ctx, accountID, tokenHash, lifespan, family)
 	w.RecordMetrics("CreateWithFamily", time.Now().Sub(startedAt), err == nil)
 
 	return refreshToken, err
 }
 
-func (w metricWrapper) Get(ctx context.Context, token string) (*types.RefreshToken, error) {
+func (w metricWrapper) GetByHash(ctx context.Context, tokenHash string) (*types.RefreshToken, error) {
 	startedAt := time.Now()
-	refreshToken, err := w.wrapped.Get(ctx, token)
-	w.RecordMetrics("Get", time.Now().Sub(startedAt), err == nil)
+	refreshToken, err := w.wrapped.GetByHash(ctx, tokenHash)
+	w.RecordMetrics("GetByHash", time.Now().Sub(startedAt), err == nil)
 
 	return refreshToken, err
 }
@@ -69,3 +83,35 @@ func (w metricWrapper) SetCompromisedState(ctx context.Context, family uint64) e
 
 	return err
 }
+
+func (w metricWrapper) RevokeAllForAccount(ctx context.Context, accountID uint64) error {
+	startedAt := time.Now()
+	err := w.wrapped.RevokeAllForAccount(ctx, accountID)
+	w.RecordMetrics("RevokeAllForAccount", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) RevokeFamily(ctx context.Context, accountID, family uint64) error {
+	startedAt := time.Now()
+	err := w.wrapped.RevokeFamily(ctx, accountID, family)
+	w.RecordMetrics("RevokeFamily", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) GetActiveFamilies(ctx context.Context, accountID uint64) ([]types.Session, error) {
+	startedAt := time.Now()
+	sessions, err := w.wrapped.GetActiveFamilies(ctx, accountID)
+	w.RecordMetrics("GetActiveFamilies", time.Now().Sub(startedAt), err == nil)
+
+	return sessions, err
+}
+
+func (w metricWrapper) GetFamilies(ctx context.Context, accountID uint64) ([]types.Session, error) {
+	startedAt := time.Now()
+	sessions, err := w.wrapped.GetFamilies(ctx, accountID)
+	w.RecordMetrics("GetFamilies", time.Now().Sub(startedAt), err == nil)
+
+	return sessions, err
+}
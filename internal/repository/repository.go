@@ -10,6 +10,7 @@ import (
 var (
 	ErrNotFound           = errors.New("not found")
 	ErrUniquenessViolated = errors.New("requested operation violates uniqueness of a field")
+	ErrLimitReached       = errors.New("requested operation exceeds a configured limit")
 )
 
 type BaseMetricWrapper struct {
@@ -0,0 +1,127 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+type cachedSession struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+type cachedAccount struct {
+	revokedBefore time.Time
+	expiresAt     time.Time
+}
+
+// inProcessCacheV1 keeps revocations in memory for a short while, so that checking an access token does not need a
+// round-trip on every request. revocations made by other instances take up to ttl to be seen.
+type inProcessCacheV1 struct {
+	ttl       time.Duration
+	nextLayer Repository
+
+	lock      sync.Mutex
+	sessions  map[uint64]cachedSession
+	accounts  map[uint64]cachedAccount
+	nextPrune time.Time
+}
+
+func NewInProcessCacheV1(cacheTTL time.Duration, nextLayer Repository) Repository {
+	return &inProcessCacheV1{
+		ttl:       cacheTTL,
+		nextLayer: nextLayer,
+		sessions:  make(map[uint64]cachedSession),
+		accounts:  make(map[uint64]cachedAccount),
+	}
+}
+
+// prune drops expired entries, at most once per ttl. the lock must be held.
+func (c *inProcessCacheV1) prune(now time.Time) {
+	if now.Before(c.nextPrune) {
+		return
+	}
+
+	for id, session := range c.sessions {
+		if now.After(session.expiresAt) {
+			delete(c.sessions, id)
+		}
+	}
+	for id, account := range c.accounts {
+		if now.After(account.expiresAt) {
+			delete(c.accounts, id)
+		}
+	}
+
+	c.nextPrune = now.Add(c.ttl)
+}
+
+func (c *inProcessCacheV1) RevokeSession(ctx context.Context, sessionID uint64, ttl time.Duration) error {
+	if err := c.nextLayer.RevokeSession(ctx, sessionID, ttl); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.prune(now)
+	c.sessions[sessionID] = cachedSession{revoked: true, expiresAt: now.Add(c.ttl)}
+
+	return nil
+}
+
+func (c *inProcessCacheV1) RevokeAccountBefore(ctx context.Context, accountID uint64, before time.Time, ttl time.Duration) error {
+	if err := c.nextLayer.RevokeAccountBefore(ctx, accountID, before, ttl); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.prune(now)
+	c.accounts[accountID] = cachedAccount{revokedBefore: before, expiresAt: now.Add(c.ttl)}
+
+	return nil
+}
+
+func (c *inProcessCacheV1) Get(ctx context.Context, accountID, sessionID uint64) (types.Revocation, error) {
+	now := time.Now()
+
+	c.lock.Lock()
+	session, sessionFound := c.sessions[sessionID]
+	account, accountFound := c.accounts[accountID]
+	c.lock.Unlock()
+
+	if sessionFound && accountFound && now.Before(session.expiresAt) && now.Before(account.expiresAt) {
+		return types.Revocation{SessionRevoked: session.revoked, RevokedBefore: account.revokedBefore}, nil
+	}
+
+	revocation, err := c.nextLayer.Get(ctx, accountID, sessionID)
+	if err != nil {
+		return types.Revocation{}, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// revocations only ever grow, so one made by this instance while fetching is not overwritten by a stale answer.
+	if cached, ok := c.sessions[sessionID]; ok && cached.revoked && now.Before(cached.expiresAt) {
+		revocation.SessionRevoked = true
+	}
+	if cached, ok := c.accounts[accountID]; ok && cached.revokedBefore.After(revocation.RevokedBefore) && now.Before(cached.expiresAt) {
+		revocation.RevokedBefore = cached.revokedBefore
+	}
+
+	c.prune(now)
+	c.sessions[sessionID] = cachedSession{revoked: revocation.SessionRevoked, expiresAt: now.Add(c.ttl)}
+	c.accounts[accountID] = cachedAccount{revokedBefore: revocation.RevokedBefore, expiresAt: now.Add(c.ttl)}
+
+	return revocation, nil
+}
@@ -0,0 +1,63 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+type redisV1 struct {
+	redis *redis.Client
+}
+
+func NewRedisRepositoryV1(redisClient *redis.Client) Repository {
+	return &redisV1{redis: redisClient}
+}
+
+func sessionKey(sessionID uint64) string {
+	return fmt.Sprintf("revoked-session-%d", sessionID)
+}
+
+func accountKey(accountID uint64) string {
+	return fmt.Sprintf("revoked-account-%d", accountID)
+}
+
+func (r redisV1) RevokeSession(ctx context.Context, sessionID uint64, ttl time.Duration) error {
+	if err := r.redis.Set(ctx, sessionKey(sessionID), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke session(%d): %w", sessionID, err)
+	}
+
+	return nil
+}
+
+func (r redisV1) RevokeAccountBefore(ctx context.Context, accountID uint64, before time.Time, ttl time.Duration) error {
+	if err := r.redis.Set(ctx, accountKey(accountID), before.Unix(), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke access tokens of account(%d): %w", accountID, err)
+	}
+
+	return nil
+}
+
+func (r redisV1) Get(ctx context.Context, accountID, sessionID uint64) (types.Revocation, error) {
+	values, err := r.redis.MGet(ctx, sessionKey(sessionID), accountKey(accountID)).Result()
+	if err != nil {
+		return types.Revocation{}, fmt.Errorf("failed to fetch revocations: %w", err)
+	}
+
+	var revocation types.Revocation
+	revocation.SessionRevoked = values[0] != nil
+
+	if rawBefore, ok := values[1].(string); ok {
+		before, err := strconv.ParseInt(rawBefore, 10, 64)
+		if err != nil {
+			return types.Revocation{}, fmt.Errorf("malformed revocation of account(%d): %w", accountID, err)
+		}
+		revocation.RevokedBefore = time.Unix(before, 0).UTC()
+	}
+
+	return revocation, nil
+}
@@ -0,0 +1,55 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/monitoring"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+// Repository is a denylist of access tokens. entries only need to outlive the access tokens they reject, so they
+// are kept for ttl.
+type Repository interface {
+	RevokeSession(ctx context.Context, sessionID uint64, ttl time.Duration) error
+	RevokeAccountBefore(ctx context.Context, accountID uint64, before time.Time, ttl time.Duration) error
+	Get(ctx context.Context, accountID, sessionID uint64) (types.Revocation, error)
+}
+
+type metricWrapper struct {
+	*repository.BaseMetricWrapper
+
+	wrapped Repository
+}
+
+func NewMetricWrapper(wrapped Repository, metricCollector monitoring.MetricCollector, name string) Repository {
+	return &metricWrapper{
+		BaseMetricWrapper: repository.NewBaseMetricWrapper(metricCollector, name),
+		wrapped:           wrapped,
+	}
+}
+
+func (w metricWrapper) RevokeSession(ctx context.Context, sessionID uint64, ttl time.Duration) error {
+	startedAt := time.Now()
+	err := w.wrapped.RevokeSession(ctx, sessionID, ttl)
+	w.RecordMetrics("RevokeSession", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) RevokeAccountBefore(ctx context.Context, accountID uint64, before time.Time, ttl time.Duration) error {
+	startedAt := time.Now()
+	err := w.wrapped.RevokeAccountBefore(ctx, accountID, before, ttl)
+	w.RecordMetrics("RevokeAccountBefore", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) Get(ctx context.Context, accountID, sessionID uint64) (types.Revocation, error) {
+	startedAt := time.Now()
+	revocation, err := w.wrapped.Get(ctx, accountID, sessionID)
+	w.RecordMetrics("Get", time.Now().Sub(startedAt), err == nil)
+
+	return revocation, err
+}
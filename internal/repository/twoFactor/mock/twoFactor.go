@@ -0,0 +1,121 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/twoFactor/twoFactor.go
+
+// Package mock_twoFactor is a generated GoMock package.
+package mock_twoFactor
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	types "github.com/h3isenbug/url-shortener/internal/types"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ConsumeRecoveryCode mocks base method.
+func (m *MockRepository) ConsumeRecoveryCode(ctx context.Context, accountID uint64, codeHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeRecoveryCode", ctx, accountID, codeHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeRecoveryCode indicates an expected call of ConsumeRecoveryCode.
+func (mr *MockRepositoryMockRecorder) ConsumeRecoveryCode(ctx, accountID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeRecoveryCode", reflect.TypeOf((*MockRepository)(nil).ConsumeRecoveryCode), ctx, accountID, codeHash)
+}
+
+// Disable mocks base method.
+func (m *MockRepository) Disable(ctx context.Context, accountID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Disable", ctx, accountID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Disable indicates an expected call of Disable.
+func (mr *MockRepositoryMockRecorder) Disable(ctx, accountID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disable", reflect.TypeOf((*MockRepository)(nil).Disable), ctx, accountID)
+}
+
+// Enable mocks base method.
+func (m *MockRepository) Enable(ctx context.Context, accountID uint64, recoveryCodeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enable", ctx, accountID, recoveryCodeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enable indicates an expected call of Enable.
+func (mr *MockRepositoryMockRecorder) Enable(ctx, accountID, recoveryCodeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enable", reflect.TypeOf((*MockRepository)(nil).Enable), ctx, accountID, recoveryCodeHashes)
+}
+
+// GetSecret mocks base method.
+func (m *MockRepository) GetSecret(ctx context.Context, accountID uint64) (*types.TwoFactorSecret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecret", ctx, accountID)
+	ret0, _ := ret[0].(*types.TwoFactorSecret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecret indicates an expected call of GetSecret.
+func (mr *MockRepositoryMockRecorder) GetSecret(ctx, accountID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecret", reflect.TypeOf((*MockRepository)(nil).GetSecret), ctx, accountID)
+}
+
+// SaveSecret mocks base method.
+func (m *MockRepository) SaveSecret(ctx context.Context, accountID uint64, secret []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSecret", ctx, accountID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSecret indicates an expected call of SaveSecret.
+func (mr *MockRepositoryMockRecorder) SaveSecret(ctx, accountID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSecret", reflect.TypeOf((*MockRepository)(nil).SaveSecret), ctx, accountID, secret)
+}
+
+// UseCounter mocks base method.
+func (m *MockRepository) UseCounter(ctx context.Context, accountID, counter uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseCounter", ctx, accountID, counter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseCounter indicates an expected call of UseCounter.
+func (mr *MockRepositoryMockRecorder) UseCounter(ctx, accountID, counter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseCounter", reflect.TypeOf((*MockRepository)(nil).UseCounter), ctx, accountID, counter)
+}
@@ -0,0 +1,146 @@
+package twoFactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/jmoiron/sqlx"
+)
+
+type postgresV1 struct {
+	con *sqlx.DB
+}
+
+func NewPostgresRepositoryV1(connection *sqlx.DB) Repository {
+	return &postgresV1{con: connection}
+}
+
+func (r postgresV1) SaveSecret(ctx context.Context, accountID uint64, secret []byte) error {
+	_, err := r.con.ExecContext(
+		ctx,
+		`INSERT INTO two_factor_secrets(account_id, secret) VALUES ($1, $2)
+					ON CONFLICT (account_id) DO UPDATE SET secret=$2, last_used_counter=0, created_at=CURRENT_TIMESTAMP`,
+		accountID, secret,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save two-factor secret of account(%d): %w", accountID, err)
+	}
+
+	return nil
+}
+
+func (r postgresV1) GetSecret(ctx context.Context, accountID uint64) (*types.TwoFactorSecret, error) {
+	var secret types.TwoFactorSecret
+	err := r.con.GetContext(
+		ctx, &secret,
+		"SELECT account_id, secret, last_used_counter FROM two_factor_secrets WHERE account_id=$1",
+		accountID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: two-factor secret of account(%d)", repository.ErrNotFound, accountID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor secret of account(%d): %w", accountID, err)
+	}
+
+	return &secret, nil
+}
+
+func (r postgresV1) Enable(ctx context.Context, accountID uint64, recoveryCodeHashes []string) error {
+	tx, err := r.con.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE accounts SET two_factor_enabled=TRUE WHERE id=$1", accountID); err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE account_id=$1", accountID); err != nil {
+		return fmt.Errorf("failed to delete previous recovery codes: %w", err)
+	}
+	for _, codeHash := range recoveryCodeHashes {
+		_, err := tx.ExecContext(
+			ctx, "INSERT INTO recovery_codes(account_id, code_hash) VALUES ($1, $2)", accountID, codeHash,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert recovery code: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r postgresV1) Disable(ctx context.Context, accountID uint64) error {
+	tx, err := r.con.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE accounts SET two_factor_enabled=FALSE WHERE id=$1", accountID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE account_id=$1", accountID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM two_factor_secrets WHERE account_id=$1", accountID); err != nil {
+		return fmt.Errorf("failed to delete two-factor secret: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r postgresV1) UseCounter(ctx context.Context, accountID, counter uint64) error {
+	result, err := r.con.ExecContext(
+		ctx,
+		"UPDATE two_factor_secrets SET last_used_counter=$2 WHERE account_id=$1 AND last_used_counter < $2",
+		accountID, counter,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record use of two-factor code: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to record use of two-factor code: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: two-factor code was already used", repository.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r postgresV1) ConsumeRecoveryCode(ctx context.Context, accountID uint64, codeHash string) error {
+	result, err := r.con.ExecContext(
+		ctx,
+		"UPDATE recovery_codes SET used_at=CURRENT_TIMESTAMP WHERE account_id=$1 AND code_hash=$2 AND used_at IS NULL",
+		accountID, codeHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: recovery code is unknown or already used", repository.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package twoFactor
+
+import (
+	"context"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/monitoring"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+// Repository keeps TOTP secrets and recovery codes of accounts. like other single-use tokens, only hashes of recovery
+// codes are stored.
+type Repository interface {
+	// SaveSecret starts a new enrollment, replacing the secret of any enrollment that was not confirmed.
+	SaveSecret(ctx context.Context, accountID uint64, secret []byte) error
+	GetSecret(ctx context.Context, accountID uint64) (*types.TwoFactorSecret, error)
+	// Enable confirms the enrollment of the account and replaces its recovery codes.
+	Enable(ctx context.Context, accountID uint64, recoveryCodeHashes []string) error
+	Disable(ctx context.Context, accountID uint64) error
+	// UseCounter records that the code of counter was used. it returns repository.ErrNotFound if a code of the same
+	// or a later period was already used.
+	UseCounter(ctx context.Context, accountID, counter uint64) error
+	// ConsumeRecoveryCode returns repository.ErrNotFound if the code is unknown or already used.
+	ConsumeRecoveryCode(ctx context.Context, accountID uint64, codeHash string) error
+}
+
+type metricWrapper struct {
+	*repository.BaseMetricWrapper
+
+	wrapped Repository
+}
+
+func NewMetricWrapper(wrapped Repository, metricCollector monitoring.MetricCollector, name string) Repository {
+	return &metricWrapper{
+		BaseMetricWrapper: repository.NewBaseMetricWrapper(metricCollector, name),
+		wrapped:           wrapped,
+	}
+}
+
+func (w metricWrapper) SaveSecret(ctx context.Context, accountID uint64, secret []byte) error {
+	startedAt := time.Now()
+	err := w.wrapped.SaveSecret(ctx, accountID, secret)
+	w.RecordMetrics("SaveSecret", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) GetSecret(ctx context.Context, accountID uint64) (*types.TwoFactorSecret, error) {
+	startedAt := time.Now()
+	secret, err := w.wrapped.GetSecret(ctx, accountID)
+	w.RecordMetrics("GetSecret", time.Now().Sub(startedAt), err == nil)
+
+	return secret, err
+}
+
+func (w metricWrapper) Enable(ctx context.Context, accountID uint64, recoveryCodeHashes []string) error {
+	startedAt := time.Now()
+	err := w.wrapped.Enable(ctx, accountID, recoveryCodeHashes)
+	w.RecordMetrics("Enable", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) Disable(ctx context.Context, accountID uint64) error {
+	startedAt := time.Now()
+	err := w.wrapped.Disable(ctx, accountID)
+	w.RecordMetrics("Disable", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) UseCounter(ctx context.Context, accountID, counter uint64) error {
+	startedAt := time.Now()
+	err := w.wrapped.UseCounter(ctx, accountID, counter)
+	w.RecordMetrics("UseCounter", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) ConsumeRecoveryCode(ctx context.Context, accountID uint64, codeHash string) error {
+	startedAt := time.Now()
+	err := w.wrapped.ConsumeRecoveryCode(ctx, accountID, codeHash)
+	w.RecordMetrics("ConsumeRecoveryCode", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
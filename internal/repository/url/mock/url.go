@@ -36,17 +36,17 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 }
 
 // CreateShortUrl mocks base method.
-func (m *MockRepository) CreateShortUrl(ctx context.Context, originalUrl, slug string, accountID uint64) error {
+func (m *MockRepository) CreateShortUrl(ctx context.Context, url *types.Url) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateShortUrl", ctx, originalUrl, slug, accountID)
+	ret := m.ctrl.Call(m, "CreateShortUrl", ctx, url)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateShortUrl indicates an expected call of CreateShortUrl.
-func (mr *MockRepositoryMockRecorder) CreateShortUrl(ctx, originalUrl, slug, accountID interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CreateShortUrl(ctx, url interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortUrl", reflect.TypeOf((*MockRepository)(nil).CreateShortUrl), ctx, originalUrl, slug, accountID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortUrl", reflect.TypeOf((*MockRepository)(nil).CreateShortUrl), ctx, url)
 }
 
 // GetByAccountID mocks base method.
@@ -80,6 +80,37 @@ func (mr *MockRepositoryMockRecorder) GetBySlug(ctx, slug interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBySlug", reflect.TypeOf((*MockRepository)(nil).GetBySlug), ctx, slug)
 }
 
+// GetRevision mocks base method.
+func (m *MockRepository) GetRevision(ctx context.Context, urlID, revisionID uint64) (*types.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", ctx, urlID, revisionID)
+	ret0, _ := ret[0].(*types.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockRepositoryMockRecorder) GetRevision(ctx, urlID, revisionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockRepository)(nil).GetRevision), ctx, urlID, revisionID)
+}
+
+// GetRevisions mocks base method.
+func (m *MockRepository) GetRevisions(ctx context.Context, urlID uint64, cursor string) ([]types.UrlRevision, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisions", ctx, urlID, cursor)
+	ret0, _ := ret[0].([]types.UrlRevision)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRevisions indicates an expected call of GetRevisions.
+func (mr *MockRepositoryMockRecorder) GetRevisions(ctx, urlID, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisions", reflect.TypeOf((*MockRepository)(nil).GetRevisions), ctx, urlID, cursor)
+}
+
 // IncrementVisits mocks base method.
 func (m *MockRepository) IncrementVisits(ctx context.Context, slug string, newVisit bool) error {
 	m.ctrl.T.Helper()
@@ -94,6 +125,20 @@ func (mr *MockRepositoryMockRecorder) IncrementVisits(ctx, slug, newVisit interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementVisits", reflect.TypeOf((*MockRepository)(nil).IncrementVisits), ctx, slug, newVisit)
 }
 
+// Invalidate mocks base method.
+func (m *MockRepository) Invalidate(ctx context.Context, slugs []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Invalidate", ctx, slugs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Invalidate indicates an expected call of Invalidate.
+func (mr *MockRepositoryMockRecorder) Invalidate(ctx, slugs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockRepository)(nil).Invalidate), ctx, slugs)
+}
+
 // SetUrlState mocks base method.
 func (m *MockRepository) SetUrlState(ctx context.Context, accountID uint64, slug string, disabled bool) error {
 	m.ctrl.T.Helper()
@@ -107,3 +152,31 @@ func (mr *MockRepositoryMockRecorder) SetUrlState(ctx, accountID, slug, disabled
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUrlState", reflect.TypeOf((*MockRepository)(nil).SetUrlState), ctx, accountID, slug, disabled)
 }
+
+// UpdateOriginalUrl mocks base method.
+func (m *MockRepository) UpdateOriginalUrl(ctx context.Context, accountID uint64, slug, originalUrl string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOriginalUrl", ctx, accountID, slug, originalUrl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOriginalUrl indicates an expected call of UpdateOriginalUrl.
+func (mr *MockRepositoryMockRecorder) UpdateOriginalUrl(ctx, accountID, slug, originalUrl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOriginalUrl", reflect.TypeOf((*MockRepository)(nil).UpdateOriginalUrl), ctx, accountID, slug, originalUrl)
+}
+
+// UpdateUrl mocks base method.
+func (m *MockRepository) UpdateUrl(ctx context.Context, accountID uint64, slug string, originalUrl *string, disabled *bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUrl", ctx, accountID, slug, originalUrl, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUrl indicates an expected call of UpdateUrl.
+func (mr *MockRepositoryMockRecorder) UpdateUrl(ctx, accountID, slug, originalUrl, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUrl", reflect.TypeOf((*MockRepository)(nil).UpdateUrl), ctx, accountID, slug, originalUrl, disabled)
+}
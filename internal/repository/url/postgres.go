@@ -10,6 +10,7 @@ import (
 	"github.com/h3isenbug/url-shortener/internal/repository"
 	"github.com/h3isenbug/url-shortener/internal/types"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type postgresV1 struct {
@@ -27,11 +28,13 @@ func (r postgresV1) GetBySlug(ctx context.Context, slug string) (*types.Url, err
 	var url types.Url
 	err := r.con.GetContext(
 		ctx, &url,
-		"SELECT id, original_url, slug, total_visits, unique_visits, account_id, disabled, created_at FROM urls WHERE slug=$1",
+		`SELECT
+					id, original_url, slug, total_visits, unique_visits, bot_visits, COALESCE(account_id, 0) AS account_id, disabled, expires_at, max_visits, password_hash, created_at
+			   FROM urls WHERE slug=$1`,
 		slug,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, fmt.Errorf("%w: url not found(by slug)", err)
+		return nil, fmt.Errorf("%w: url not found(by slug)", repository.ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch url: %w", err)
@@ -41,38 +44,54 @@ func (r postgresV1) GetBySlug(ctx context.Context, slug string) (*types.Url, err
 }
 
 func (r postgresV1) IncrementVisits(ctx context.Context, slug string, newVisit bool) error {
-	var query = "UPDATE urls SET total_visits=total_visits+1 WHERE slug=$1"
-	if newVisit {
-		query = "UPDATE urls SET total_visits=total_visits+1, unique_visits=unique_visits+1 WHERE slug=$1"
+	// the visit limit is checked in the same statement so that concurrent visits can not overrun it. the url is
+	// looked up along with it, to tell a missing url apart from one that has reached its limit.
+	var result struct {
+		Found   bool `db:"found"`
+		Updated bool `db:"updated"`
 	}
-
-	result, err := r.con.ExecContext(ctx, query, slug)
+	err := r.con.GetContext(
+		ctx, &result,
+		`WITH url AS (SELECT id FROM urls WHERE slug=$1),
+					updated AS (
+						UPDATE urls SET
+							total_visits=total_visits+1,
+							unique_visits=unique_visits+(CASE WHEN $2::BOOLEAN THEN 1 ELSE 0 END)
+						WHERE id=(SELECT id FROM url) AND (max_visits IS NULL OR total_visits < max_visits)
+						RETURNING id
+					)
+			   SELECT EXISTS(SELECT 1 FROM url) AS found, EXISTS(SELECT 1 FROM updated) AS updated`,
+		slug, newVisit,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to update url visit metrics: %w", err)
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	if !result.Found {
+		return fmt.Errorf("%w: url not found(by slug)", repository.ErrNotFound)
 	}
-	if rowsAffected == 0 {
-		return repository.ErrNotFound
+	if !result.Updated {
+		return fmt.Errorf("%w: url has reached its visit limit", repository.ErrLimitReached)
 	}
 
 	return nil
 }
 
-func (r postgresV1) CreateShortUrl(ctx context.Context, originalUrl, slug string, accountID uint64) error {
+func (r postgresV1) CreateShortUrl(ctx context.Context, url *types.Url) error {
 	_, err := r.con.ExecContext(
 		ctx,
-		"INSERT INTO urls(original_url, slug, account_id) VALUES ($1, $2, $3)",
-		originalUrl, slug, accountID,
+		`INSERT INTO urls(original_url, slug, account_id, expires_at, max_visits, password_hash)
+					VALUES ($1, $2, $3, $4, $5, $6)`,
+		url.OriginalUrl, url.Slug, url.AccountID, url.ExpiresAt, url.MaxVisits, url.PasswordHash,
 	)
-	if err != nil {
-		return fmt.Errorf("failed to insert url: %w", err)
+	if err == nil {
+		return nil
 	}
 
-	return nil
+	if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+		return fmt.Errorf("%w: a url with the given slug already exists", repository.ErrUniquenessViolated)
+	}
+
+	return fmt.Errorf("failed to insert url: %w", err)
 }
 
 func (r postgresV1) GetByAccountID(ctx context.Context, accountID uint64, cursor string) ([]types.Url, string, error) {
@@ -81,7 +100,7 @@ func (r postgresV1) GetByAccountID(ctx context.Context, accountID uint64, cursor
 	err := r.con.SelectContext(
 		ctx, &urls,
 		`SELECT
-       				id, original_url, slug, total_visits, unique_visits, account_id, disabled, created_at
+       				id, original_url, slug, total_visits, unique_visits, bot_visits, account_id, disabled, expires_at, max_visits, password_hash, created_at
 			   FROM urls WHERE account_id=$1 ORDER BY created_at DESC OFFSET $2 LIMIT $3`,
 		accountID, offset, r.itemsPerPage+1,
 	)
@@ -115,3 +134,105 @@ func (r postgresV1) SetUrlState(ctx context.Context, accountID uint64, slug stri
 
 	return nil
 }
+
+func (r postgresV1) UpdateOriginalUrl(ctx context.Context, accountID uint64, slug, originalUrl string) error {
+	return r.UpdateUrl(ctx, accountID, slug, &originalUrl, nil)
+}
+
+func (r postgresV1) UpdateUrl(
+	ctx context.Context, accountID uint64, slug string, originalUrl *string, disabled *bool,
+) error {
+	tx, err := r.con.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current struct {
+		ID          uint64 `db:"id"`
+		OriginalUrl string `db:"original_url"`
+	}
+	err = tx.GetContext(
+		ctx, &current,
+		"SELECT id, original_url FROM urls WHERE slug=$1 AND account_id=$2 FOR UPDATE",
+		slug, accountID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: url not found(by slug and account id)", repository.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch url: %w", err)
+	}
+
+	if originalUrl != nil {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO url_revisions(url_id, original_url, changed_by) VALUES ($1, $2, $3)",
+			current.ID, current.OriginalUrl, accountID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert url revision: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE urls SET original_url=$2 WHERE id=$1", current.ID, *originalUrl); err != nil {
+			return fmt.Errorf("failed to update original url: %w", err)
+		}
+	}
+
+	if disabled != nil {
+		if _, err := tx.ExecContext(ctx, "UPDATE urls SET disabled=$2 WHERE id=$1", current.ID, *disabled); err != nil {
+			return fmt.Errorf("failed to disable url: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r postgresV1) GetRevisions(ctx context.Context, urlID uint64, cursor string) ([]types.UrlRevision, string, error) {
+	var revisions []types.UrlRevision
+	offset, _ := strconv.Atoi(cursor)
+	err := r.con.SelectContext(
+		ctx, &revisions,
+		`SELECT id, url_id, original_url, COALESCE(changed_by, 0) AS changed_by, changed_at
+			   FROM url_revisions WHERE url_id=$1 ORDER BY id DESC OFFSET $2 LIMIT $3`,
+		urlID, offset, r.itemsPerPage+1,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch url revisions: %w", err)
+	}
+
+	var nextCursor string
+
+	if len(revisions) > r.itemsPerPage {
+		revisions = revisions[:r.itemsPerPage]
+		nextCursor = strconv.Itoa(offset + r.itemsPerPage)
+	}
+
+	return revisions, nextCursor, nil
+}
+
+func (r postgresV1) GetRevision(ctx context.Context, urlID, revisionID uint64) (*types.UrlRevision, error) {
+	var revision types.UrlRevision
+	err := r.con.GetContext(
+		ctx, &revision,
+		"SELECT id, url_id, original_url, COALESCE(changed_by, 0) AS changed_by, changed_at FROM url_revisions WHERE id=$1 AND url_id=$2",
+		revisionID, urlID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: url revision not found", repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch url revision: %w", err)
+	}
+
+	return &revision, nil
+}
+
+// Invalidate has nothing to do, urls are always read from the database at this layer.
+func (r postgresV1) Invalidate(ctx context.Context, slugs []string) error {
+	return nil
+}
@@ -34,7 +34,7 @@ func (r redisCacheV1) GetBySlug(ctx context.Context, slug string) (*types.Url, e
 	var url types.Url
 	value, err := r.redis.Get(ctx, generateCacheKey(slug)).Result()
 	if err == redis.Nil {
-		return r.nextLayer.GetBySlug(ctx, slug)
+		return r.getFromNextLayer(ctx, slug)
 	}
 	if err != nil {
 		r.logger.Warn("failed to fetch url cache entry", map[string]interface{}{
@@ -52,24 +52,48 @@ func (r redisCacheV1) GetBySlug(ctx context.Context, slug string) (*types.Url, e
 			"cacheValue":   value,
 			"errorMessage": err.Error(),
 		})
-		if err := r.redis.Del(ctx, slug).Err(); err != nil {
-			r.logger.Warn("failed to remove bogus cache entry from redis", map[string]interface{}{
-				"slug":         slug,
-				"cacheKey":     generateCacheKey(slug),
-				"errorMessage": err.Error(),
-			})
-		}
+		r.invalidate(ctx, slug)
+		return r.getFromNextLayer(ctx, slug)
+	}
+
+	// an entry might outlive the url's expiration if the clocks of redis and this host disagree.
+	if url.IsExpired(time.Now().UTC()) {
+		r.invalidate(ctx, slug)
+		return r.nextLayer.GetBySlug(ctx, slug)
 	}
 
 	return &url, nil
 }
 
-func (r redisCacheV1) IncrementVisits(ctx context.Context, slug string, newVisit bool) error {
-	err := r.nextLayer.IncrementVisits(ctx, slug, newVisit)
+func (r redisCacheV1) getFromNextLayer(ctx context.Context, slug string) (*types.Url, error) {
+	url, err := r.nextLayer.GetBySlug(ctx, slug)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	ttl := r.ttl
+	if url.ExpiresAt != nil {
+		untilExpiration := url.ExpiresAt.Sub(time.Now().UTC())
+		if untilExpiration <= 0 {
+			return url, nil
+		}
+		if untilExpiration < ttl {
+			ttl = untilExpiration
+		}
+	}
+
+	if err := r.redis.Set(ctx, generateCacheKey(slug), url.String(), ttl).Err(); err != nil {
+		r.logger.Warn("failed to save url cache entry", map[string]interface{}{
+			"slug":         slug,
+			"cacheKey":     generateCacheKey(slug),
+			"errorMessage": err.Error(),
+		})
 	}
 
+	return url, nil
+}
+
+func (r redisCacheV1) invalidate(ctx context.Context, slug string) {
 	if err := r.redis.Del(ctx, generateCacheKey(slug)).Err(); err != nil {
 		r.logger.Warn("failed to invalidate cache entry", map[string]interface{}{
 			"slug":         slug,
@@ -77,12 +101,16 @@ func (r redisCacheV1) IncrementVisits(ctx context.Context, slug string, newVisit
 			"errorMessage": err.Error(),
 		})
 	}
+}
 
-	return nil
+// IncrementVisits leaves the cache entry in place. the visit counters of a cached url may lag behind, but the visit
+// limit is enforced by the next layer and invalidating on every redirect would make the cache useless for hot urls.
+func (r redisCacheV1) IncrementVisits(ctx context.Context, slug string, newVisit bool) error {
+	return r.nextLayer.IncrementVisits(ctx, slug, newVisit)
 }
 
-func (r redisCacheV1) CreateShortUrl(ctx context.Context, originalUrl, slug string, accountID uint64) error {
-	return r.nextLayer.CreateShortUrl(ctx, originalUrl, slug, accountID)
+func (r redisCacheV1) CreateShortUrl(ctx context.Context, url *types.Url) error {
+	return r.nextLayer.CreateShortUrl(ctx, url)
 }
 
 func (r redisCacheV1) GetByAccountID(ctx context.Context, accountID uint64, cursor string) (items []types.Url, nextCursor string, err error) {
@@ -95,13 +123,47 @@ func (r redisCacheV1) SetUrlState(ctx context.Context, accountID uint64, slug st
 		return err
 	}
 
-	if err := r.redis.Del(ctx, generateCacheKey(slug)).Err(); err != nil {
-		r.logger.Warn("failed to invalidate cache entry", map[string]interface{}{
-			"slug":         slug,
-			"cacheKey":     generateCacheKey(slug),
-			"errorMessage": err.Error(),
-		})
+	r.invalidate(ctx, slug)
+
+	return nil
+}
+
+func (r redisCacheV1) UpdateOriginalUrl(ctx context.Context, accountID uint64, slug, originalUrl string) error {
+	err := r.nextLayer.UpdateOriginalUrl(ctx, accountID, slug, originalUrl)
+	if err != nil {
+		return err
 	}
 
+	r.invalidate(ctx, slug)
+
 	return nil
 }
+
+func (r redisCacheV1) UpdateUrl(
+	ctx context.Context, accountID uint64, slug string, originalUrl *string, disabled *bool,
+) error {
+	err := r.nextLayer.UpdateUrl(ctx, accountID, slug, originalUrl, disabled)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, slug)
+
+	return nil
+}
+
+func (r redisCacheV1) GetRevisions(ctx context.Context, urlID uint64, cursor string) ([]types.UrlRevision, string, error) {
+	return r.nextLayer.GetRevisions(ctx, urlID, cursor)
+}
+
+func (r redisCacheV1) GetRevision(ctx context.Context, urlID, revisionID uint64) (*types.UrlRevision, error) {
+	return r.nextLayer.GetRevision(ctx, urlID, revisionID)
+}
+
+func (r redisCacheV1) Invalidate(ctx context.Context, slugs []string) error {
+	for _, slug := range slugs {
+		r.invalidate(ctx, slug)
+	}
+
+	return r.nextLayer.Invalidate(ctx, slugs)
+}
@@ -12,9 +12,17 @@ import (
 type Repository interface {
 	GetBySlug(ctx context.Context, slug string) (*types.Url, error)
 	IncrementVisits(ctx context.Context, slug string, newVisit bool) error
-	CreateShortUrl(ctx context.Context, originalUrl, slug string, accountID uint64) error
+	CreateShortUrl(ctx context.Context, url *types.Url) error
 	GetByAccountID(ctx context.Context, accountID uint64, cursor string) (items []types.Url, nextCursor string, err error)
 	SetUrlState(ctx context.Context, accountID uint64, slug string, disabled bool) error
+	UpdateOriginalUrl(ctx context.Context, accountID uint64, slug, originalUrl string) error
+	// UpdateUrl changes the original url and disabled state of a url in one transaction, either is left as is when
+	// nil.
+	UpdateUrl(ctx context.Context, accountID uint64, slug string, originalUrl *string, disabled *bool) error
+	GetRevisions(ctx context.Context, urlID uint64, cursor string) (items []types.UrlRevision, nextCursor string, err error)
+	GetRevision(ctx context.Context, urlID, revisionID uint64) (*types.UrlRevision, error)
+	// Invalidate drops whatever is cached about the given urls, after they were changed outside this repository.
+	Invalidate(ctx context.Context, slugs []string) error
 }
 
 type metricWrapper struct {
@@ -46,9 +54,9 @@ func (w metricWrapper) IncrementVisits(ctx context.Context, slug string, newVisi
 	return err
 }
 
-func (w metricWrapper) CreateShortUrl(ctx context.Context, originalUrl, slug string, accountID uint64) error {
+func (w metricWrapper) CreateShortUrl(ctx context.Context, url *types.Url) error {
 	startedAt := time.Now()
-	err := w.wrapped.CreateShortUrl(ctx, originalUrl, slug, accountID)
+	err := w.wrapped.CreateShortUrl(ctx, url)
 	w.RecordMetrics("CreateShortUrl", time.Now().Sub(startedAt), err == nil)
 
 	return err
@@ -69,3 +77,45 @@ func (w metricWrapper) SetUrlState(ctx context.Context, accountID uint64, slug s
 
 	return err
 }
+
+func (w metricWrapper) UpdateOriginalUrl(ctx context.Context, accountID uint64, slug, originalUrl string) error {
+	startedAt := time.Now()
+	err := w.wrapped.UpdateOriginalUrl(ctx, accountID, slug, originalUrl)
+	w.RecordMetrics("UpdateOriginalUrl", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) UpdateUrl(
+	ctx context.Context, accountID uint64, slug string, originalUrl *string, disabled *bool,
+) error {
+	startedAt := time.Now()
+	err := w.wrapped.UpdateUrl(ctx, accountID, slug, originalUrl, disabled)
+	w.RecordMetrics("UpdateUrl", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) GetRevisions(ctx context.Context, urlID uint64, cursor string) ([]types.UrlRevision, string, error) {
+	startedAt := time.Now()
+	items, nextCursor, err := w.wrapped.GetRevisions(ctx, urlID, cursor)
+	w.RecordMetrics("GetRevisions", time.Now().Sub(startedAt), err == nil)
+
+	return items, nextCursor, err
+}
+
+func (w metricWrapper) GetRevision(ctx context.Context, urlID, revisionID uint64) (*types.UrlRevision, error) {
+	startedAt := time.Now()
+	revision, err := w.wrapped.GetRevision(ctx, urlID, revisionID)
+	w.RecordMetrics("GetRevision", time.Now().Sub(startedAt), err == nil)
+
+	return revision, err
+}
+
+func (w metricWrapper) Invalidate(ctx context.Context, slugs []string) error {
+	startedAt := time.Now()
+	err := w.wrapped.Invalidate(ctx, slugs)
+	w.RecordMetrics("Invalidate", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
@@ -0,0 +1,127 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/visit/visit.go
+
+// Package mock_visit is a generated GoMock package.
+package mock_visit
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	types "github.com/h3isenbug/url-shortener/internal/types"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateBatch mocks base method.
+func (m *MockRepository) CreateBatch(ctx context.Context, visits []types.Visit, counts []types.VisitCount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, visits, counts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockRepositoryMockRecorder) CreateBatch(ctx, visits, counts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockRepository)(nil).CreateBatch), ctx, visits, counts)
+}
+
+// GetAccountBreakdown mocks base method.
+func (m *MockRepository) GetAccountBreakdown(ctx context.Context, accountID uint64, dimension types.BreakdownDimension, from, to time.Time, limit int) ([]types.BreakdownItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountBreakdown", ctx, accountID, dimension, from, to, limit)
+	ret0, _ := ret[0].([]types.BreakdownItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountBreakdown indicates an expected call of GetAccountBreakdown.
+func (mr *MockRepositoryMockRecorder) GetAccountBreakdown(ctx, accountID, dimension, from, to, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountBreakdown", reflect.TypeOf((*MockRepository)(nil).GetAccountBreakdown), ctx, accountID, dimension, from, to, limit)
+}
+
+// GetByUrlID mocks base method.
+func (m *MockRepository) GetByUrlID(ctx context.Context, urlID uint64, cursor string) ([]types.Visit, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUrlID", ctx, urlID, cursor)
+	ret0, _ := ret[0].([]types.Visit)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetByUrlID indicates an expected call of GetByUrlID.
+func (mr *MockRepositoryMockRecorder) GetByUrlID(ctx, urlID, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUrlID", reflect.TypeOf((*MockRepository)(nil).GetByUrlID), ctx, urlID, cursor)
+}
+
+// GetGeoBreakdown mocks base method.
+func (m *MockRepository) GetGeoBreakdown(ctx context.Context, urlID uint64, from, to time.Time, limit int) ([]types.GeoBreakdownItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGeoBreakdown", ctx, urlID, from, to, limit)
+	ret0, _ := ret[0].([]types.GeoBreakdownItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGeoBreakdown indicates an expected call of GetGeoBreakdown.
+func (mr *MockRepositoryMockRecorder) GetGeoBreakdown(ctx, urlID, from, to, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGeoBreakdown", reflect.TypeOf((*MockRepository)(nil).GetGeoBreakdown), ctx, urlID, from, to, limit)
+}
+
+// GetStats mocks base method.
+func (m *MockRepository) GetStats(ctx context.Context, urlID uint64, from, to time.Time, granularity types.StatsGranularity, location *time.Location) ([]types.VisitStatsBucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", ctx, urlID, from, to, granularity, location)
+	ret0, _ := ret[0].([]types.VisitStatsBucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockRepositoryMockRecorder) GetStats(ctx, urlID, from, to, granularity, location interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockRepository)(nil).GetStats), ctx, urlID, from, to, granularity, location)
+}
+
+// GetUrlBreakdown mocks base method.
+func (m *MockRepository) GetUrlBreakdown(ctx context.Context, urlID uint64, dimension types.BreakdownDimension, from, to time.Time, limit int) ([]types.BreakdownItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUrlBreakdown", ctx, urlID, dimension, from, to, limit)
+	ret0, _ := ret[0].([]types.BreakdownItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUrlBreakdown indicates an expected call of GetUrlBreakdown.
+func (mr *MockRepositoryMockRecorder) GetUrlBreakdown(ctx, urlID, dimension, from, to, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUrlBreakdown", reflect.TypeOf((*MockRepository)(nil).GetUrlBreakdown), ctx, urlID, dimension, from, to, limit)
+}
@@ -0,0 +1,338 @@
+package visit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// breakdownColumns maps breakdown dimensions to their columns. it is the only source of column names in breakdown
+// queries, so that a dimension never reaches the query text unchecked.
+var breakdownColumns = map[types.BreakdownDimension]string{
+	types.BreakdownDimensionReferrer: "referrer_domain",
+	types.BreakdownDimensionDevice:   "device",
+	types.BreakdownDimensionBrowser:  "browser",
+	types.BreakdownDimensionOS:       "os",
+	types.BreakdownDimensionCountry:  "country",
+}
+
+type postgresV1 struct {
+	con          *sqlx.DB
+	itemsPerPage int
+}
+
+func NewPostgresRepositoryV1(connection *sqlx.DB, itemsPerPage int) Repository {
+	return &postgresV1{
+		con:          connection,
+		itemsPerPage: itemsPerPage,
+	}
+}
+
+func (r postgresV1) CreateBatch(ctx context.Context, visits []types.Visit, counts []types.VisitCount) error {
+	if len(visits) == 0 {
+		return nil
+	}
+
+	tx, err := r.con.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	visits, err = r.dropOrphans(ctx, tx, visits)
+	if err != nil {
+		return err
+	}
+	if len(visits) == 0 {
+		return nil
+	}
+
+	_, err = tx.NamedExecContext(
+		ctx,
+		`INSERT INTO url_visits(
+					url_id, visited_at, referrer, user_agent, client_ip, accept_language, unique_visit,
+					referrer_domain, device, browser, os, country, city, bot
+			   ) VALUES (
+					:url_id, :visited_at, :referrer, :user_agent, :client_ip, :accept_language, :unique_visit,
+					:referrer_domain, :device, :browser, :os, :country, :city, :bot
+			   )`,
+		visits,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert url visits: %w", err)
+	}
+
+	if err := r.addToRollups(ctx, tx, visits); err != nil {
+		return err
+	}
+
+	if err := r.addToCounters(ctx, tx, counts); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// dropOrphans leaves out the visits of urls that were deleted while their visits waited to be written, so that one
+// deleted url does not fail the whole batch. the urls that are left are locked until the transaction ends.
+func (r postgresV1) dropOrphans(ctx context.Context, tx *sqlx.Tx, visits []types.Visit) ([]types.Visit, error) {
+	urlIDs := make([]int64, len(visits))
+	for i, visit := range visits {
+		urlIDs[i] = int64(visit.UrlID)
+	}
+
+	var existing []uint64
+	err := tx.SelectContext(ctx, &existing, "SELECT id FROM urls WHERE id=ANY($1) FOR KEY SHARE", pq.Array(urlIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock urls of visits: %w", err)
+	}
+
+	exists := make(map[uint64]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	kept := make([]types.Visit, 0, len(visits))
+	for _, visit := range visits {
+		if exists[visit.UrlID] {
+			kept = append(kept, visit)
+		}
+	}
+
+	return kept, nil
+}
+
+// addToCounters adds counts to the visit counters of their urls.
+func (r postgresV1) addToCounters(ctx context.Context, tx *sqlx.Tx, counts []types.VisitCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	var (
+		urlIDs       = make([]int64, len(counts))
+		totalVisits  = make([]int64, len(counts))
+		uniqueVisits = make([]int64, len(counts))
+		botVisits    = make([]int64, len(counts))
+	)
+	for i, count := range counts {
+		urlIDs[i] = int64(count.UrlID)
+		totalVisits[i] = int64(count.TotalVisits)
+		uniqueVisits[i] = int64(count.UniqueVisits)
+		botVisits[i] = int64(count.BotVisits)
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE urls SET
+					total_visits=urls.total_visits+counts.total_visits,
+					unique_visits=urls.unique_visits+counts.unique_visits,
+					bot_visits=urls.bot_visits+counts.bot_visits
+			   FROM unnest($1::BIGINT[], $2::BIGINT[], $3::BIGINT[], $4::BIGINT[])
+					AS counts(url_id, total_visits, unique_visits, bot_visits)
+			   WHERE urls.id=counts.url_id`,
+		pq.Array(urlIDs), pq.Array(totalVisits), pq.Array(uniqueVisits), pq.Array(botVisits),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add url visit metrics: %w", err)
+	}
+
+	return nil
+}
+
+// addToRollups adds visits to the hourly rollups of their urls. hours are truncated in UTC.
+// visits of bots only count towards bot_visits.
+func (r postgresV1) addToRollups(ctx context.Context, tx *sqlx.Tx, visits []types.Visit) error {
+	type rollupKey struct {
+		urlID  uint64
+		bucket time.Time
+	}
+
+	var (
+		urlIDs       []int64
+		buckets      []string
+		totalVisits  []int64
+		uniqueVisits []int64
+		botVisits    []int64
+		indexes      = make(map[rollupKey]int)
+	)
+	for _, visit := range visits {
+		key := rollupKey{urlID: visit.UrlID, bucket: visit.VisitedAt.UTC().Truncate(time.Hour)}
+
+		index, found := indexes[key]
+		if !found {
+			index = len(urlIDs)
+			indexes[key] = index
+			urlIDs = append(urlIDs, int64(key.urlID))
+			buckets = append(buckets, key.bucket.Format(time.RFC3339))
+			totalVisits = append(totalVisits, 0)
+			uniqueVisits = append(uniqueVisits, 0)
+			botVisits = append(botVisits, 0)
+		}
+
+		switch {
+		case visit.Bot:
+			botVisits[index]++
+		case visit.Unique:
+			totalVisits[index]++
+			uniqueVisits[index]++
+		default:
+			totalVisits[index]++
+		}
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO url_visit_rollups(url_id, bucket, total_visits, unique_visits, bot_visits)
+					SELECT * FROM unnest($1::BIGINT[], $2::TIMESTAMPTZ[], $3::BIGINT[], $4::BIGINT[], $5::BIGINT[])
+			   ON CONFLICT (url_id, bucket) DO UPDATE SET
+					total_visits=url_visit_rollups.total_visits+EXCLUDED.total_visits,
+					unique_visits=url_visit_rollups.unique_visits+EXCLUDED.unique_visits,
+					bot_visits=url_visit_rollups.bot_visits+EXCLUDED.bot_visits`,
+		pq.Array(urlIDs), pq.Array(buckets), pq.Array(totalVisits), pq.Array(uniqueVisits), pq.Array(botVisits),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update url visit rollups: %w", err)
+	}
+
+	return nil
+}
+
+func (r postgresV1) GetByUrlID(ctx context.Context, urlID uint64, cursor string) ([]types.Visit, string, error) {
+	var visits []types.Visit
+
+	// visits are only ever appended, so paging with the last seen id stays stable while new visits arrive.
+	lastSeenID, _ := strconv.ParseUint(cursor, 10, 64)
+	err := r.con.SelectContext(
+		ctx, &visits,
+		`SELECT
+       				id, url_id, visited_at, referrer, user_agent, client_ip, accept_language, unique_visit,
+       				referrer_domain, device, browser, os, country, city, bot
+			   FROM url_visits WHERE url_id=$1 AND ($2::BIGINT=0 OR id < $2::BIGINT) ORDER BY id DESC LIMIT $3`,
+		urlID, lastSeenID, r.itemsPerPage+1,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch url visits: %w", err)
+	}
+
+	var nextCursor string
+
+	if len(visits) > r.itemsPerPage {
+		visits = visits[:r.itemsPerPage]
+		nextCursor = strconv.FormatUint(visits[len(visits)-1].ID, 10)
+	}
+
+	return visits, nextCursor, nil
+}
+
+func (r postgresV1) GetStats(
+	ctx context.Context, urlID uint64, from, to time.Time, granularity types.StatsGranularity, location *time.Location,
+) ([]types.VisitStatsBucket, error) {
+	var buckets []types.VisitStatsBucket
+
+	var err error
+	switch granularity {
+	case types.StatsGranularityHour:
+		err = r.con.SelectContext(
+			ctx, &buckets,
+			`SELECT bucket, total_visits, unique_visits, bot_visits
+				   FROM url_visit_rollups WHERE url_id=$1 AND bucket >= $2 AND bucket < $3 ORDER BY bucket`,
+			urlID, from, to,
+		)
+	case types.StatsGranularityDay:
+		// days are truncated in the requested timezone, yielding the local date of each bucket.
+		err = r.con.SelectContext(
+			ctx, &buckets,
+			`SELECT
+						date_trunc('day', bucket AT TIME ZONE $4) AS bucket,
+						SUM(total_visits)::BIGINT AS total_visits,
+						SUM(unique_visits)::BIGINT AS unique_visits,
+						SUM(bot_visits)::BIGINT AS bot_visits
+				   FROM url_visit_rollups WHERE url_id=$1 AND bucket >= $2 AND bucket < $3 GROUP BY 1 ORDER BY 1`,
+			urlID, from, to, location.String(),
+		)
+	default:
+		return nil, fmt.Errorf("unknown stats granularity: %s", granularity)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch url visit stats: %w", err)
+	}
+
+	for i, bucket := range buckets {
+		if granularity == types.StatsGranularityDay {
+			buckets[i].Bucket = time.Date(bucket.Bucket.Year(), bucket.Bucket.Month(), bucket.Bucket.Day(), 0, 0, 0, 0, location)
+		} else {
+			buckets[i].Bucket = bucket.Bucket.In(location)
+		}
+	}
+
+	return buckets, nil
+}
+
+func (r postgresV1) getBreakdown(
+	ctx context.Context, urlFilter string, id uint64, dimension types.BreakdownDimension, from, to time.Time, limit int,
+) ([]types.BreakdownItem, error) {
+	column, found := breakdownColumns[dimension]
+	if !found {
+		return nil, fmt.Errorf("unknown breakdown dimension: %s", dimension)
+	}
+
+	var items []types.BreakdownItem
+	err := r.con.SelectContext(
+		ctx, &items,
+		fmt.Sprintf(
+			`SELECT
+						%[1]s AS value,
+						COUNT(*) AS total_visits,
+						COUNT(*) FILTER (WHERE unique_visit) AS unique_visits
+				   FROM url_visits WHERE %[2]s AND NOT bot AND visited_at >= $2 AND visited_at < $3
+				   GROUP BY %[1]s ORDER BY total_visits DESC, value LIMIT $4`,
+			column, urlFilter,
+		),
+		id, from, to, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch url visit breakdown: %w", err)
+	}
+
+	return items, nil
+}
+
+func (r postgresV1) GetUrlBreakdown(
+	ctx context.Context, urlID uint64, dimension types.BreakdownDimension, from, to time.Time, limit int,
+) ([]types.BreakdownItem, error) {
+	return r.getBreakdown(ctx, "url_id=$1", urlID, dimension, from, to, limit)
+}
+
+func (r postgresV1) GetAccountBreakdown(
+	ctx context.Context, accountID uint64, dimension types.BreakdownDimension, from, to time.Time, limit int,
+) ([]types.BreakdownItem, error) {
+	return r.getBreakdown(ctx, "url_id IN (SELECT id FROM urls WHERE account_id=$1)", accountID, dimension, from, to, limit)
+}
+
+func (r postgresV1) GetGeoBreakdown(ctx context.Context, urlID uint64, from, to time.Time, limit int) ([]types.GeoBreakdownItem, error) {
+	var items []types.GeoBreakdownItem
+	err := r.con.SelectContext(
+		ctx, &items,
+		`SELECT
+					country, city,
+					COUNT(*) AS total_visits,
+					COUNT(*) FILTER (WHERE unique_visit) AS unique_visits
+			   FROM url_visits WHERE url_id=$1 AND NOT bot AND visited_at >= $2 AND visited_at < $3
+			   GROUP BY country, city ORDER BY total_visits DESC, country, city LIMIT $4`,
+		urlID, from, to, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch url visit geo breakdown: %w", err)
+	}
+
+	return items, nil
+}
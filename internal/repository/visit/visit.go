@@ -0,0 +1,94 @@
+package visit
+
+import (
+	"context"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/monitoring"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+type Repository interface {
+	// CreateBatch stores visits and adds counts to the visit counters of their urls, in one transaction. visits and
+	// counts of urls that no longer exist are left out.
+	CreateBatch(ctx context.Context, visits []types.Visit, counts []types.VisitCount) error
+	GetByUrlID(ctx context.Context, urlID uint64, cursor string) (items []types.Visit, nextCursor string, err error)
+	GetStats(
+		ctx context.Context, urlID uint64, from, to time.Time, granularity types.StatsGranularity, location *time.Location,
+	) ([]types.VisitStatsBucket, error)
+	GetUrlBreakdown(
+		ctx context.Context, urlID uint64, dimension types.BreakdownDimension, from, to time.Time, limit int,
+	) ([]types.BreakdownItem, error)
+	GetAccountBreakdown(
+		ctx context.Context, accountID uint64, dimension types.BreakdownDimension, from, to time.Time, limit int,
+	) ([]types.BreakdownItem, error)
+	GetGeoBreakdown(ctx context.Context, urlID uint64, from, to time.Time, limit int) ([]types.GeoBreakdownItem, error)
+}
+
+type metricWrapper struct {
+	*repository.BaseMetricWrapper
+
+	wrapped Repository
+}
+
+func NewMetricWrapper(wrapped Repository, metricCollector monitoring.MetricCollector, name string) Repository {
+	return &metricWrapper{
+		BaseMetricWrapper: repository.NewBaseMetricWrapper(metricCollector, name),
+		wrapped:           wrapped,
+	}
+}
+
+func (w metricWrapper) CreateBatch(ctx context.Context, visits []types.Visit, counts []types.VisitCount) error {
+	startedAt := time.Now()
+	err := w.wrapped.CreateBatch(ctx, visits, counts)
+	w.RecordMetrics("CreateBatch", time.Now().Sub(startedAt), err == nil)
+
+	return err
+}
+
+func (w metricWrapper) GetByUrlID(ctx context.Context, urlID uint64, cursor string) ([]types.Visit, string, error) {
+	startedAt := time.Now()
+	items, nextCursor, err := w.wrapped.GetByUrlID(ctx, urlID, cursor)
+	w.RecordMetrics("GetByUrlID", time.Now().Sub(startedAt), err == nil)
+
+	return items, nextCursor, err
+}
+
+func (w metricWrapper) GetStats(
+	ctx context.Context, urlID uint64, from, to time.Time, granularity types.StatsGranularity, location *time.Location,
+) ([]types.VisitStatsBucket, error) {
+	startedAt := time.Now()
+	buckets, err := w.wrapped.GetStats(ctx, urlID, from, to, granularity, location)
+	w.RecordMetrics("GetStats", time.Now().Sub(startedAt), err == nil)
+
+	return buckets, err
+}
+
+func (w metricWrapper) GetUrlBreakdown(
+	ctx context.Context, urlID uint64, dimension types.BreakdownDimension, from, to time.Time, limit int,
+) ([]types.BreakdownItem, error) {
+	startedAt := time.Now()
+	items, err := w.wrapped.GetUrlBreakdown(ctx, urlID, dimension, from, to, limit)
+	w.RecordMetrics("GetUrlBreakdown", time.Now().Sub(startedAt), err == nil)
+
+	return items, err
+}
+
+func (w metricWrapper) GetAccountBreakdown(
+	ctx context.Context, accountID uint64, dimension types.BreakdownDimension, from, to time.Time, limit int,
+) ([]types.BreakdownItem, error) {
+	startedAt := time.Now()
+	items, err := w.wrapped.GetAccountBreakdown(ctx, accountID, dimension, from, to, limit)
+	w.RecordMetrics("GetAccountBreakdown", time.Now().Sub(startedAt), err == nil)
+
+	return items, err
+}
+
+func (w metricWrapper) GetGeoBreakdown(ctx context.Context, urlID uint64, from, to time.Time, limit int) ([]types.GeoBreakdownItem, error) {
+	startedAt := time.Now()
+	items, err := w.wrapped.GetGeoBreakdown(ctx, urlID, from, to, limit)
+	w.RecordMetrics("GetGeoBreakdown", time.Now().Sub(startedAt), err == nil)
+
+	return items, err
+}
@@ -0,0 +1,54 @@
+package visitor
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	saltLength = 32
+	// salts outlive their day a bit, so that instances with slightly skewed clocks agree on them.
+	saltTTL = 48 * time.Hour
+)
+
+type redisV1 struct {
+	redis *redis.Client
+}
+
+func NewRedisRepositoryV1(redisClient *redis.Client) Repository {
+	return &redisV1{redis: redisClient}
+}
+
+func (r redisV1) GetSalt(ctx context.Context, day string) ([]byte, error) {
+	key := fmt.Sprintf("visitor-salt-%s", day)
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	// whichever instance gets here first decides the salt of the day.
+	if err := r.redis.SetNX(ctx, key, salt, saltTTL).Err(); err != nil {
+		return nil, fmt.Errorf("failed to save salt: %w", err)
+	}
+
+	stored, err := r.redis.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch salt: %w", err)
+	}
+
+	return stored, nil
+}
+
+func (r redisV1) MarkSeen(ctx context.Context, urlID uint64, visitorID string, ttl time.Duration) (bool, error) {
+	firstVisit, err := r.redis.SetNX(ctx, fmt.Sprintf("visitor-seen-%d-%s", urlID, visitorID), 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark visitor as seen: %w", err)
+	}
+
+	return firstVisit, nil
+}
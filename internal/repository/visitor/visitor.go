@@ -0,0 +1,45 @@
+package visitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/monitoring"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+)
+
+type Repository interface {
+	// GetSalt returns the salt of the given day, creating it if there is none yet.
+	GetSalt(ctx context.Context, day string) ([]byte, error)
+	// MarkSeen records a visit of a visitor to a url and reports whether it is the first one within ttl.
+	MarkSeen(ctx context.Context, urlID uint64, visitorID string, ttl time.Duration) (firstVisit bool, err error)
+}
+
+type metricWrapper struct {
+	*repository.BaseMetricWrapper
+
+	wrapped Repository
+}
+
+func NewMetricWrapper(wrapped Repository, metricCollector monitoring.MetricCollector, name string) Repository {
+	return &metricWrapper{
+		BaseMetricWrapper: repository.NewBaseMetricWrapper(metricCollector, name),
+		wrapped:           wrapped,
+	}
+}
+
+func (w metricWrapper) GetSalt(ctx context.Context, day string) ([]byte, error) {
+	startedAt := time.Now()
+	salt, err := w.wrapped.GetSalt(ctx, day)
+	w.RecordMetrics("GetSalt", time.Now().Sub(startedAt), err == nil)
+
+	return salt, err
+}
+
+func (w metricWrapper) MarkSeen(ctx context.Context, urlID uint64, visitorID string, ttl time.Duration) (bool, error) {
+	startedAt := time.Now()
+	firstVisit, err := w.wrapped.MarkSeen(ctx, urlID, visitorID, ttl)
+	w.RecordMetrics("MarkSeen", time.Now().Sub(startedAt), err == nil)
+
+	return firstVisit, err
+}
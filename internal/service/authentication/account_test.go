@@ -0,0 +1,198 @@
+package authentication_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const newEMail = "new.address@example.com"
+
+func expectAccount(t *testing.T, sut sut, password string) {
+	acct := &types.Account{ID: 1, EMail: "h.kalantari.1997@gmail.com"}
+	if password != "" {
+		acct.PasswordHash = mustHashPassword(t, password)
+	}
+
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).Return(acct, nil).Times(1)
+}
+
+func TestChangePassword(t *testing.T) {
+	sut := createSUT(t)
+	expectAccount(t, sut, "123456")
+
+	sut.accountRepo.EXPECT().SetPasswordHash(gomock.Any(), uint64(1), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ uint64, passwordHash string) error {
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte("654321")))
+			return nil
+		},
+	).Times(1)
+	sut.refreshTokenRepo.EXPECT().RevokeAllForAccount(gomock.Any(), uint64(1)).Return(nil).Times(1)
+	sut.revocationRepo.EXPECT().RevokeAccountBefore(gomock.Any(), uint64(1), gomock.Any(), time.Minute*10).
+		Return(nil).Times(1)
+	sut.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), clientInfo).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 4}, nil).Times(1)
+
+	tokenPair, err := sut.service.ChangePassword(context.Background(), 1, "123456", "654321", clientInfo)
+	require.NoError(t, err)
+
+	// the new session is opened in the second every other one is revoked in
+	sut.revocationRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(4)).
+		Return(types.Revocation{RevokedBefore: time.Now().UTC()}, nil).Times(1)
+
+	accountInfo, err := sut.service.GetAccountInfoFromAccessToken(context.Background(), tokenPair.AccessToken)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint64(4), accountInfo.SessionID)
+	}
+}
+
+func TestChangePasswordWithWrongPassword(t *testing.T) {
+	sut := createSUT(t)
+	expectAccount(t, sut, "123456")
+
+	sut.accountRepo.EXPECT().SetPasswordHash(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.ChangePassword(context.Background(), 1, "000000", "654321", clientInfo)
+	assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
+}
+
+func TestChangePasswordOfAccountWithoutPassword(t *testing.T) {
+	sut := createSUT(t)
+	expectAccount(t, sut, "")
+
+	_, err := sut.service.ChangePassword(context.Background(), 1, "", "654321", clientInfo)
+	assert.ErrorIs(t, err, authentication.ErrPasswordRequired)
+}
+
+func TestRequestEMailChange(t *testing.T) {
+	sut := createSUT(t)
+	expectAccount(t, sut, "123456")
+
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), newEMail).Return(nil, repository.ErrNotFound).Times(1)
+	sut.accountRepo.EXPECT().SetPendingEMail(gomock.Any(), uint64(1), newEMail).Return(nil).Times(1)
+	sut.accountTokenRepo.EXPECT().RevokeAll(gomock.Any(), uint64(1), types.AccountTokenPurposeEMailChange).
+		Return(nil).Times(1)
+
+	var tokenHash string
+	sut.accountTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), types.AccountTokenPurposeEMailChange, gomock.Any(), time.Hour*24).
+		DoAndReturn(func(_ context.Context, _ uint64, _ types.AccountTokenPurpose, hash string, _ time.Duration) error {
+			tokenHash = hash
+			return nil
+		}).Times(1)
+
+	var messages []mail.Message
+	sut.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, message mail.Message) error {
+		messages = append(messages, message)
+		return nil
+	}).Times(2)
+
+	require.NoError(t, sut.service.RequestEMailChange(context.Background(), 1, "123456", newEMail))
+	require.Len(t, messages, 2)
+
+	// the link goes to the new address, the old one is only told about it
+	assert.Equal(t, newEMail, messages[0].To)
+	assert.Equal(t, "h.kalantari.1997@gmail.com", messages[1].To)
+	assert.NotContains(t, messages[1].Body, emailChangeURL)
+
+	require.Contains(t, messages[0].Body, emailChangeURL+"?token=")
+	token := strings.TrimSpace(messages[0].Body[strings.Index(messages[0].Body, "?token=")+len("?token="):])
+	assert.Equal(t, tokenHash, hashToken(token))
+}
+
+func TestRequestEMailChangeToUsedAddress(t *testing.T) {
+	sut := createSUT(t)
+	expectAccount(t, sut, "123456")
+
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), newEMail).Return(&types.Account{ID: 2}, nil).Times(1)
+	sut.accountRepo.EXPECT().SetPendingEMail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.RequestEMailChange(context.Background(), 1, "123456", newEMail)
+	assert.ErrorIs(t, err, authentication.ErrEMailAlreadyUsed)
+}
+
+func TestConfirmEMailChange(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountTokenRepo.EXPECT().Consume(gomock.Any(), types.AccountTokenPurposeEMailChange, hashToken("token")).
+		Return(uint64(1), nil).Times(1)
+	sut.accountRepo.EXPECT().ApplyPendingEMail(gomock.Any(), uint64(1)).Return(newEMail, nil).Times(1)
+
+	assert.NoError(t, sut.service.ConfirmEMailChange(context.Background(), "token"))
+}
+
+func TestConfirmEMailChangeToAddressRegisteredSince(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountTokenRepo.EXPECT().Consume(gomock.Any(), types.AccountTokenPurposeEMailChange, gomock.Any()).
+		Return(uint64(1), nil).Times(1)
+	sut.accountRepo.EXPECT().ApplyPendingEMail(gomock.Any(), uint64(1)).
+		Return("", repository.ErrUniquenessViolated).Times(1)
+
+	err := sut.service.ConfirmEMailChange(context.Background(), "token")
+	assert.ErrorIs(t, err, authentication.ErrEMailAlreadyUsed)
+}
+
+func TestConfirmEMailChangeWithUnknownToken(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountTokenRepo.EXPECT().Consume(gomock.Any(), types.AccountTokenPurposeEMailChange, gomock.Any()).
+		Return(uint64(0), repository.ErrNotFound).Times(1)
+
+	err := sut.service.ConfirmEMailChange(context.Background(), "token")
+	assert.ErrorIs(t, err, authentication.ErrInvalidEMailChangeToken)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	for _, deletion := range []accountDeletion{
+		{policy: types.AccountDeletionPolicyAnonymize},
+		{policy: types.AccountDeletionPolicyTransfer, transferTo: 2},
+		{policy: types.AccountDeletionPolicyDelete},
+	} {
+		deletion := deletion
+		t.Run(string(deletion.policy), func(t *testing.T) {
+			sut := createSUTWithDeletionPolicy(t, deletion)
+			expectAccount(t, sut, "123456")
+
+			gomock.InOrder(
+				sut.revocationRepo.EXPECT().RevokeAccountBefore(gomock.Any(), uint64(1), gomock.Any(), time.Minute*10).
+					Return(nil).Times(1),
+				sut.accountRepo.EXPECT().Delete(gomock.Any(), uint64(1), deletion.policy, deletion.transferTo).
+					Return([]string{"abc", "def"}, nil).Times(1),
+				sut.urlRepo.EXPECT().Invalidate(gomock.Any(), []string{"abc", "def"}).Return(nil).Times(1),
+			)
+
+			assert.NoError(t, sut.service.DeleteAccount(context.Background(), 1, "123456"))
+		})
+	}
+}
+
+func TestDeleteAccountWithWrongPassword(t *testing.T) {
+	sut := createSUT(t)
+	expectAccount(t, sut, "123456")
+
+	sut.accountRepo.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.DeleteAccount(context.Background(), 1, "000000")
+	assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
+}
+
+func TestDeleteAccountReceivingTransferredUrls(t *testing.T) {
+	sut := createSUTWithDeletionPolicy(t, accountDeletion{policy: types.AccountDeletionPolicyTransfer, transferTo: 1})
+	expectAccount(t, sut, "123456")
+
+	sut.accountRepo.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.DeleteAccount(context.Background(), 1, "123456")
+	assert.ErrorIs(t, err, authentication.ErrAccountNotDeletable)
+}
@@ -0,0 +1,131 @@
+package authentication_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	sut := createSUT(t)
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).Return(&types.Account{ID: 1}, nil).Times(1)
+
+	var saved *types.APIKey
+	sut.apiKeyRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, apiKey *types.APIKey) (*types.APIKey, error) {
+			saved = apiKey
+			created := *apiKey
+			created.ID = 5
+			return &created, nil
+		},
+	).Times(1)
+
+	scopes := types.Scopes{types.ScopeUrlsRead}
+	key, apiKey, err := sut.service.CreateAPIKey(context.Background(), 1, "ci", scopes, nil)
+	require.NoError(t, err)
+
+	assert.True(t, authentication.IsAPIKey(key))
+	assert.Equal(t, uint64(5), apiKey.ID)
+	assert.Equal(t, uint64(1), saved.AccountID)
+	assert.Equal(t, scopes, saved.Scopes)
+	assert.True(t, strings.HasPrefix(key, saved.Prefix), "prefix should be the beginning of the key")
+	assert.Equal(t, hashToken(key), saved.KeyHash, "only the hash of the key should be stored")
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name      string
+		keyName   string
+		scopes    types.Scopes
+		expiresAt *time.Time
+		err       error
+	}{
+		{"empty name", "", types.Scopes{types.ScopeUrlsRead}, nil, authentication.ErrInvalidAPIKeyName},
+		{"long name", strings.Repeat("a", types.MaxAPIKeyNameLength+1), types.Scopes{types.ScopeUrlsRead}, nil, authentication.ErrInvalidAPIKeyName},
+		{"no scopes", "ci", types.Scopes{}, nil, authentication.ErrNoScope},
+		{"unknown scope", "ci", types.Scopes{"urls:delete"}, nil, authentication.ErrInvalidScope},
+		{"expired", "ci", types.Scopes{types.ScopeUrlsRead}, &past, authentication.ErrInvalidAPIKeyExpiry},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sut := createSUT(t)
+			sut.apiKeyRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+			_, _, err := sut.service.CreateAPIKey(context.Background(), 1, test.keyName, test.scopes, test.expiresAt)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestCreateAPIKeyWithScopeNotGrantedToAccount(t *testing.T) {
+	sut := createSUT(t)
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).Return(&types.Account{ID: 1}, nil).Times(1)
+	sut.apiKeyRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	_, _, err := sut.service.CreateAPIKey(context.Background(), 1, "ci", types.Scopes{types.ScopeAdmin}, nil)
+	assert.ErrorIs(t, err, authentication.ErrScopeNotGranted)
+}
+
+func TestGetAccountInfoFromAPIKey(t *testing.T) {
+	const key = authentication.APIKeyPrefix + "abcdefghijklmnopqrstuvwxyz"
+	scopes := types.Scopes{types.ScopeUrlsRead, types.ScopeUrlsCreate}
+
+	sut := createSUT(t)
+	sut.apiKeyRepo.EXPECT().GetByHash(gomock.Any(), hashToken(key)).
+		Return(&types.APIKey{ID: 5, AccountID: 1, Scopes: scopes}, nil).Times(1)
+	sut.apiKeyRepo.EXPECT().Touch(gomock.Any(), uint64(5)).Return(nil).Times(1)
+
+	accountInfo, err := sut.service.GetAccountInfoFromAPIKey(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, &types.AccountInfo{ID: 1, APIKeyID: 5, Scopes: scopes}, accountInfo)
+	assert.True(t, accountInfo.HasScope(types.ScopeUrlsCreate))
+	assert.False(t, accountInfo.HasScope(types.ScopeUrlsWrite))
+}
+
+func TestGetAccountInfoFromAPIKeyWhenUsageIsNotRecorded(t *testing.T) {
+	sut := createSUT(t)
+	sut.apiKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).
+		Return(&types.APIKey{ID: 5, AccountID: 1}, nil).Times(1)
+	sut.apiKeyRepo.EXPECT().Touch(gomock.Any(), uint64(5)).Return(errors.New("connection refused")).Times(1)
+
+	_, err := sut.service.GetAccountInfoFromAPIKey(context.Background(), authentication.APIKeyPrefix+"abc")
+	assert.NoError(t, err)
+}
+
+func TestGetAccountInfoFromUnknownAPIKey(t *testing.T) {
+	sut := createSUT(t)
+	sut.apiKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(nil, repository.ErrNotFound).Times(1)
+
+	_, err := sut.service.GetAccountInfoFromAPIKey(context.Background(), authentication.APIKeyPrefix+"abc")
+	assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
+}
+
+func TestGetAccountInfoFromExpiredAPIKey(t *testing.T) {
+	expiredAt := time.Now().Add(-time.Minute)
+
+	sut := createSUT(t)
+	sut.apiKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).
+		Return(&types.APIKey{ID: 5, AccountID: 1, ExpiresAt: &expiredAt}, nil).Times(1)
+	sut.apiKeyRepo.EXPECT().Touch(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.GetAccountInfoFromAPIKey(context.Background(), authentication.APIKeyPrefix+"abc")
+	assert.ErrorIs(t, err, authentication.ErrExpiredToken)
+}
+
+func TestRevokeUnknownAPIKey(t *testing.T) {
+	sut := createSUT(t)
+	sut.apiKeyRepo.EXPECT().Revoke(gomock.Any(), uint64(1), uint64(5)).Return(repository.ErrNotFound).Times(1)
+
+	assert.ErrorIs(t, sut.service.RevokeAPIKey(context.Background(), 1, 5), authentication.ErrAPIKeyNotFound)
+}
@@ -4,22 +4,171 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/jwk"
 )
 
+// APIKeyPrefix starts every api key, which makes leaked keys easy to recognize.
+const APIKeyPrefix = "usk_"
+
 var (
 	ErrValidationFailed = errors.New("validation error")
 	ErrWrongCredentials = fmt.Errorf("%w: wrong credentials", ErrValidationFailed)
+	ErrRevokedToken     = fmt.Errorf("%w: token is revoked", ErrWrongCredentials)
 	ErrEMailAlreadyUsed = fmt.Errorf("%w: email already used", ErrValidationFailed)
 	ErrExpiredToken     = fmt.Errorf("%w: token is expired", ErrValidationFailed)
 	ErrTamperedToken    = fmt.Errorf("%w: token is tampered", ErrValidationFailed)
 	ErrWrongToken       = fmt.Errorf("%w: token is malformed or was not meant for this purpose", ErrValidationFailed)
+
+	ErrInvalidVerificationToken = fmt.Errorf("%w: verification token is unknown, expired or already used", ErrValidationFailed)
+	ErrEMailAlreadyVerified     = fmt.Errorf("%w: email is already verified", ErrValidationFailed)
+	ErrInvalidResetToken        = fmt.Errorf("%w: password reset token is unknown, expired or already used", ErrValidationFailed)
+	ErrSessionNotFound          = fmt.Errorf("%w: session not found", ErrValidationFailed)
+	ErrInvalidEMailChangeToken  = fmt.Errorf("%w: email change token is unknown, expired or already used", ErrValidationFailed)
+	ErrPasswordRequired         = fmt.Errorf("%w: account has no password, one can be set through password reset", ErrValidationFailed)
+	ErrAccountNotDeletable      = fmt.Errorf("%w: urls of deleted accounts are transferred to this account", ErrValidationFailed)
+
+	ErrInvalidAPIKeyName   = fmt.Errorf("%w: api key name must be between 1 and %d characters", ErrValidationFailed, types.MaxAPIKeyNameLength)
+	ErrInvalidScope        = fmt.Errorf("%w: unknown scope", ErrValidationFailed)
+	ErrNoScope             = fmt.Errorf("%w: at least one scope is required", ErrValidationFailed)
+	ErrScopeNotGranted     = fmt.Errorf("%w: scope is not granted to the account", ErrValidationFailed)
+	ErrInvalidAPIKeyExpiry = fmt.Errorf("%w: api key expiry must be in the future", ErrValidationFailed)
+	ErrAPIKeyNotFound      = fmt.Errorf("%w: api key not found", ErrValidationFailed)
+
+	ErrWrongTwoFactorCode      = fmt.Errorf("%w: two-factor code is wrong or already used", ErrWrongCredentials)
+	ErrTwoFactorAlreadyEnabled = fmt.Errorf("%w: two-factor authentication is already enabled", ErrValidationFailed)
+	ErrTwoFactorNotEnabled     = fmt.Errorf("%w: two-factor authentication is not enabled", ErrValidationFailed)
+	ErrTwoFactorNotEnrolled    = fmt.Errorf("%w: two-factor enrollment was not started", ErrValidationFailed)
+
+	ErrSSODisabled         = fmt.Errorf("%w: single sign-on is not configured", ErrValidationFailed)
+	ErrSSOFailed           = fmt.Errorf("%w: single sign-on failed", ErrWrongCredentials)
+	ErrSSOEMailNotVerified = fmt.Errorf("%w: provider has not verified the email address", ErrValidationFailed)
+	// ErrSSOAccountNotVerified is returned instead of linking an identity to an account that has not verified its
+	// email address. whoever registered it may not own the address, and would keep their way into the account.
+	ErrSSOAccountNotVerified = fmt.Errorf("%w: account has not verified the email address", ErrValidationFailed)
+
+	// ErrTooManyAttempts is not a validation error, the attempt is refused before the credentials are checked.
+	ErrTooManyAttempts = errors.New("too many failed attempts")
+	ErrAccountLocked   = fmt.Errorf("%w: account is temporarily locked", ErrTooManyAttempts)
 )
 
+// TooManyAttemptsError is returned instead of checking credentials while failed attempts are throttled. it is
+// ErrAccountLocked once the account is locked out, and ErrTooManyAttempts for the delays before it and for client ips.
+type TooManyAttemptsError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", e.Err, e.RetryAfter)
+}
+
+func (e *TooManyAttemptsError) Unwrap() error {
+	return e.Err
+}
+
+// Config holds the settings of the service. lifespans are how long tokens and flows are valid for, urls are the pages
+// links in emails point to.
+type Config struct {
+	RefreshTokenLength   int
+	RefreshTokenLifespan time.Duration
+	AccessTokenLifespan  time.Duration
+
+	VerificationTokenLifespan time.Duration
+	VerificationURL           string
+
+	PasswordResetTokenLifespan time.Duration
+	PasswordResetURL           string
+
+	// EMailChangeURL is the page email change links point to, the token is passed like other account tokens.
+	EMailChangeURL string
+
+	TwoFactorIssuer            string
+	TwoFactorChallengeLifespan time.Duration
+
+	SSOFlowLifespan time.Duration
+
+	// AccountLoginThrottle also throttles the two-factor codes of an account.
+	AccountLoginThrottle  types.LoginThrottlePolicy
+	ClientIPLoginThrottle types.LoginThrottlePolicy
+
+	AccountDeletionPolicy types.AccountDeletionPolicy
+	// AccountDeletionTransferTo receives the urls of deleted accounts under AccountDeletionPolicyTransfer.
+	AccountDeletionTransferTo uint64
+
+	PasswordPolicy types.PasswordPolicy
+}
+
 type Service interface {
-	Login(ctx context.Context, email, password string) (*types.TokenPair, error)
+	// Login only returns a challenge for accounts with two-factor authentication, the session is opened once the
+	// challenge is completed with CompleteTwoFactorLogin. failed attempts are throttled per email address and per
+	// client ip, a throttled attempt fails with a *TooManyAttemptsError.
+	Login(ctx context.Context, email, password string, clientInfo types.ClientInfo) (*types.LoginResult, error)
+	// BeginSSOLogin starts an OpenID Connect authorization code flow. the flow token must be handed back along with
+	// what the provider redirects with.
+	BeginSSOLogin(ctx context.Context) (authorizationURL, flowToken string, err error)
+	// CompleteSSOLogin logs into the account linked to the user of the provider. an account is linked by email, or
+	// created without a password, on first login.
+	CompleteSSOLogin(
+		ctx context.Context, flowToken, state, code string, clientInfo types.ClientInfo,
+	) (*types.LoginResult, error)
+	// CompleteTwoFactorLogin accepts either a TOTP code or one of the recovery codes of the account. wrong codes are
+	// throttled like wrong passwords, per account.
+	CompleteTwoFactorLogin(
+		ctx context.Context, challengeToken, code string, clientInfo types.ClientInfo,
+	) (*types.TokenPair, error)
 	RenewTokens(ctx context.Context, oldAccessToken, refreshToken string) (*types.TokenPair, error)
 	GetAccountInfoFromAccessToken(ctx context.Context, accessToken string) (*types.AccountInfo, error)
+	// GetJSONWebKeySet returns the public keys tokens can be verified with. shared secrets are left out.
+	GetJSONWebKeySet() jwk.JSONWebKeySet
+	// Register rejects malformed email addresses and passwords the password policy does not allow with a
+	// *FieldValidationError, like every method taking a new email address or password.
 	Register(ctx context.Context, email, password string) error
+	VerifyEMail(ctx context.Context, verificationToken string) error
+	ResendVerificationEMail(ctx context.Context, accountID uint64) error
+	// RequestPasswordReset mails a password reset link to the account. it does not fail for unknown email
+	// addresses, so that it can not be used to find out who has an account.
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, resetToken, newPassword string) error
+
+	// ChangePassword ends every session of the account, and opens a new one for clientInfo. like the other changes
+	// to the account, it needs the current password, and wrong ones are throttled like failed logins.
+	ChangePassword(
+		ctx context.Context, accountID uint64, currentPassword, newPassword string, clientInfo types.ClientInfo,
+	) (*types.TokenPair, error)
+	// RequestEMailChange mails a confirmation link to the new address. the email is not changed until the link is
+	// opened, the old address is told about the request.
+	RequestEMailChange(ctx context.Context, accountID uint64, currentPassword, newEMail string) error
+	ConfirmEMailChange(ctx context.Context, token string) error
+	// DeleteAccount deletes the account for good. its urls are anonymized, transferred or deleted as the deletion
+	// policy says.
+	DeleteAccount(ctx context.Context, accountID uint64, currentPassword string) error
+
+	GetSessions(ctx context.Context, accountID uint64) ([]types.Session, error)
+	// RevokeSession ends a session of an account. logging out is revoking the session of the current access token.
+	RevokeSession(ctx context.Context, accountID, sessionID uint64) error
+	RevokeAllSessions(ctx context.Context, accountID uint64) error
+
+	// CreateAPIKey returns the new key along with its details. the key can not be retrieved again afterwards.
+	CreateAPIKey(
+		ctx context.Context, accountID uint64, name string, scopes types.Scopes, expiresAt *time.Time,
+	) (string, *types.APIKey, error)
+	GetAPIKeys(ctx context.Context, accountID uint64) ([]types.APIKey, error)
+	RevokeAPIKey(ctx context.Context, accountID, apiKeyID uint64) error
+	GetAccountInfoFromAPIKey(ctx context.Context, apiKey string) (*types.AccountInfo, error)
+
+	// BeginTwoFactorEnrollment returns a new secret, both in the form typed into authenticator apps and as a
+	// provisioning uri. two-factor authentication is not enabled until the enrollment is confirmed.
+	BeginTwoFactorEnrollment(ctx context.Context, accountID uint64) (secret, provisioningURI string, err error)
+	// ConfirmTwoFactorEnrollment enables two-factor authentication and returns the recovery codes of the account.
+	ConfirmTwoFactorEnrollment(ctx context.Context, accountID uint64, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, accountID uint64, code string) error
+}
+
+// IsAPIKey tells api keys apart from access tokens, so both can be sent in the same header.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
 }
@@ -1,46 +1,206 @@
 package authentication_test
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	mockAccount "github.com/h3isenbug/url-shortener/internal/repository/account/mock"
+	mockAccountToken "github.com/h3isenbug/url-shortener/internal/repository/accountToken/mock"
+	mockAPIKey "github.com/h3isenbug/url-shortener/internal/repository/apiKey/mock"
+	mockLoginAttempt "github.com/h3isenbug/url-shortener/internal/repository/loginAttempt/mock"
 	mockRefreshToken "github.com/h3isenbug/url-shortener/internal/repository/refreshToken/mock"
+	mockRevocation "github.com/h3isenbug/url-shortener/internal/repository/revocation/mock"
+	mockTwoFactor "github.com/h3isenbug/url-shortener/internal/repository/twoFactor/mock"
+	mockUrl "github.com/h3isenbug/url-shortener/internal/repository/url/mock"
 	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/breach"
+	"github.com/h3isenbug/url-shortener/pkg/jwk"
 	"github.com/h3isenbug/url-shortener/pkg/log"
+	mockMail "github.com/h3isenbug/url-shortener/pkg/mail/mock"
+	mockOIDC "github.com/h3isenbug/url-shortener/pkg/oidc/mock"
 	"github.com/stretchr/testify/require"
 )
 
 const refreshTokenLength = 30
 
-func createSUT(t *testing.T) (authentication.Service, *mockAccount.MockRepository, *mockRefreshToken.MockRepository) {
+var refreshTokenHashKey = []byte("Zd3cpmAdKFFd3ZpvLVkr0Hs1bJhb0DzW")
+
+const verificationURL = "https://short.ir/verify"
+
+const passwordResetURL = "https://short.ir/reset-password"
+
+const emailChangeURL = "https://short.ir/change-email"
+
+const twoFactorIssuer = "short.ir"
+
+const oidcIssuer = "https://accounts.example.com"
+
+var accountLoginThrottle = types.LoginThrottlePolicy{
+	FreeAttempts: 3, BaseDelay: time.Second, LockoutThreshold: 10, LockoutDuration: time.Minute * 15,
+	FailureWindow: time.Hour,
+}
+
+var clientIPLoginThrottle = types.LoginThrottlePolicy{
+	FreeAttempts: 20, BaseDelay: time.Second, LockoutThreshold: 100, LockoutDuration: time.Minute * 15,
+	FailureWindow: time.Hour,
+}
+
+var passwordPolicy = types.PasswordPolicy{MinLength: 6, MaxLength: 72, Blocklist: []string{"password", "qwerty123"}}
+
+// breachedPasswords holds the sha-1 hash of "correct horse battery staple".
+const breachedPasswords = "ABF7AAD6438836DBE526AA231ABDE2D0EEF74D42:103"
+
+// serviceConfig anonymizes the urls of deleted accounts.
+var serviceConfig = authentication.Config{
+	RefreshTokenLength:         refreshTokenLength,
+	RefreshTokenLifespan:       time.Hour,
+	AccessTokenLifespan:        time.Minute * 10,
+	VerificationTokenLifespan:  time.Hour * 24,
+	VerificationURL:            verificationURL,
+	PasswordResetTokenLifespan: time.Hour,
+	PasswordResetURL:           passwordResetURL,
+	EMailChangeURL:             emailChangeURL,
+	TwoFactorIssuer:            twoFactorIssuer,
+	TwoFactorChallengeLifespan: time.Minute * 5,
+	SSOFlowLifespan:            time.Minute * 10,
+	AccountLoginThrottle:       accountLoginThrottle,
+	ClientIPLoginThrottle:      clientIPLoginThrottle,
+	AccountDeletionPolicy:      types.AccountDeletionPolicyAnonymize,
+	PasswordPolicy:             passwordPolicy,
+}
+
+var clientInfo = types.ClientInfo{UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:93.0) Gecko/20100101 Firefox/93.0", ClientIP: "192.168.10.42"}
+
+type sut struct {
+	service          authentication.Service
+	accountRepo      *mockAccount.MockRepository
+	refreshTokenRepo *mockRefreshToken.MockRepository
+	accountTokenRepo *mockAccountToken.MockRepository
+	revocationRepo   *mockRevocation.MockRepository
+	apiKeyRepo       *mockAPIKey.MockRepository
+	twoFactorRepo    *mockTwoFactor.MockRepository
+	loginAttemptRepo *mockLoginAttempt.MockRepository
+	urlRepo          *mockUrl.MockRepository
+	mailer           *mockMail.MockMailer
+	oidcProvider     *mockOIDC.MockProvider
+}
+
+// createSUT never throttles login attempts, createThrottledSUT leaves them to the test.
+func createSUT(t *testing.T) sut {
+	return createSUTWithKeys(t, createKeys(t), "2")
+}
+
+func createThrottledSUT(t *testing.T) sut {
+	return newSUT(t, createKeys(t), "2", serviceConfig)
+}
+
+// accountDeletion is the deletion policy of the service under test.
+type accountDeletion struct {
+	policy     types.AccountDeletionPolicy
+	transferTo uint64
+}
+
+func createSUTWithDeletionPolicy(t *testing.T, deletion accountDeletion) sut {
+	config := serviceConfig
+	config.AccountDeletionPolicy = deletion.policy
+	config.AccountDeletionTransferTo = deletion.transferTo
+
+	sut := newSUT(t, createKeys(t), "2", config)
+	allowLoginAttempts(sut)
+
+	return sut
+}
+
+func createKeys(t *testing.T) jwk.Keys {
+	firstKey, err := base64.StdEncoding.DecodeString("9D9J0eqJPalytpmklvEK+2iDgCBI2m7bLUXtVLLJRq8AF4yE7QtJIg==")
+	require.NoError(t, err)
+
+	secondKey, err := base64.StdEncoding.DecodeString("tTvp/J03jQu8zkuJP6Vnrk/SuxTC9cWfOj2IsY+8XEzqwfyNm3alAA==")
+	require.NoError(t, err)
+
+	return jwk.Keys{"1": jwk.NewHMACKey("1", firstKey), "2": jwk.NewHMACKey("2", secondKey)}
+}
+
+func createSUTWithKeys(t *testing.T, keys jwk.Keys, currentKID string) sut {
+	sut := newSUT(t, keys, currentKID, serviceConfig)
+	allowLoginAttempts(sut)
+
+	return sut
+}
+
+func allowLoginAttempts(sut sut) {
+	sut.loginAttemptRepo.EXPECT().GetBlock(gomock.Any(), gomock.Any()).Return(types.LoginBlock{}, nil).AnyTimes()
+	sut.loginAttemptRepo.EXPECT().AddFailure(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(int64(1), nil).AnyTimes()
+	sut.loginAttemptRepo.EXPECT().ResetFailures(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+}
+
+func newSUT(t *testing.T, keys jwk.Keys, currentKID string, config authentication.Config) sut {
 	ctrl := gomock.NewController(t)
 
+	keyring, err := jwk.NewStaticKeyring(keys, currentKID)
+	require.NoError(t, err)
+
 	accountRepo := mockAccount.NewMockRepository(ctrl)
 	refreshTokenRepo := mockRefreshToken.NewMockRepository(ctrl)
+	accountTokenRepo := mockAccountToken.NewMockRepository(ctrl)
+	revocationRepo := mockRevocation.NewMockRepository(ctrl)
+	apiKeyRepo := mockAPIKey.NewMockRepository(ctrl)
+	twoFactorRepo := mockTwoFactor.NewMockRepository(ctrl)
+	loginAttemptRepo := mockLoginAttempt.NewMockRepository(ctrl)
+	urlRepo := mockUrl.NewMockRepository(ctrl)
+	mailer := mockMail.NewMockMailer(ctrl)
+	oidcProvider := mockOIDC.NewMockProvider(ctrl)
 
 	logger, err := log.NewZapLoggingService("")
 	require.NoError(t, err)
 
-	firstKey, err := base64.StdEncoding.DecodeString("9D9J0eqJPalytpmklvEK+2iDgCBI2m7bLUXtVLLJRq8AF4yE7QtJIg==")
-	require.NoError(t, err)
-
-	secondKey, err := base64.StdEncoding.DecodeString("tTvp/J03jQu8zkuJP6Vnrk/SuxTC9cWfOj2IsY+8XEzqwfyNm3alAA==")
+	breachedPasswordSource, err := breach.NewListSourceV1(strings.NewReader(breachedPasswords))
 	require.NoError(t, err)
 
-	return authentication.NewAuthenticationServiceV1(
+	service := authentication.NewAuthenticationServiceV1(
 		logger,
 		accountRepo,
 		refreshTokenRepo,
-		refreshTokenLength,
-		time.Hour,
-		time.Minute*10,
-		map[string][]byte{
-			"1": firstKey,
-			"2": secondKey,
-		},
-		"2",
-	), accountRepo, refreshTokenRepo
+		accountTokenRepo,
+		revocationRepo,
+		apiKeyRepo,
+		twoFactorRepo,
+		loginAttemptRepo,
+		urlRepo,
+		mailer,
+		refreshTokenHashKey,
+		keyring,
+		oidcProvider,
+		breachedPasswordSource,
+		config,
+	)
+
+	return sut{
+		service:          service,
+		accountRepo:      accountRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		accountTokenRepo: accountTokenRepo,
+		revocationRepo:   revocationRepo,
+		apiKeyRepo:       apiKeyRepo,
+		twoFactorRepo:    twoFactorRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		urlRepo:          urlRepo,
+		mailer:           mailer,
+		oidcProvider:     oidcProvider,
+	}
+}
+
+func hashRefreshToken(token string) string {
+	mac := hmac.New(sha256.New, refreshTokenHashKey)
+	mac.Write([]byte(token))
+
+	return hex.EncodeToString(mac.Sum(nil))
 }
@@ -0,0 +1,120 @@
+package authentication_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustGenerateKeys(t *testing.T) jwk.Keys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys := jwk.Keys{"hmac": jwk.NewHMACKey("hmac", []byte("tTvp/J03jQu8zkuJP6Vnrk/SuxTC9cWfOj2IsY+8XEzq"))}
+	for kid, privateKey := range map[string]crypto.Signer{
+		jwk.AlgorithmRS256: rsaKey,
+		jwk.AlgorithmES256: ecKey,
+		jwk.AlgorithmEdDSA: edKey,
+	} {
+		keys[kid], err = jwk.NewSigningKey(kid, kid, privateKey)
+		require.NoError(t, err)
+	}
+
+	return keys
+}
+
+func TestAccessTokenSignedWithAsymmetricKey(t *testing.T) {
+	keys := mustGenerateKeys(t)
+
+	for _, algorithm := range []string{jwk.AlgorithmRS256, jwk.AlgorithmES256, jwk.AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			sut := createSUTWithKeys(t, keys, algorithm)
+			tokenPair := loginForSession(t, sut, 3)
+
+			token, _, err := new(jwt.Parser).ParseUnverified(tokenPair.AccessToken, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, token.Method.Alg())
+			assert.Equal(t, algorithm, token.Header["kid"])
+
+			sut.revocationRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(3)).Return(types.Revocation{}, nil).Times(1)
+
+			accountInfo, err := sut.service.GetAccountInfoFromAccessToken(context.Background(), tokenPair.AccessToken)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(1), accountInfo.ID)
+		})
+	}
+}
+
+func TestJSONWebKeySetLeavesOutSharedSecrets(t *testing.T) {
+	sut := createSUTWithKeys(t, mustGenerateKeys(t), jwk.AlgorithmEdDSA)
+
+	keySet := sut.service.GetJSONWebKeySet()
+	require.Len(t, keySet.Keys, 3)
+
+	for _, key := range keySet.Keys {
+		assert.Equal(t, key.KeyID, key.Algorithm)
+		assert.NotEqual(t, "oct", key.KeyType)
+	}
+}
+
+func TestAccessTokenVerifiedWithPublishedKey(t *testing.T) {
+	sut := createSUTWithKeys(t, mustGenerateKeys(t), jwk.AlgorithmES256)
+	tokenPair := loginForSession(t, sut, 3)
+
+	// what a downstream service without access to our secrets does
+	publicKeys := make(map[string]interface{})
+	for _, key := range sut.service.GetJSONWebKeySet().Keys {
+		publicKey, err := key.PublicKey()
+		require.NoError(t, err)
+		publicKeys[key.KeyID] = publicKey
+	}
+
+	token, err := jwt.Parse(tokenPair.AccessToken, func(token *jwt.Token) (interface{}, error) {
+		return publicKeys[token.Header["kid"].(string)], nil
+	})
+	require.NoError(t, err)
+	assert.True(t, token.Valid)
+}
+
+func TestAccessTokenSignedWithPublicKeyAsSecretIsRejected(t *testing.T) {
+	keys := mustGenerateKeys(t)
+	sut := createSUTWithKeys(t, keys, jwk.AlgorithmRS256)
+
+	publicKey, err := x509.MarshalPKIXPublicKey(keys[jwk.AlgorithmRS256].VerificationKey())
+	require.NoError(t, err)
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.RegisteredClaims{
+		Issuer:    types.ServiceName,
+		Subject:   "authentication",
+		Audience:  []string{types.ServiceName},
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	})
+	token.Header["kid"] = jwk.AlgorithmRS256
+
+	forged, err := token.SignedString(publicKey)
+	require.NoError(t, err)
+
+	_, err = sut.service.GetAccountInfoFromAccessToken(context.Background(), forged)
+	assert.ErrorIs(t, err, authentication.ErrValidationFailed)
+}
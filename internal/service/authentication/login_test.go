@@ -36,18 +36,19 @@ func TestLoginSuccessful(t *testing.T) {
 		Family:      1,
 		CreatedAt:   time.Now().UTC(),
 	}
-	authenticationService, accountRepo, refreshTokenRepo := createSUT(t)
+	sut := createSUT(t)
 
-	accountRepo.EXPECT().GetByEMail(
+	sut.accountRepo.EXPECT().GetByEMail(
 		gomock.Any(), gomock.Eq(email),
 	).Return(account, nil).Times(1)
 
-	refreshTokenRepo.EXPECT().Create(
-		gomock.Any(), gomock.Eq(account.ID), gomock.Any(), time.Hour,
+	sut.refreshTokenRepo.EXPECT().Create(
+		gomock.Any(), gomock.Eq(account.ID), gomock.Any(), time.Hour, clientInfo,
 	).Return(refreshToken, nil).Times(1)
 
-	tokenPair, err := authenticationService.Login(context.Background(), email, password)
+	result, err := sut.service.Login(context.Background(), email, password, clientInfo)
 	require.NoError(t, err)
+	tokenPair := result.TokenPair
 	assert.Greater(t, len(tokenPair.AccessToken), 0, "Access token is empty")
 	assert.Greater(t, len(tokenPair.RefreshToken), 0, "Refresh token is empty")
 }
@@ -56,19 +57,19 @@ func TestLoginWrongEMail(t *testing.T) {
 	const email = "h.kalantari.1997@gmail.com"
 	const password = "123456"
 
-	authenticationService, accountRepo, refreshTokenRepo := createSUT(t)
+	sut := createSUT(t)
 
-	accountRepo.EXPECT().GetByEMail(
+	sut.accountRepo.EXPECT().GetByEMail(
 		gomock.Any(), gomock.Eq(email),
 	).Return(nil, repository.ErrNotFound).Times(1)
 
-	refreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
-	tokenPair, err := authenticationService.Login(context.Background(), email, password+"ASD")
+	result, err := sut.service.Login(context.Background(), email, password+"ASD", clientInfo)
 	if assert.Error(t, err) {
 		assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
 	}
-	assert.Nil(t, tokenPair)
+	assert.Nil(t, result)
 }
 
 func TestLoginWrongPassword(t *testing.T) {
@@ -84,17 +85,17 @@ func TestLoginWrongPassword(t *testing.T) {
 		PasswordHash: string(hash),
 	}
 
-	authenticationService, accountRepo, refreshTokenRepo := createSUT(t)
+	sut := createSUT(t)
 
-	accountRepo.EXPECT().GetByEMail(
+	sut.accountRepo.EXPECT().GetByEMail(
 		gomock.Any(), gomock.Eq(email),
 	).Return(account, nil).Times(1)
 
-	refreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
-	tokenPair, err := authenticationService.Login(context.Background(), email, password+"ASD")
+	result, err := sut.service.Login(context.Background(), email, password+"ASD", clientInfo)
 	if assert.Error(t, err) {
 		assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
 	}
-	assert.Nil(t, tokenPair)
+	assert.Nil(t, result)
 }
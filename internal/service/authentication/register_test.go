@@ -2,10 +2,14 @@ package authentication_test
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/mail"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,26 +18,60 @@ func TestRegistrationSuccessful(t *testing.T) {
 	const email = "h.kalantari.1997@gmail.com"
 	const password = "123456"
 
-	authenticationService, accountRepo, _ := createSUT(t)
+	sut := createSUT(t)
 
-	accountRepo.EXPECT().Create(
+	sut.accountRepo.EXPECT().Create(
 		gomock.Any(), gomock.Eq(email), gomock.Any(),
-	).Return(nil).Times(1)
+	).Return(&types.Account{ID: 1, EMail: email}, nil).Times(1)
 
-	require.NoError(t, authenticationService.Register(context.Background(), email, password))
+	var tokenHash string
+	sut.accountTokenRepo.EXPECT().Create(
+		gomock.Any(), uint64(1), types.AccountTokenPurposeEMailVerification, gomock.Any(), gomock.Any(),
+	).DoAndReturn(func(_ context.Context, _ uint64, _ types.AccountTokenPurpose, hash string, _ time.Duration) error {
+		tokenHash = hash
+		return nil
+	}).Times(1)
+
+	sut.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, message mail.Message) error {
+			assert.Equal(t, email, message.To)
+			assert.Contains(t, message.Body, verificationURL+"?token=")
+			assert.NotContains(t, message.Body, tokenHash, "only the hash of the token should be stored")
+			return nil
+		},
+	).Times(1)
+
+	require.NoError(t, sut.service.Register(context.Background(), email, password))
+}
+
+func TestRegistrationSucceedsWhenMailIsNotDelivered(t *testing.T) {
+	const email = "h.kalantari.1997@gmail.com"
+	const password = "123456"
+
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().Create(
+		gomock.Any(), gomock.Eq(email), gomock.Any(),
+	).Return(&types.Account{ID: 1, EMail: email}, nil).Times(1)
+	sut.accountTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).Times(1)
+	sut.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errors.New("connection refused")).Times(1)
+
+	require.NoError(t, sut.service.Register(context.Background(), email, password))
 }
 
 func TestRegistrationInUseEMail(t *testing.T) {
 	const email = "h.kalantari.1997@gmail.com"
 	const password = "123456"
 
-	authenticationService, accountRepo, _ := createSUT(t)
+	sut := createSUT(t)
 
-	accountRepo.EXPECT().Create(
+	sut.accountRepo.EXPECT().Create(
 		gomock.Any(), gomock.Eq(email), gomock.Any(),
-	).Return(authentication.ErrEMailAlreadyUsed).Times(1)
+	).Return(nil, authentication.ErrEMailAlreadyUsed).Times(1)
+	sut.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(0)
 
-	if err := authenticationService.Register(context.Background(), email, password); assert.Error(t, err) {
+	if err := sut.service.Register(context.Background(), email, password); assert.Error(t, err) {
 		assert.ErrorIs(t, err, authentication.ErrEMailAlreadyUsed)
 	}
 }
@@ -0,0 +1,63 @@
+package authentication_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenIsSavedHashed(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).Return(&types.Account{
+		ID: 1, PasswordHash: mustHashPassword(t, "123456"),
+	}, nil).Times(1)
+
+	var savedHash string
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ uint64, tokenHash string, _ time.Duration, _ types.ClientInfo) (*types.RefreshToken, error) {
+			savedHash = tokenHash
+			return &types.RefreshToken{ID: 7, AccountID: 1, Family: 3}, nil
+		},
+	).Times(1)
+
+	result, err := sut.service.Login(context.Background(), "h.kalantari.1997@gmail.com", "123456", clientInfo)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, result.TokenPair.RefreshToken, savedHash)
+	assert.Equal(t, hashRefreshToken(result.TokenPair.RefreshToken), savedHash)
+}
+
+func TestRenewTokensWithUnknownRefreshToken(t *testing.T) {
+	sut := createSUT(t)
+	tokenPair := loginForSession(t, sut, 3)
+
+	// tokens saved before they were hashed are never found again
+	sut.refreshTokenRepo.EXPECT().GetByHash(gomock.Any(), hashRefreshToken(tokenPair.RefreshToken)).
+		Return(nil, repository.ErrNotFound).Times(1)
+
+	_, err := sut.service.RenewTokens(context.Background(), tokenPair.AccessToken, tokenPair.RefreshToken)
+	assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
+}
+
+func TestRenewTokensWithReusedRefreshToken(t *testing.T) {
+	sut := createSUT(t)
+	tokenPair := loginForSession(t, sut, 3)
+
+	sut.refreshTokenRepo.EXPECT().GetByHash(gomock.Any(), hashRefreshToken(tokenPair.RefreshToken)).Return(&types.RefreshToken{
+		ID: 7, AccountID: 1, Family: 3, ValidUntil: time.Now().Add(time.Hour), Disabled: true,
+	}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().SetCompromisedState(gomock.Any(), uint64(3)).Return(nil).Times(1)
+	sut.revocationRepo.EXPECT().RevokeSession(gomock.Any(), uint64(3), time.Minute*10).Return(nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().CreateWithFamily(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.RenewTokens(context.Background(), tokenPair.AccessToken, tokenPair.RefreshToken)
+	assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
+}
@@ -0,0 +1,143 @@
+package authentication_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRequestPasswordReset(t *testing.T) {
+	const email = "h.kalantari.1997@gmail.com"
+
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), email).
+		Return(&types.Account{ID: 1, EMail: email}, nil).Times(1)
+
+	revoke := sut.accountTokenRepo.EXPECT().RevokeAll(
+		gomock.Any(), uint64(1), types.AccountTokenPurposePasswordReset,
+	).Return(nil).Times(1)
+
+	var tokenHash string
+	sut.accountTokenRepo.EXPECT().Create(
+		gomock.Any(), uint64(1), types.AccountTokenPurposePasswordReset, gomock.Any(), time.Hour,
+	).Do(func(_ context.Context, _ uint64, _ types.AccountTokenPurpose, hash string, _ time.Duration) {
+		tokenHash = hash
+	}).Return(nil).After(revoke).Times(1)
+
+	sut.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, message mail.Message) error {
+			assert.Equal(t, email, message.To)
+			require.Contains(t, message.Body, passwordResetURL+"?token=")
+
+			token := strings.TrimSpace(message.Body[strings.Index(message.Body, "?token=")+len("?token="):])
+			assert.Equal(t, tokenHash, hashToken(token), "mailed token does not match the stored one")
+			return nil
+		},
+	).Times(1)
+
+	require.NoError(t, sut.service.RequestPasswordReset(context.Background(), email))
+}
+
+func TestRequestPasswordResetUnknownEMail(t *testing.T) {
+	const email = "nobody@example.com"
+
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), email).
+		Return(nil, repository.ErrNotFound).Times(1)
+	sut.accountTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(0)
+
+	require.NoError(t, sut.service.RequestPasswordReset(context.Background(), email))
+}
+
+func TestRequestPasswordResetHidesDeliveryFailure(t *testing.T) {
+	const email = "h.kalantari.1997@gmail.com"
+
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), email).
+		Return(&types.Account{ID: 1, EMail: email}, nil).Times(1)
+	sut.accountTokenRepo.EXPECT().RevokeAll(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	sut.accountTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).Times(1)
+	sut.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errors.New("connection refused")).Times(1)
+
+	require.NoError(t, sut.service.RequestPasswordReset(context.Background(), email))
+}
+
+func TestResetPasswordSuccessful(t *testing.T) {
+	const token = "reset-token"
+	const newPassword = "new password"
+
+	sut := createSUT(t)
+
+	sut.accountTokenRepo.EXPECT().Consume(
+		gomock.Any(), types.AccountTokenPurposePasswordReset, hashToken(token),
+	).Return(uint64(1), nil).Times(1)
+
+	setPassword := sut.accountRepo.EXPECT().SetPasswordHash(gomock.Any(), uint64(1), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ uint64, passwordHash string) error {
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(newPassword)))
+			return nil
+		},
+	).Times(1)
+	sut.refreshTokenRepo.EXPECT().RevokeAllForAccount(gomock.Any(), uint64(1)).Return(nil).After(setPassword).Times(1)
+	sut.revocationRepo.EXPECT().RevokeAccountBefore(gomock.Any(), uint64(1), gomock.Any(), time.Minute*10).
+		Return(nil).Times(1)
+
+	require.NoError(t, sut.service.ResetPassword(context.Background(), token, newPassword))
+}
+
+func TestResetPasswordInvalidToken(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountTokenRepo.EXPECT().Consume(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(uint64(0), repository.ErrNotFound).Times(1)
+	sut.accountRepo.EXPECT().SetPasswordHash(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.refreshTokenRepo.EXPECT().RevokeAllForAccount(gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.ResetPassword(context.Background(), "used-or-expired-token", "new password")
+	assert.ErrorIs(t, err, authentication.ErrInvalidResetToken)
+}
+
+func TestRenewTokensWithRevokedRefreshToken(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).Return(&types.Account{
+		ID: 1, PasswordHash: mustHashPassword(t, "123456"),
+	}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 1}, nil).Times(1)
+
+	result, err := sut.service.Login(context.Background(), "h.kalantari.1997@gmail.com", "123456", clientInfo)
+	require.NoError(t, err)
+	tokenPair := result.TokenPair
+
+	sut.refreshTokenRepo.EXPECT().GetByHash(gomock.Any(), hashRefreshToken(tokenPair.RefreshToken)).Return(&types.RefreshToken{
+		ID: 7, AccountID: 1, Family: 1, ValidUntil: time.Now().Add(time.Hour), Revoked: true,
+	}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().CreateWithFamily(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err = sut.service.RenewTokens(context.Background(), tokenPair.AccessToken, tokenPair.RefreshToken)
+	assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
+}
+
+func mustHashPassword(t *testing.T, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	return string(hash)
+}
@@ -0,0 +1,190 @@
+package authentication_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessTokenCarriesSession(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).Return(&types.Account{
+		ID: 1, PasswordHash: mustHashPassword(t, "123456"),
+	}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), clientInfo).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 3}, nil).Times(1)
+
+	result, err := sut.service.Login(context.Background(), "h.kalantari.1997@gmail.com", "123456", clientInfo)
+	require.NoError(t, err)
+	tokenPair := result.TokenPair
+
+	sut.revocationRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(3)).Return(types.Revocation{}, nil).Times(1)
+
+	accountInfo, err := sut.service.GetAccountInfoFromAccessToken(context.Background(), tokenPair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, &types.AccountInfo{ID: 1, SessionID: 3, Scopes: types.DefaultScopes}, accountInfo)
+}
+
+func TestGetSessions(t *testing.T) {
+	sessions := []types.Session{
+		{ID: 3, UserAgent: clientInfo.UserAgent, ClientIP: clientInfo.ClientIP, CreatedAt: time.Now(), LastUsedAt: time.Now()},
+	}
+
+	sut := createSUT(t)
+	sut.refreshTokenRepo.EXPECT().GetActiveFamilies(gomock.Any(), uint64(1)).Return(sessions, nil).Times(1)
+
+	result, err := sut.service.GetSessions(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, sessions, result)
+}
+
+func TestRevokeSession(t *testing.T) {
+	sut := createSUT(t)
+	revokeFamily := sut.refreshTokenRepo.EXPECT().RevokeFamily(gomock.Any(), uint64(1), uint64(3)).Return(nil).Times(1)
+	sut.revocationRepo.EXPECT().RevokeSession(gomock.Any(), uint64(3), time.Minute*10).
+		Return(nil).After(revokeFamily).Times(1)
+
+	require.NoError(t, sut.service.RevokeSession(context.Background(), 1, 3))
+}
+
+func TestRevokeUnknownSession(t *testing.T) {
+	sut := createSUT(t)
+	sut.refreshTokenRepo.EXPECT().RevokeFamily(gomock.Any(), uint64(1), uint64(4)).
+		Return(repository.ErrNotFound).Times(1)
+	sut.revocationRepo.EXPECT().RevokeSession(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.RevokeSession(context.Background(), 1, 4)
+	assert.ErrorIs(t, err, authentication.ErrSessionNotFound)
+
+	// access tokens issued before sessions were tracked carry no session
+	err = sut.service.RevokeSession(context.Background(), 1, 0)
+	assert.ErrorIs(t, err, authentication.ErrSessionNotFound)
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	sut := createSUT(t)
+	sut.refreshTokenRepo.EXPECT().RevokeAllForAccount(gomock.Any(), uint64(1)).Return(nil).Times(1)
+	sut.revocationRepo.EXPECT().RevokeAccountBefore(gomock.Any(), uint64(1), gomock.Any(), time.Minute*10).DoAndReturn(
+		func(_ context.Context, _ uint64, before time.Time, _ time.Duration) error {
+			assert.WithinDuration(t, time.Now(), before, time.Minute)
+			return nil
+		},
+	).Times(1)
+
+	require.NoError(t, sut.service.RevokeAllSessions(context.Background(), 1))
+}
+
+func loginForSession(t *testing.T, sut sut, sessionID uint64) *types.TokenPair {
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).Return(&types.Account{
+		ID: 1, PasswordHash: mustHashPassword(t, "123456"),
+	}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: sessionID}, nil).Times(1)
+
+	result, err := sut.service.Login(context.Background(), "h.kalantari.1997@gmail.com", "123456", clientInfo)
+	require.NoError(t, err)
+	tokenPair := result.TokenPair
+
+	return tokenPair
+}
+
+func TestAccessTokenOfRevokedSessionIsRejected(t *testing.T) {
+	sut := createSUT(t)
+	tokenPair := loginForSession(t, sut, 3)
+
+	sut.revocationRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(3)).
+		Return(types.Revocation{SessionRevoked: true}, nil).Times(1)
+
+	_, err := sut.service.GetAccountInfoFromAccessToken(context.Background(), tokenPair.AccessToken)
+	assert.ErrorIs(t, err, authentication.ErrRevokedToken)
+	assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
+}
+
+func TestAccessTokenIssuedBeforeAccountRevocationIsRejected(t *testing.T) {
+	sut := createSUT(t)
+	tokenPair := loginForSession(t, sut, 3)
+
+	sut.revocationRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(3)).
+		Return(types.Revocation{RevokedBefore: time.Now().Add(time.Minute)}, nil).Times(1)
+
+	_, err := sut.service.GetAccountInfoFromAccessToken(context.Background(), tokenPair.AccessToken)
+	assert.ErrorIs(t, err, authentication.ErrRevokedToken)
+
+	// a token issued after the revocation is let through
+	sut.revocationRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(3)).
+		Return(types.Revocation{RevokedBefore: time.Now().Add(-time.Minute)}, nil).Times(1)
+
+	_, err = sut.service.GetAccountInfoFromAccessToken(context.Background(), tokenPair.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestAccessTokenCarriesScopesOfAccount(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).Return(&types.Account{
+		ID: 1, PasswordHash: mustHashPassword(t, "123456"), Admin: true,
+	}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), clientInfo).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 3}, nil).Times(1)
+
+	result, err := sut.service.Login(context.Background(), "h.kalantari.1997@gmail.com", "123456", clientInfo)
+	require.NoError(t, err)
+	tokenPair := result.TokenPair
+
+	sut.refreshTokenRepo.EXPECT().GetByHash(gomock.Any(), hashRefreshToken(tokenPair.RefreshToken)).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 3, ValidUntil: time.Now().Add(time.Hour)}, nil).Times(1)
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).Return(&types.Account{ID: 1, Admin: true}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().CreateWithFamily(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), uint64(3)).
+		Return(&types.RefreshToken{ID: 8, AccountID: 1, Family: 3}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Disable(gomock.Any(), uint64(7)).Return(nil).Times(1)
+
+	tokenPair, err = sut.service.RenewTokens(context.Background(), tokenPair.AccessToken, tokenPair.RefreshToken)
+	require.NoError(t, err)
+
+	sut.revocationRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(3)).Return(types.Revocation{}, nil).Times(1)
+
+	accountInfo, err := sut.service.GetAccountInfoFromAccessToken(context.Background(), tokenPair.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, accountInfo.HasScope(types.ScopeAdmin))
+	assert.ElementsMatch(t, types.AllScopes, accountInfo.Scopes)
+}
+
+func TestRenewedTokensLoseScopesTakenFromAccount(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).Return(&types.Account{
+		ID: 1, PasswordHash: mustHashPassword(t, "123456"), Admin: true,
+	}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), clientInfo).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 3}, nil).Times(1)
+
+	result, err := sut.service.Login(context.Background(), "h.kalantari.1997@gmail.com", "123456", clientInfo)
+	require.NoError(t, err)
+	tokenPair := result.TokenPair
+
+	// the account is no longer an administrator by the time the session is renewed
+	sut.refreshTokenRepo.EXPECT().GetByHash(gomock.Any(), hashRefreshToken(tokenPair.RefreshToken)).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 3, ValidUntil: time.Now().Add(time.Hour)}, nil).Times(1)
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).Return(&types.Account{ID: 1}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().CreateWithFamily(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), uint64(3)).
+		Return(&types.RefreshToken{ID: 8, AccountID: 1, Family: 3}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Disable(gomock.Any(), uint64(7)).Return(nil).Times(1)
+
+	tokenPair, err = sut.service.RenewTokens(context.Background(), tokenPair.AccessToken, tokenPair.RefreshToken)
+	require.NoError(t, err)
+
+	sut.revocationRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(3)).Return(types.Revocation{}, nil).Times(1)
+
+	accountInfo, err := sut.service.GetAccountInfoFromAccessToken(context.Background(), tokenPair.AccessToken)
+	require.NoError(t, err)
+	assert.False(t, accountInfo.HasScope(types.ScopeAdmin))
+	assert.ElementsMatch(t, types.DefaultScopes, accountInfo.Scopes)
+}
@@ -0,0 +1,179 @@
+package authentication_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ssoFlow struct {
+	flowToken, state, nonce, codeChallenge string
+}
+
+func beginSSOLogin(t *testing.T, sut sut) ssoFlow {
+	var flow ssoFlow
+	sut.oidcProvider.EXPECT().AuthorizationURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, state, nonce, codeChallenge string) (string, error) {
+			flow.state, flow.nonce, flow.codeChallenge = state, nonce, codeChallenge
+			return oidcIssuer + "/authorize?state=" + state, nil
+		},
+	).Times(1)
+
+	authorizationURL, flowToken, err := sut.service.BeginSSOLogin(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, oidcIssuer+"/authorize?state="+flow.state, authorizationURL)
+	assert.NotEmpty(t, flow.state)
+	assert.NotEmpty(t, flow.nonce)
+
+	flow.flowToken = flowToken
+	return flow
+}
+
+func expectExchange(t *testing.T, sut sut, flow ssoFlow, claims *oidc.Claims) {
+	sut.oidcProvider.EXPECT().Issuer().Return(oidcIssuer).AnyTimes()
+	sut.oidcProvider.EXPECT().Exchange(gomock.Any(), "code", gomock.Any(), flow.nonce).DoAndReturn(
+		func(_ context.Context, _, codeVerifier, _ string) (*oidc.Claims, error) {
+			assert.Equal(t, flow.codeChallenge, oidc.CodeChallenge(codeVerifier), "code verifier does not match the challenge")
+			return claims, nil
+		},
+	).Times(1)
+}
+
+func TestSSOLoginProvisionsAccount(t *testing.T) {
+	const email = "h.kalantari.1997@gmail.com"
+
+	sut := createSUT(t)
+	flow := beginSSOLogin(t, sut)
+	expectExchange(t, sut, flow, &oidc.Claims{Subject: "248289761001", EMail: email, EMailVerified: true})
+
+	sut.accountRepo.EXPECT().GetByIdentity(gomock.Any(), oidcIssuer, "248289761001").
+		Return(nil, repository.ErrNotFound).Times(1)
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), email).Return(nil, repository.ErrNotFound).Times(1)
+	sut.accountRepo.EXPECT().CreateWithIdentity(gomock.Any(), email, oidcIssuer, "248289761001").
+		Return(&types.Account{ID: 1, EMail: email, EMailVerified: true}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), clientInfo).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 3}, nil).Times(1)
+
+	result, err := sut.service.CompleteSSOLogin(context.Background(), flow.flowToken, flow.state, "code", clientInfo)
+	require.NoError(t, err)
+	require.NotNil(t, result.TokenPair)
+}
+
+func TestSSOLoginLinksAccountByEMail(t *testing.T) {
+	const email = "h.kalantari.1997@gmail.com"
+
+	sut := createSUT(t)
+	flow := beginSSOLogin(t, sut)
+	expectExchange(t, sut, flow, &oidc.Claims{Subject: "248289761001", EMail: email, EMailVerified: true})
+
+	sut.accountRepo.EXPECT().GetByIdentity(gomock.Any(), oidcIssuer, "248289761001").
+		Return(nil, repository.ErrNotFound).Times(1)
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), email).
+		Return(&types.Account{ID: 1, EMail: email, EMailVerified: true, PasswordHash: mustHashPassword(t, "123456")}, nil).
+		Times(1)
+	sut.accountRepo.EXPECT().LinkIdentity(gomock.Any(), uint64(1), oidcIssuer, "248289761001").Return(nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), clientInfo).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 3}, nil).Times(1)
+
+	result, err := sut.service.CompleteSSOLogin(context.Background(), flow.flowToken, flow.state, "code", clientInfo)
+	require.NoError(t, err)
+	require.NotNil(t, result.TokenPair)
+}
+
+func TestSSOLoginDoesNotLinkUnverifiedAccount(t *testing.T) {
+	const email = "h.kalantari.1997@gmail.com"
+
+	sut := createSUT(t)
+	flow := beginSSOLogin(t, sut)
+	expectExchange(t, sut, flow, &oidc.Claims{Subject: "248289761001", EMail: email, EMailVerified: true})
+
+	// whoever registered the address first could still log in with the password they chose
+	sut.accountRepo.EXPECT().GetByIdentity(gomock.Any(), oidcIssuer, "248289761001").
+		Return(nil, repository.ErrNotFound).Times(1)
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), email).
+		Return(&types.Account{ID: 1, EMail: email, PasswordHash: mustHashPassword(t, "123456")}, nil).Times(1)
+	sut.accountRepo.EXPECT().LinkIdentity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.accountRepo.EXPECT().SetEMailVerified(gomock.Any(), gomock.Any()).Times(0)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.CompleteSSOLogin(context.Background(), flow.flowToken, flow.state, "code", clientInfo)
+	assert.ErrorIs(t, err, authentication.ErrSSOAccountNotVerified)
+}
+
+func TestSSOLoginOfLinkedAccountRequiresTwoFactor(t *testing.T) {
+	sut := createSUT(t)
+	flow := beginSSOLogin(t, sut)
+	// the provider has no say over which account a linked identity belongs to
+	expectExchange(t, sut, flow, &oidc.Claims{Subject: "248289761001", EMail: "someone@example.com"})
+
+	sut.accountRepo.EXPECT().GetByIdentity(gomock.Any(), oidcIssuer, "248289761001").
+		Return(&types.Account{ID: 1, TwoFactorEnabled: true}, nil).Times(1)
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).Times(0)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := sut.service.CompleteSSOLogin(context.Background(), flow.flowToken, flow.state, "code", clientInfo)
+	require.NoError(t, err)
+	assert.Nil(t, result.TokenPair)
+	assert.NotEmpty(t, result.ChallengeToken)
+}
+
+func TestSSOLoginWithUnverifiedEMail(t *testing.T) {
+	sut := createSUT(t)
+	flow := beginSSOLogin(t, sut)
+	expectExchange(t, sut, flow, &oidc.Claims{Subject: "248289761001", EMail: "h.kalantari.1997@gmail.com"})
+
+	sut.accountRepo.EXPECT().GetByIdentity(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, repository.ErrNotFound).Times(1)
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).Times(0)
+	sut.accountRepo.EXPECT().LinkIdentity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.accountRepo.EXPECT().CreateWithIdentity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.CompleteSSOLogin(context.Background(), flow.flowToken, flow.state, "code", clientInfo)
+	assert.ErrorIs(t, err, authentication.ErrSSOEMailNotVerified)
+}
+
+func TestSSOLoginWithWrongState(t *testing.T) {
+	sut := createSUT(t)
+	flow := beginSSOLogin(t, sut)
+	sut.oidcProvider.EXPECT().Exchange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.CompleteSSOLogin(context.Background(), flow.flowToken, "forged-state", "code", clientInfo)
+	assert.ErrorIs(t, err, authentication.ErrSSOFailed)
+	assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
+}
+
+func TestSSOLoginRejectedByProvider(t *testing.T) {
+	sut := createSUT(t)
+	flow := beginSSOLogin(t, sut)
+	sut.oidcProvider.EXPECT().Exchange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, oidc.ErrInvalidIDToken).Times(1)
+	sut.accountRepo.EXPECT().GetByIdentity(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.CompleteSSOLogin(context.Background(), flow.flowToken, flow.state, "code", clientInfo)
+	assert.ErrorIs(t, err, authentication.ErrSSOFailed)
+}
+
+func TestSSOFlowTokenIsNotAnAccessToken(t *testing.T) {
+	sut := createSUT(t)
+	flow := beginSSOLogin(t, sut)
+
+	_, err := sut.service.GetAccountInfoFromAccessToken(context.Background(), flow.flowToken)
+	assert.ErrorIs(t, err, authentication.ErrWrongToken)
+}
+
+func TestPasswordLoginOfSSOOnlyAccount(t *testing.T) {
+	sut := createSUT(t)
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).
+		Return(&types.Account{ID: 1, EMail: "h.kalantari.1997@gmail.com"}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.Login(context.Background(), "h.kalantari.1997@gmail.com", "", clientInfo)
+	assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
+}
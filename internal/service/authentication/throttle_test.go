@@ -0,0 +1,140 @@
+package authentication_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	throttledEMail       = "h.kalantari.1997@gmail.com"
+	emailThrottleKey     = "email-" + throttledEMail
+	clientIPThrottleKey  = "ip-192.168.10.42"
+	twoFactorThrottleKey = "two-factor-1"
+)
+
+func expectNotBlocked(sut sut, keys ...string) {
+	for _, key := range keys {
+		sut.loginAttemptRepo.EXPECT().GetBlock(gomock.Any(), key).Return(types.LoginBlock{}, nil).Times(1)
+	}
+}
+
+func requireTooManyAttempts(t *testing.T, err error, retryAfter time.Duration) {
+	var tooManyAttempts *authentication.TooManyAttemptsError
+	require.True(t, errors.As(err, &tooManyAttempts))
+	assert.Equal(t, retryAfter, tooManyAttempts.RetryAfter)
+	assert.ErrorIs(t, err, authentication.ErrTooManyAttempts)
+	assert.NotErrorIs(t, err, authentication.ErrValidationFailed)
+}
+
+func TestLockedOutAccountIsNotChecked(t *testing.T) {
+	sut := createThrottledSUT(t)
+
+	sut.loginAttemptRepo.EXPECT().GetBlock(gomock.Any(), emailThrottleKey).Return(types.LoginBlock{
+		LockedOut: true, RetryAfter: time.Minute * 7,
+	}, nil).Times(1)
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).Times(0)
+
+	// addresses differing in case are the same account
+	_, err := sut.service.Login(context.Background(), "H.Kalantari.1997@gmail.com", "123456", clientInfo)
+	requireTooManyAttempts(t, err, time.Minute*7)
+	assert.ErrorIs(t, err, authentication.ErrAccountLocked)
+}
+
+func TestLockedOutClientIPIsNotALockedAccount(t *testing.T) {
+	sut := createThrottledSUT(t)
+
+	expectNotBlocked(sut, emailThrottleKey)
+	sut.loginAttemptRepo.EXPECT().GetBlock(gomock.Any(), clientIPThrottleKey).Return(types.LoginBlock{
+		LockedOut: true, RetryAfter: time.Second * 4,
+	}, nil).Times(1)
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.Login(context.Background(), throttledEMail, "123456", clientInfo)
+	requireTooManyAttempts(t, err, time.Second*4)
+	assert.NotErrorIs(t, err, authentication.ErrAccountLocked)
+}
+
+func TestWrongPasswordIsDelayedProgressively(t *testing.T) {
+	sut := createThrottledSUT(t)
+
+	expectNotBlocked(sut, emailThrottleKey, clientIPThrottleKey)
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), throttledEMail).Return(&types.Account{
+		ID: 1, PasswordHash: mustHashPassword(t, "123456"),
+	}, nil).Times(1)
+
+	var emailDelays, clientIPDelays []time.Duration
+	sut.loginAttemptRepo.EXPECT().AddFailure(gomock.Any(), emailThrottleKey, time.Hour, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, _ time.Duration, delays []time.Duration) (int64, error) {
+			emailDelays = delays
+			return 1, nil
+		},
+	).Times(1)
+	sut.loginAttemptRepo.EXPECT().AddFailure(gomock.Any(), clientIPThrottleKey, time.Hour, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, _ time.Duration, delays []time.Duration) (int64, error) {
+			clientIPDelays = delays
+			return 1, nil
+		},
+	).Times(1)
+
+	_, err := sut.service.Login(context.Background(), throttledEMail, "654321", clientInfo)
+	assert.ErrorIs(t, err, authentication.ErrWrongCredentials)
+
+	// the last delay is the lockout, reached on the tenth failure
+	require.Len(t, emailDelays, 10)
+	for failures, delay := range map[int]time.Duration{
+		3:  0,
+		4:  time.Second,
+		5:  time.Second * 2,
+		7:  time.Second * 8,
+		9:  time.Second * 32,
+		10: time.Minute * 15,
+	} {
+		assert.Equal(t, delay, emailDelays[failures-1], "delay after %d failures", failures)
+	}
+
+	// the client ip has more free attempts
+	require.Len(t, clientIPDelays, 100)
+	assert.Zero(t, clientIPDelays[19])
+	assert.Equal(t, time.Second, clientIPDelays[20])
+}
+
+func TestSuccessfulLoginOnlyResetsAccount(t *testing.T) {
+	sut := createThrottledSUT(t)
+
+	expectNotBlocked(sut, emailThrottleKey, clientIPThrottleKey)
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), throttledEMail).Return(&types.Account{
+		ID: 1, PasswordHash: mustHashPassword(t, "123456"),
+	}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 3}, nil).Times(1)
+
+	sut.loginAttemptRepo.EXPECT().ResetFailures(gomock.Any(), emailThrottleKey).Return(nil).Times(1)
+	sut.loginAttemptRepo.EXPECT().ResetFailures(gomock.Any(), clientIPThrottleKey).Times(0)
+
+	_, err := sut.service.Login(context.Background(), throttledEMail, "123456", clientInfo)
+	require.NoError(t, err)
+}
+
+func TestLockedOutTwoFactorCodeIsNotChecked(t *testing.T) {
+	sut := createThrottledSUT(t)
+
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).Return(&types.Account{
+		ID: 1, TwoFactorEnabled: true,
+	}, nil).Times(1)
+	sut.loginAttemptRepo.EXPECT().GetBlock(gomock.Any(), twoFactorThrottleKey).Return(types.LoginBlock{
+		LockedOut: true, RetryAfter: time.Minute,
+	}, nil).Times(1)
+	sut.twoFactorRepo.EXPECT().GetSecret(gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.DisableTwoFactor(context.Background(), 1, "123456")
+	requireTooManyAttempts(t, err, time.Minute)
+	assert.ErrorIs(t, err, authentication.ErrAccountLocked)
+}
@@ -0,0 +1,184 @@
+package authentication_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const twoFactorEMail = "h.kalantari.1997@gmail.com"
+
+var twoFactorSecret = []byte("12345678901234567890")
+
+// loginWithTwoFactor logs into an account with two-factor authentication and returns the challenge.
+func loginWithTwoFactor(t *testing.T, sut sut) string {
+	sut.accountRepo.EXPECT().GetByEMail(gomock.Any(), twoFactorEMail).Return(&types.Account{
+		ID: 1, EMail: twoFactorEMail, PasswordHash: mustHashPassword(t, "123456"), TwoFactorEnabled: true,
+	}, nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := sut.service.Login(context.Background(), twoFactorEMail, "123456", clientInfo)
+	require.NoError(t, err)
+	require.Nil(t, result.TokenPair, "no session should be opened before the second factor is checked")
+	require.NotEmpty(t, result.ChallengeToken)
+
+	return result.ChallengeToken
+}
+
+func expectTwoFactorAccount(sut sut) {
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).Return(&types.Account{
+		ID: 1, EMail: twoFactorEMail, TwoFactorEnabled: true,
+	}, nil).Times(1)
+	sut.twoFactorRepo.EXPECT().GetSecret(gomock.Any(), uint64(1)).
+		Return(&types.TwoFactorSecret{AccountID: 1, Secret: twoFactorSecret}, nil).Times(1)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	sut := createSUT(t)
+	challengeToken := loginWithTwoFactor(t, sut)
+
+	now := time.Now()
+	expectTwoFactorAccount(sut)
+	sut.twoFactorRepo.EXPECT().UseCounter(gomock.Any(), uint64(1), totp.Counter(now)).Return(nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), clientInfo).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 3}, nil).Times(1)
+
+	tokenPair, err := sut.service.CompleteTwoFactorLogin(
+		context.Background(), challengeToken, totp.Generate(twoFactorSecret, now), clientInfo,
+	)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokenPair.AccessToken)
+}
+
+func TestTwoFactorLoginWithReplayedCode(t *testing.T) {
+	sut := createSUT(t)
+	challengeToken := loginWithTwoFactor(t, sut)
+
+	expectTwoFactorAccount(sut)
+	sut.twoFactorRepo.EXPECT().UseCounter(gomock.Any(), uint64(1), gomock.Any()).
+		Return(repository.ErrNotFound).Times(1)
+
+	_, err := sut.service.CompleteTwoFactorLogin(
+		context.Background(), challengeToken, totp.Generate(twoFactorSecret, time.Now()), clientInfo,
+	)
+	assert.ErrorIs(t, err, authentication.ErrWrongTwoFactorCode)
+}
+
+func TestTwoFactorLoginWithRecoveryCode(t *testing.T) {
+	sut := createSUT(t)
+	challengeToken := loginWithTwoFactor(t, sut)
+
+	expectTwoFactorAccount(sut)
+	sut.twoFactorRepo.EXPECT().ConsumeRecoveryCode(gomock.Any(), uint64(1), hashToken("abcdefghijklmnop")).
+		Return(nil).Times(1)
+	sut.refreshTokenRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), clientInfo).
+		Return(&types.RefreshToken{ID: 7, AccountID: 1, Family: 3}, nil).Times(1)
+
+	_, err := sut.service.CompleteTwoFactorLogin(context.Background(), challengeToken, "ABCDEFGH-ijklmnop", clientInfo)
+	require.NoError(t, err)
+}
+
+func TestTwoFactorLoginWithWrongCode(t *testing.T) {
+	sut := createSUT(t)
+	challengeToken := loginWithTwoFactor(t, sut)
+
+	expectTwoFactorAccount(sut)
+	sut.twoFactorRepo.EXPECT().ConsumeRecoveryCode(gomock.Any(), uint64(1), gomock.Any()).
+		Return(repository.ErrNotFound).Times(1)
+
+	_, err := sut.service.CompleteTwoFactorLogin(context.Background(), challengeToken, "000000", clientInfo)
+	assert.ErrorIs(t, err, authentication.ErrWrongTwoFactorCode)
+}
+
+func TestChallengeIsNotAnAccessToken(t *testing.T) {
+	sut := createSUT(t)
+	challengeToken := loginWithTwoFactor(t, sut)
+
+	_, err := sut.service.GetAccountInfoFromAccessToken(context.Background(), challengeToken)
+	assert.ErrorIs(t, err, authentication.ErrWrongToken)
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).
+		Return(&types.Account{ID: 1, EMail: twoFactorEMail}, nil).Times(2)
+
+	var saved []byte
+	sut.twoFactorRepo.EXPECT().SaveSecret(gomock.Any(), uint64(1), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ uint64, secret []byte) error {
+			saved = secret
+			return nil
+		},
+	).Times(1)
+
+	secret, provisioningURI, err := sut.service.BeginTwoFactorEnrollment(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, totp.EncodeSecret(saved), secret)
+
+	parsed, err := url.Parse(provisioningURI)
+	require.NoError(t, err)
+	assert.Equal(t, secret, parsed.Query().Get("secret"))
+	assert.Equal(t, twoFactorIssuer, parsed.Query().Get("issuer"))
+
+	sut.twoFactorRepo.EXPECT().GetSecret(gomock.Any(), uint64(1)).
+		Return(&types.TwoFactorSecret{AccountID: 1, Secret: saved}, nil).Times(1)
+	sut.twoFactorRepo.EXPECT().UseCounter(gomock.Any(), uint64(1), gomock.Any()).Return(nil).Times(1)
+
+	var savedHashes []string
+	sut.twoFactorRepo.EXPECT().Enable(gomock.Any(), uint64(1), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ uint64, hashes []string) error {
+			savedHashes = hashes
+			return nil
+		},
+	).Times(1)
+
+	recoveryCodes, err := sut.service.ConfirmTwoFactorEnrollment(context.Background(), 1, totp.Generate(saved, time.Now()))
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, types.RecoveryCodeCount)
+	assert.Len(t, savedHashes, types.RecoveryCodeCount)
+	assert.NotContains(t, savedHashes, recoveryCodes[0], "only hashes of recovery codes should be stored")
+}
+
+func TestTwoFactorEnrollmentIsNotConfirmedWithRecoveryCode(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).Return(&types.Account{ID: 1}, nil).Times(1)
+	sut.twoFactorRepo.EXPECT().GetSecret(gomock.Any(), uint64(1)).
+		Return(&types.TwoFactorSecret{AccountID: 1, Secret: twoFactorSecret}, nil).Times(1)
+	sut.twoFactorRepo.EXPECT().ConsumeRecoveryCode(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.twoFactorRepo.EXPECT().Enable(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.ConfirmTwoFactorEnrollment(context.Background(), 1, "abcdefgh-ijklmnop")
+	assert.ErrorIs(t, err, authentication.ErrWrongTwoFactorCode)
+}
+
+func TestTwoFactorEnrollmentWhenAlreadyEnabled(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).
+		Return(&types.Account{ID: 1, TwoFactorEnabled: true}, nil).Times(1)
+	sut.twoFactorRepo.EXPECT().SaveSecret(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, _, err := sut.service.BeginTwoFactorEnrollment(context.Background(), 1)
+	assert.ErrorIs(t, err, authentication.ErrTwoFactorAlreadyEnabled)
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	sut := createSUT(t)
+
+	expectTwoFactorAccount(sut)
+	sut.twoFactorRepo.EXPECT().UseCounter(gomock.Any(), uint64(1), gomock.Any()).Return(nil).Times(1)
+	sut.twoFactorRepo.EXPECT().Disable(gomock.Any(), uint64(1)).Return(nil).Times(1)
+
+	require.NoError(t, sut.service.DisableTwoFactor(context.Background(), 1, totp.Generate(twoFactorSecret, time.Now())))
+}
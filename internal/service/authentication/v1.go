@@ -2,81 +2,157 @@ package authentication
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"github.com/h3isenbug/url-shortener/internal/repository"
 	"github.com/h3isenbug/url-shortener/internal/repository/account"
+	accountTokenRepository "github.com/h3isenbug/url-shortener/internal/repository/accountToken"
+	apiKeyRepository "github.com/h3isenbug/url-shortener/internal/repository/apiKey"
+	loginAttemptRepository "github.com/h3isenbug/url-shortener/internal/repository/loginAttempt"
 	refreshTokenRepository "github.com/h3isenbug/url-shortener/internal/repository/refreshToken"
+	revocationRepository "github.com/h3isenbug/url-shortener/internal/repository/revocation"
+	twoFactorRepository "github.com/h3isenbug/url-shortener/internal/repository/twoFactor"
+	urlRepository "github.com/h3isenbug/url-shortener/internal/repository/url"
 	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/breach"
+	"github.com/h3isenbug/url-shortener/pkg/jwk"
 	"github.com/h3isenbug/url-shortener/pkg/log"
+	"github.com/h3isenbug/url-shortener/pkg/mail"
+	"github.com/h3isenbug/url-shortener/pkg/oidc"
+	"github.com/h3isenbug/url-shortener/pkg/totp"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	jwtSubject = "authentication"
+	// challenges are signed like access tokens, their subject keeps one from being used as the other.
+	jwtSubjectTwoFactorChallenge = "two-factor-challenge"
+	jwtSubjectSSOFlow            = "sso-flow"
 )
 
 const refreshTokenFamilyLength = 10
 
+// accountTokenLength is the number of random bytes in email verification and password reset tokens.
+const accountTokenLength = 32
+
+// apiKeyLength is the number of random bytes in api keys. apiKeyDisplayLength characters of a key are kept in clear.
+const (
+	apiKeyLength        = 32
+	apiKeyDisplayLength = 12
+)
+
+// twoFactorSkew is the number of periods a TOTP code is accepted for before and after its own, to tolerate clock drift.
+const twoFactorSkew = 1
+
+// recoveryCodeLength is the number of random bytes in each half of a recovery code.
+const recoveryCodeLength = 5
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
 type jwtClaims struct {
 	jwt.RegisteredClaims
 
 	RefreshTokenID uint64 `json:"refreshTokenID,omitempty"`
+	Family         uint64 `json:"family,omitempty"`
 	AccountID      uint64 `json:"accountID"`
+	// Scopes are fixed when the session is opened and are carried over when tokens are renewed.
+	Scopes types.Scopes `json:"scopes,omitempty"`
+
+	// Nonce and CodeVerifier are only set in single sign-on flow tokens, whose ID is the state of the flow.
+	Nonce        string `json:"nonce,omitempty"`
+	CodeVerifier string `json:"codeVerifier,omitempty"`
+}
+
+// scopes of access tokens issued before scopes existed are missing, those were granted what every account is.
+func (c jwtClaims) scopes() types.Scopes {
+	if c.Scopes == nil {
+		return types.DefaultScopes
+	}
+
+	return c.Scopes
 }
 
 type v1 struct {
 	accountRepository      account.Repository
 	refreshTokenRepository refreshTokenRepository.Repository
+	accountTokenRepository accountTokenRepository.Repository
+	revocationRepository   revocationRepository.Repository
+	apiKeyRepository       apiKeyRepository.Repository
+	twoFactorRepository    twoFactorRepository.Repository
+	loginAttemptRepository loginAttemptRepository.Repository
+	urlRepository          urlRepository.Repository
+	mailer                 mail.Mailer
 	logger                 log.Logger
 
-	refreshTokenLength int
+	config Config
+	// refreshTokenHashKey is kept out of the database, so a copy of refresh_tokens is of no use on its own.
+	refreshTokenHashKey []byte
 
-	refreshTokenLifespan time.Duration
-	accessTokenLifespan  time.Duration
+	// accessTokenKeyring is read on every use, the keys are swapped while tokens are being signed and verified.
+	accessTokenKeyring jwk.Keyring
 
-	accessTokenSecrets    map[string][]byte
-	accessTokenCurrentKID string
+	// oidcProvider is nil when single sign-on is disabled.
+	oidcProvider oidc.Provider
+
+	// passwordBlocklist holds the blocklist of the policy in lower case.
+	passwordBlocklist map[string]struct{}
+	breachedPasswords breach.Source
 }
 
 func NewAuthenticationServiceV1(
 	logger log.Logger,
 	accountRepository account.Repository,
 	refreshTokenRepository refreshTokenRepository.Repository,
-	refreshTokenLength int,
-
-	refreshTokenLifespan time.Duration,
-	accessTokenLifespan time.Duration,
-
-	accessTokenSecrets map[string][]byte,
-	accessTokenCurrentKID string,
+	accountTokenRepository accountTokenRepository.Repository,
+	revocationRepository revocationRepository.Repository,
+	apiKeyRepository apiKeyRepository.Repository,
+	twoFactorRepository twoFactorRepository.Repository,
+	loginAttemptRepository loginAttemptRepository.Repository,
+	urlRepository urlRepository.Repository,
+	mailer mail.Mailer,
+	refreshTokenHashKey []byte,
+	accessTokenKeyring jwk.Keyring,
+	oidcProvider oidc.Provider,
+	breachedPasswords breach.Source,
+	config Config,
 ) Service {
-	service := &v1{
+	passwordBlocklist := make(map[string]struct{}, len(config.PasswordPolicy.Blocklist))
+	for _, password := range config.PasswordPolicy.Blocklist {
+		passwordBlocklist[strings.ToLower(password)] = struct{}{}
+	}
+
+	return &v1{
 		accountRepository:      accountRepository,
 		refreshTokenRepository: refreshTokenRepository,
+		accountTokenRepository: accountTokenRepository,
+		revocationRepository:   revocationRepository,
+		apiKeyRepository:       apiKeyRepository,
+		twoFactorRepository:    twoFactorRepository,
+		loginAttemptRepository: loginAttemptRepository,
+		urlRepository:          urlRepository,
+		mailer:                 mailer,
 		logger:                 logger,
-		refreshTokenLength:     refreshTokenLength,
-		refreshTokenLifespan:   refreshTokenLifespan,
-		accessTokenLifespan:    accessTokenLifespan,
-		accessTokenSecrets:     accessTokenSecrets,
-		accessTokenCurrentKID:  accessTokenCurrentKID,
-	}
-	if _, ok := accessTokenSecrets[accessTokenCurrentKID]; !ok {
-		errorMessage := fmt.Sprintf(
-			"auth token secrets are not configured correctly."+
-				"no auth token secret was found with KID %s",
-			accessTokenCurrentKID,
-		)
-		logger.Panic(errorMessage)
-	}
 
-	return service
+		config:              config,
+		refreshTokenHashKey: refreshTokenHashKey,
+		accessTokenKeyring:  accessTokenKeyring,
+		oidcProvider:        oidcProvider,
+
+		passwordBlocklist: passwordBlocklist,
+		breachedPasswords: breachedPasswords,
+	}
 }
 
 func (s v1) getRandomEncodedBytes(count int) string {
@@ -90,7 +166,32 @@ func (s v1) getRandomEncodedBytes(count int) string {
 	return base64.RawURLEncoding.EncodeToString(bytes)
 }
 
-func (s v1) Login(ctx context.Context, email, password string) (*types.TokenPair, error) {
+func (s v1) Login(ctx context.Context, email, password string, clientInfo types.ClientInfo) (*types.LoginResult, error) {
+	// unknown email addresses are throttled as well, so that lockouts do not tell who has an account
+	subjects := []loginThrottleSubject{
+		s.emailThrottleSubject(email),
+		{key: "ip-" + clientInfo.ClientIP, policy: s.config.ClientIPLoginThrottle, lockoutErr: ErrTooManyAttempts},
+	}
+	if err := s.checkLoginThrottle(ctx, subjects...); err != nil {
+		return nil, err
+	}
+
+	acct, err := s.checkPassword(ctx, email, password)
+	if errors.Is(err, ErrWrongCredentials) {
+		s.recordLoginFailure(ctx, subjects...)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the client ip is not reset, one valid account must not clear the failures of every other one tried from it
+	s.resetLoginFailures(ctx, subjects[0])
+
+	return s.loginAs(ctx, acct, clientInfo)
+}
+
+func (s v1) checkPassword(ctx context.Context, email, password string) (*types.Account, error) {
 	acct, err := s.accountRepository.GetByEMail(ctx, email)
 	if errors.Is(err, repository.ErrNotFound) {
 		return nil, ErrWrongCredentials
@@ -99,29 +200,177 @@ func (s v1) Login(ctx context.Context, email, password string) (*types.TokenPair
 		return nil, fmt.Errorf("failed to get account by email: %w", err)
 	}
 
+	// accounts created through single sign-on have no password
+	if acct.PasswordHash == "" {
+		return nil, ErrWrongCredentials
+	}
 	if err := bcrypt.CompareHashAndPassword([]byte(acct.PasswordHash), []byte(password)); err != nil {
 		return nil, ErrWrongCredentials
 	}
 
-	return s.generateTokenPair(ctx, acct.ID, nil)
+	return acct, nil
+}
+
+type loginThrottleSubject struct {
+	key    string
+	policy types.LoginThrottlePolicy
+	// lockoutErr is what a locked out subject is refused with.
+	lockoutErr error
+}
+
+func (s v1) emailThrottleSubject(email string) loginThrottleSubject {
+	return loginThrottleSubject{
+		key: "email-" + strings.ToLower(email), policy: s.config.AccountLoginThrottle, lockoutErr: ErrAccountLocked,
+	}
+}
+
+func twoFactorThrottleSubject(accountID uint64, policy types.LoginThrottlePolicy) loginThrottleSubject {
+	return loginThrottleSubject{
+		key: fmt.Sprintf("two-factor-%d", accountID), policy: policy, lockoutErr: ErrAccountLocked,
+	}
+}
+
+// checkLoginThrottle refuses the attempt if any of the subjects is blocked, before its credentials cost anything.
+func (s v1) checkLoginThrottle(ctx context.Context, subjects ...loginThrottleSubject) error {
+	for _, subject := range subjects {
+		block, err := s.loginAttemptRepository.GetBlock(ctx, subject.key)
+		if err != nil {
+			return fmt.Errorf("failed to check failed login attempts: %w", err)
+		}
+		if block.RetryAfter <= 0 {
+			continue
+		}
+
+		if block.LockedOut {
+			return &TooManyAttemptsError{Err: subject.lockoutErr, RetryAfter: block.RetryAfter}
+		}
+
+		return &TooManyAttemptsError{Err: ErrTooManyAttempts, RetryAfter: block.RetryAfter}
+	}
+
+	return nil
+}
+
+// recordLoginFailure blocks the subjects for a delay that doubles with every failure, until they are locked out. the
+// block is decided by the repository along with the count, so concurrent failures never leave a shorter block behind.
+// it only logs its errors, the attempt has failed either way.
+func (s v1) recordLoginFailure(ctx context.Context, subjects ...loginThrottleSubject) {
+	for _, subject := range subjects {
+		failures, err := s.loginAttemptRepository.AddFailure(
+			ctx, subject.key, subject.policy.FailureWindow, subject.policy.Delays(),
+		)
+		if err != nil {
+			s.logger.Error("failed to record failed login attempt", map[string]interface{}{
+				"subject":      subject.key,
+				"errorMessage": err.Error(),
+			})
+			continue
+		}
+
+		if failures >= int64(subject.policy.LockoutThreshold) {
+			s.logger.Warn("locked out after too many failed login attempts", map[string]interface{}{
+				"subject":         subject.key,
+				"failures":        failures,
+				"lockoutDuration": subject.policy.LockoutDuration.String(),
+			})
+		}
+	}
+}
+
+func (s v1) resetLoginFailures(ctx context.Context, subjects ...loginThrottleSubject) {
+	for _, subject := range subjects {
+		if err := s.loginAttemptRepository.ResetFailures(ctx, subject.key); err != nil {
+			s.logger.Error("failed to reset failed login attempts", map[string]interface{}{
+				"subject":      subject.key,
+				"errorMessage": err.Error(),
+			})
+		}
+	}
+}
+
+// loginAs opens a session for an authenticated account, unless it still has to pass two-factor authentication.
+func (s v1) loginAs(ctx context.Context, acct *types.Account, clientInfo types.ClientInfo) (*types.LoginResult, error) {
+	if acct.TwoFactorEnabled {
+		challengeToken, err := s.generateTwoFactorChallenge(acct.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &types.LoginResult{ChallengeToken: challengeToken}, nil
+	}
+
+	tokenPair, err := s.openSession(ctx, acct, clientInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.LoginResult{TokenPair: tokenPair}, nil
+}
+
+func (s v1) openSession(ctx context.Context, acct *types.Account, clientInfo types.ClientInfo) (*types.TokenPair, error) {
+	if len(clientInfo.UserAgent) > types.MaxClientUserAgentLength {
+		clientInfo.UserAgent = clientInfo.UserAgent[:types.MaxClientUserAgentLength]
+	}
+
+	return s.generateTokenPair(ctx, acct.ID, nil, clientInfo, acct.Scopes())
+}
+
+func (s v1) generateTwoFactorChallenge(accountID uint64) (string, error) {
+	now := jwt.NewNumericDate(time.Now().UTC())
+
+	return s.signToken(&jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    types.ServiceName,
+			Subject:   jwtSubjectTwoFactorChallenge,
+			Audience:  []string{types.ServiceName},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.TwoFactorChallengeLifespan)),
+			NotBefore: now,
+			IssuedAt:  now,
+			ID:        uuid.New().String(),
+		},
+		AccountID: accountID,
+	})
+}
+
+func (s v1) CompleteTwoFactorLogin(
+	ctx context.Context, challengeToken, code string, clientInfo types.ClientInfo,
+) (*types.TokenPair, error) {
+	claims, err := s.parseToken(challengeToken, jwtSubjectTwoFactorChallenge, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse two-factor challenge: %w", err)
+	}
+
+	acct, err := s.accountRepository.Get(ctx, claims.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if err := s.checkTwoFactorCode(ctx, acct.ID, code, true); err != nil {
+		return nil, err
+	}
+
+	return s.openSession(ctx, acct, clientInfo)
 }
 
-func (s v1) generateTokenPair(ctx context.Context, accountID uint64, family *uint64) (*types.TokenPair, error) {
-	refreshTokenText := s.getRandomEncodedBytes(s.refreshTokenLength)
+// generateTokenPair opens a new session for clientInfo when family is nil, otherwise it continues the given one.
+func (s v1) generateTokenPair(
+	ctx context.Context, accountID uint64, family *uint64, clientInfo types.ClientInfo, scopes types.Scopes,
+) (*types.TokenPair, error) {
+	refreshTokenText := s.getRandomEncodedBytes(s.config.RefreshTokenLength)
+	refreshTokenHash := s.hashRefreshToken(refreshTokenText)
 	var refreshToken *types.RefreshToken
 	var err error
 	if family == nil {
 		refreshToken, err = s.refreshTokenRepository.Create(
-			ctx, accountID, refreshTokenText, s.refreshTokenLifespan)
+			ctx, accountID, refreshTokenHash, s.config.RefreshTokenLifespan, clientInfo)
 	} else {
 		refreshToken, err = s.refreshTokenRepository.CreateWithFamily(
-			ctx, accountID, refreshTokenText, s.refreshTokenLifespan, *family)
+			ctx, accountID, refreshTokenHash, s.config.RefreshTokenLifespan, *family)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to save refresh token: %w", err)
 	}
 
-	accessTokenText, err := s.generateAccessTokenForRefreshToken(accountID, refreshToken.ID)
+	accessTokenText, err := s.generateAccessTokenForRefreshToken(refreshToken, scopes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate auth token from refresh token(%d): %w", refreshToken.ID, err)
 	}
@@ -133,25 +382,33 @@ func (s v1) generateTokenPair(ctx context.Context, accountID uint64, family *uin
 
 }
 
-func (s v1) generateAccessTokenForRefreshToken(accountID, refreshTokenID uint64) (string, error) {
+func (s v1) generateAccessTokenForRefreshToken(refreshToken *types.RefreshToken, scopes types.Scopes) (string, error) {
 	now := jwt.NewNumericDate(time.Now().UTC())
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, &jwtClaims{
+
+	return s.signToken(&jwtClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    types.ServiceName,
 			Subject:   jwtSubject,
 			Audience:  []string{types.ServiceName},
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTokenLifespan)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessTokenLifespan)),
 			NotBefore: now,
 			IssuedAt:  now,
 			ID:        uuid.New().String(),
 		},
-		RefreshTokenID: refreshTokenID,
-		AccountID:      accountID,
+		RefreshTokenID: refreshToken.ID,
+		Family:         refreshToken.Family,
+		AccountID:      refreshToken.AccountID,
+		Scopes:         scopes,
 	})
+}
+
+func (s v1) signToken(claims *jwtClaims) (string, error) {
+	key := s.accessTokenKeyring.Current()
 
-	token.Header["kid"] = s.accessTokenCurrentKID
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
 
-	signed, err := token.SignedString(s.accessTokenSecrets[s.accessTokenCurrentKID])
+	signed, err := token.SignedString(key.SigningKey())
 	if err != nil {
 		s.logger.Panic("failed to generate signed jwt key", map[string]interface{}{"errorMessage": err.Error()})
 		return "", fmt.Errorf("failed to generate signed jwt key: %w", err)
@@ -161,18 +418,18 @@ func (s v1) generateAccessTokenForRefreshToken(accountID, refreshTokenID uint64)
 }
 
 func (s v1) parseAccessToken(tokenString string, skipClaimValidation bool) (*jwtClaims, error) {
+	return s.parseToken(tokenString, jwtSubject, skipClaimValidation)
+}
+
+func (s v1) parseToken(tokenString, subject string, skipClaimValidation bool) (*jwtClaims, error) {
 	var claims jwtClaims
 
 	parser := &jwt.Parser{
-		ValidMethods:         []string{jwt.SigningMethodHS512.Alg()},
+		ValidMethods:         jwk.Algorithms,
 		SkipClaimsValidation: skipClaimValidation,
 	}
 
 	token, err := parser.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
-		if token.Method != jwt.SigningMethodHS512 {
-			return nil, fmt.Errorf("expected signing method to be HS512, got %s", token.Method.Alg())
-		}
-
 		rawKID, ok := token.Header["kid"]
 		if !ok {
 			return nil, errors.New("no kid header was found in jwt")
@@ -183,14 +440,24 @@ func (s v1) parseAccessToken(tokenString string, skipClaimValidation bool) (*jwt
 			return nil, fmt.Errorf("expected KID header of jwt to be string, got %T", rawKID)
 		}
 
-		key, ok := s.accessTokenSecrets[kid]
+		key, ok := s.accessTokenKeyring.Get(kid)
 		if !ok {
 			return nil, fmt.Errorf("corresponding key for kid %s was not found", kid)
 		}
+		// a token signed with an rsa key must not be checked as hmac with the public key as secret
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf(
+				"expected signing method of kid %s to be %s, got %s", kid, key.Method.Alg(), token.Method.Alg(),
+			)
+		}
 
-		return key, nil
+		return key.VerificationKey(), nil
 	})
 	if token != nil && token.Valid {
+		if claims.Subject != subject {
+			return nil, fmt.Errorf("%w: expected subject %s, got %s", ErrWrongToken, subject, claims.Subject)
+		}
+
 		return &claims, nil
 	}
 
@@ -220,7 +487,10 @@ func (s v1) RenewTokens(ctx context.Context, oldAccessTokenString, refreshTokenS
 		return nil, fmt.Errorf("failed to parse old auth token: %w", err)
 	}
 
-	refreshToken, err := s.refreshTokenRepository.Get(ctx, refreshTokenString)
+	refreshToken, err := s.refreshTokenRepository.GetByHash(ctx, s.hashRefreshToken(refreshTokenString))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown refresh token", ErrWrongCredentials)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch refresh token: %w", err)
 	}
@@ -237,6 +507,10 @@ func (s v1) RenewTokens(ctx context.Context, oldAccessTokenString, refreshTokenS
 		return nil, fmt.Errorf("%w: compromised refresh token", ErrValidationFailed)
 	}
 
+	if refreshToken.Revoked {
+		return nil, fmt.Errorf("%w: refresh token is revoked", ErrWrongCredentials)
+	}
+
 	if refreshToken.Disabled {
 		s.logger.Warn("a refresh token is getting used more than once. flagging refresh token as compromised", map[string]interface{}{
 			"refreshTokenID": refreshToken.ID,
@@ -248,10 +522,27 @@ func (s v1) RenewTokens(ctx context.Context, oldAccessTokenString, refreshTokenS
 				"errorMessage": err.Error(),
 			})
 		}
+		if err := s.revocationRepository.RevokeSession(ctx, refreshToken.Family, s.config.AccessTokenLifespan); err != nil {
+			s.logger.Error("failed to revoke access tokens of compromised refresh token family", map[string]interface{}{
+				"family":       refreshToken.Family,
+				"errorMessage": err.Error(),
+			})
+		}
 		return nil, fmt.Errorf("%w: attempted to reuse refresh token", ErrWrongCredentials)
 	}
 
-	tokenPair, err := s.generateTokenPair(ctx, refreshToken.AccountID, &refreshToken.Family)
+	// scopes follow the account, e.g. an administrator that is demoted loses them on the next renewal
+	acct, err := s.accountRepository.Get(ctx, refreshToken.AccountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: account does not exist", ErrWrongCredentials)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	tokenPair, err := s.generateTokenPair(
+		ctx, refreshToken.AccountID, &refreshToken.Family, types.ClientInfo{}, acct.Scopes(),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate a new token pair: %w", err)
 	}
@@ -262,12 +553,20 @@ func (s v1) RenewTokens(ctx context.Context, oldAccessTokenString, refreshTokenS
 	return tokenPair, nil
 }
 func (s v1) Register(ctx context.Context, email, password string) error {
+	passwordError, err := s.validatePassword(ctx, "password", password, email)
+	if err != nil {
+		return err
+	}
+	if err := fieldValidationError(validateEMail("email", email), passwordError); err != nil {
+		return err
+	}
+
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	err = s.accountRepository.Create(ctx, email, string(passwordHash))
+	acct, err := s.accountRepository.Create(ctx, email, string(passwordHash))
 	if errors.Is(err, repository.ErrUniquenessViolated) {
 		return ErrEMailAlreadyUsed
 	}
@@ -275,19 +574,796 @@ func (s v1) Register(ctx context.Context, email, password string) error {
 		return fmt.Errorf("failed to save new account: %w", err)
 	}
 
-	//TODO: stuff like sending verification email, sms, etc would be done here,
-	// but authentication part of this project is just a MVP
+	// the account is already created, so a failed delivery should not fail the registration. the verification
+	// email can be sent again later.
+	if err := s.sendVerificationEMail(ctx, acct); err != nil {
+		s.logger.Error("failed to send verification email", map[string]interface{}{
+			"accountID":    acct.ID,
+			"errorMessage": err.Error(),
+		})
+	}
 
 	return nil
 }
 
-func (s v1) GetAccountInfoFromAccessToken(ctx context.Context, accessToken string) (*types.AccountInfo, error) {
-	claims, err := s.parseAccessToken(accessToken, false)
+func (s v1) hashRefreshToken(token string) string {
+	mac := hmac.New(sha256.New, s.refreshTokenHashKey)
+	mac.Write([]byte(token))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func hashAccountToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// issueAccountToken saves a new single-use token for the account and returns it. only its hash is kept.
+func (s v1) issueAccountToken(
+	ctx context.Context, accountID uint64, purpose types.AccountTokenPurpose, lifespan time.Duration,
+) (string, error) {
+	token := s.getRandomEncodedBytes(accountTokenLength)
+
+	if err := s.accountTokenRepository.Create(ctx, accountID, purpose, hashAccountToken(token), lifespan); err != nil {
+		return "", fmt.Errorf("failed to save %s token: %w", purpose, err)
+	}
+
+	return token, nil
+}
+
+func (s v1) sendVerificationEMail(ctx context.Context, acct *types.Account) error {
+	verificationToken, err := s.issueAccountToken(
+		ctx, acct.ID, types.AccountTokenPurposeEMailVerification, s.config.VerificationTokenLifespan,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get account info from auth token: %w", err)
+		return err
 	}
 
-	// if access to other info about account is needed, it should be added here. do this IF it is really necessary.
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      acct.EMail,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Open the link below to verify your email address:\n\n%s?token=%s\n",
+			s.config.VerificationURL, verificationToken,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to deliver verification email: %w", err)
+	}
+
+	return nil
+}
+
+func (s v1) VerifyEMail(ctx context.Context, verificationToken string) error {
+	accountID, err := s.accountTokenRepository.Consume(
+		ctx, types.AccountTokenPurposeEMailVerification, hashAccountToken(verificationToken),
+	)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to consume verification token: %w", err)
+	}
+
+	if err := s.accountRepository.SetEMailVerified(ctx, accountID); err != nil {
+		return fmt.Errorf("failed to mark email of account(%d) as verified: %w", accountID, err)
+	}
+
+	return nil
+}
+
+func (s v1) ResendVerificationEMail(ctx context.Context, accountID uint64) error {
+	acct, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if acct.EMailVerified {
+		return ErrEMailAlreadyVerified
+	}
+
+	// only the latest verification email is usable
+	if err := s.accountTokenRepository.RevokeAll(ctx, acct.ID, types.AccountTokenPurposeEMailVerification); err != nil {
+		return fmt.Errorf("failed to revoke previous verification tokens: %w", err)
+	}
+
+	return s.sendVerificationEMail(ctx, acct)
+}
+
+func (s v1) RequestPasswordReset(ctx context.Context, email string) error {
+	acct, err := s.accountRepository.GetByEMail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get account by email: %w", err)
+	}
+
+	// only the latest reset email is usable
+	if err := s.accountTokenRepository.RevokeAll(ctx, acct.ID, types.AccountTokenPurposePasswordReset); err != nil {
+		return fmt.Errorf("failed to revoke previous password reset tokens: %w", err)
+	}
+
+	resetToken, err := s.issueAccountToken(ctx, acct.ID, types.AccountTokenPurposePasswordReset, s.config.PasswordResetTokenLifespan)
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      acct.EMail,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Open the link below to choose a new password. if you did not ask for this, ignore this email.\n\n%s?token=%s\n",
+			s.config.PasswordResetURL, resetToken,
+		),
+	})
+	if err != nil {
+		// failing the request would tell the caller that the account exists
+		s.logger.Error("failed to send password reset email", map[string]interface{}{
+			"accountID":    acct.ID,
+			"errorMessage": err.Error(),
+		})
+	}
+
+	return nil
+}
+
+func (s v1) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	// the token is only used up by an acceptable password
+	passwordError, err := s.validatePassword(ctx, "password", newPassword, "")
+	if err != nil {
+		return err
+	}
+	if err := fieldValidationError(passwordError); err != nil {
+		return err
+	}
+
+	accountID, err := s.accountTokenRepository.Consume(
+		ctx, types.AccountTokenPurposePasswordReset, hashAccountToken(resetToken),
+	)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to consume password reset token: %w", err)
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.accountRepository.SetPasswordHash(ctx, accountID, string(passwordHash)); err != nil {
+		return fmt.Errorf("failed to update password of account(%d): %w", accountID, err)
+	}
+
+	// sessions opened with the old password may belong to whoever made the reset necessary
+	return s.RevokeAllSessions(ctx, accountID)
+}
+
+// checkCurrentPassword re-authenticates the holder of a session before the account is changed. wrong passwords
+// count towards the lockout of the account, a stolen session is no better at guessing than a login form.
+func (s v1) checkCurrentPassword(ctx context.Context, acct *types.Account, password string) error {
+	if acct.PasswordHash == "" {
+		return ErrPasswordRequired
+	}
+
+	subject := s.emailThrottleSubject(acct.EMail)
+	if err := s.checkLoginThrottle(ctx, subject); err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(acct.PasswordHash), []byte(password)); err != nil {
+		s.recordLoginFailure(ctx, subject)
+		return ErrWrongCredentials
+	}
+
+	s.resetLoginFailures(ctx, subject)
+
+	return nil
+}
+
+// sendAccountNotice tells the owner of an address about a change they may not have made. it only logs its errors,
+// the change is done already.
+func (s v1) sendAccountNotice(ctx context.Context, acct *types.Account, subject, body string) {
+	if err := s.mailer.Send(ctx, mail.Message{To: acct.EMail, Subject: subject, Body: body}); err != nil {
+		s.logger.Error("failed to send account notice", map[string]interface{}{
+			"accountID":    acct.ID,
+			"subject":      subject,
+			"errorMessage": err.Error(),
+		})
+	}
+}
+
+func (s v1) ChangePassword(
+	ctx context.Context, accountID uint64, currentPassword, newPassword string, clientInfo types.ClientInfo,
+) (*types.TokenPair, error) {
+	acct, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	passwordError, err := s.validatePassword(ctx, "newPassword", newPassword, acct.EMail)
+	if err != nil {
+		return nil, err
+	}
+	if err := fieldValidationError(passwordError); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCurrentPassword(ctx, acct, currentPassword); err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.accountRepository.SetPasswordHash(ctx, accountID, string(passwordHash)); err != nil {
+		return nil, fmt.Errorf("failed to update password of account(%d): %w", accountID, err)
+	}
+
+	// the password may be changed because it leaked, so sessions opened with it are ended as well
+	if err := s.RevokeAllSessions(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	s.sendAccountNotice(
+		ctx, acct, "Your password was changed",
+		"The password of your account was just changed. if you did not do this, reset your password right away.\n",
+	)
+
+	return s.openSession(ctx, acct, clientInfo)
+}
+
+func (s v1) RequestEMailChange(ctx context.Context, accountID uint64, currentPassword, newEMail string) error {
+	if err := fieldValidationError(validateEMail("email", newEMail)); err != nil {
+		return err
+	}
+
+	acct, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	if err := s.checkCurrentPassword(ctx, acct, currentPassword); err != nil {
+		return err
+	}
+
+	_, err = s.accountRepository.GetByEMail(ctx, newEMail)
+	if err == nil {
+		return ErrEMailAlreadyUsed
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to get account by email: %w", err)
+	}
+
+	if err := s.accountRepository.SetPendingEMail(ctx, accountID, newEMail); err != nil {
+		return fmt.Errorf("failed to save pending email of account(%d): %w", accountID, err)
+	}
+
+	// only the link to the latest address is usable
+	if err := s.accountTokenRepository.RevokeAll(ctx, accountID, types.AccountTokenPurposeEMailChange); err != nil {
+		return fmt.Errorf("failed to revoke previous email change tokens: %w", err)
+	}
+
+	changeToken, err := s.issueAccountToken(ctx, accountID, types.AccountTokenPurposeEMailChange, s.config.VerificationTokenLifespan)
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      newEMail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"Open the link below to use this address for your account:\n\n%s?token=%s\n",
+			s.config.EMailChangeURL, changeToken,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to deliver email change confirmation: %w", err)
+	}
+
+	s.sendAccountNotice(
+		ctx, acct, "Your email address is being changed",
+		fmt.Sprintf(
+			"A change of the email address of your account to %s was requested. if you did not do this, reset your "+
+				"password right away.\n",
+			newEMail,
+		),
+	)
+
+	return nil
+}
+
+func (s v1) ConfirmEMailChange(ctx context.Context, token string) error {
+	accountID, err := s.accountTokenRepository.Consume(ctx, types.AccountTokenPurposeEMailChange, hashAccountToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidEMailChangeToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to consume email change token: %w", err)
+	}
+
+	_, err = s.accountRepository.ApplyPendingEMail(ctx, accountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidEMailChangeToken
+	}
+	// the address may have been registered since the change was requested
+	if errors.Is(err, repository.ErrUniquenessViolated) {
+		return ErrEMailAlreadyUsed
+	}
+	if err != nil {
+		return fmt.Errorf("failed to change email of account(%d): %w", accountID, err)
+	}
+
+	return nil
+}
+
+func (s v1) DeleteAccount(ctx context.Context, accountID uint64, currentPassword string) error {
+	acct, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	if err := s.checkCurrentPassword(ctx, acct, currentPassword); err != nil {
+		return err
+	}
+	if s.config.AccountDeletionPolicy == types.AccountDeletionPolicyTransfer && accountID == s.config.AccountDeletionTransferTo {
+		return ErrAccountNotDeletable
+	}
+
+	// access tokens are revoked first, so that none is left working for an account that is gone. a deletion that
+	// fails after this changes nothing else and can be retried.
+	err = s.revocationRepository.RevokeAccountBefore(ctx, accountID, time.Now().UTC(), s.config.AccessTokenLifespan)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access tokens of account(%d): %w", accountID, err)
+	}
+
+	slugs, err := s.accountRepository.Delete(ctx, accountID, s.config.AccountDeletionPolicy, s.config.AccountDeletionTransferTo)
+	if err != nil {
+		return fmt.Errorf("failed to delete account(%d): %w", accountID, err)
+	}
+
+	// the account is gone by now, a stale cache entry expires on its own
+	if err := s.urlRepository.Invalidate(ctx, slugs); err != nil {
+		s.logger.Warn("failed to invalidate urls of deleted account", map[string]interface{}{
+			"accountID":    accountID,
+			"errorMessage": err.Error(),
+		})
+	}
+
+	s.logger.Info("account deleted", map[string]interface{}{
+		"accountID": accountID,
+		"policy":    s.config.AccountDeletionPolicy,
+		"urls":      len(slugs),
+	})
+
+	return nil
+}
+
+func (s v1) GetAccountInfoFromAccessToken(ctx context.Context, accessToken string) (*types.AccountInfo, error) {
+	claims, err := s.parseAccessToken(accessToken, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account info from auth token: %w", err)
+	}
+
+	revocation, err := s.revocationRepository.Get(ctx, claims.AccountID, claims.Family)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check revocation of auth token: %w", err)
+	}
+	if claims.Family != 0 && revocation.SessionRevoked {
+		return nil, ErrRevokedToken
+	}
+	// iat is only as precise as a second, so tokens issued in the second of a revocation are let through.
+	if claims.IssuedAt != nil && claims.IssuedAt.Time.Unix() < revocation.RevokedBefore.Unix() {
+		return nil, ErrRevokedToken
+	}
+
+	// if access to other info about account is needed, it should be added here. do this IF it is really necessary.
+
+	return &types.AccountInfo{ID: claims.AccountID, SessionID: claims.Family, Scopes: claims.scopes()}, nil
+}
+
+func (s v1) GetSessions(ctx context.Context, accountID uint64) ([]types.Session, error) {
+	sessions, err := s.refreshTokenRepository.GetActiveFamilies(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions of account(%d): %w", accountID, err)
+	}
+
+	return sessions, nil
+}
+
+func (s v1) RevokeSession(ctx context.Context, accountID, sessionID uint64) error {
+	// access tokens issued before sessions were tracked carry no session
+	if sessionID == 0 {
+		return ErrSessionNotFound
+	}
+
+	err := s.refreshTokenRepository.RevokeFamily(ctx, accountID, sessionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke session(%d): %w", sessionID, err)
+	}
+
+	// access tokens of the session would otherwise keep working until they expire
+	if err := s.revocationRepository.RevokeSession(ctx, sessionID, s.config.AccessTokenLifespan); err != nil {
+		return fmt.Errorf("failed to revoke access tokens of session(%d): %w", sessionID, err)
+	}
+
+	return nil
+}
+
+func (s v1) RevokeAllSessions(ctx context.Context, accountID uint64) error {
+	if err := s.refreshTokenRepository.RevokeAllForAccount(ctx, accountID); err != nil {
+		return fmt.Errorf("failed to revoke sessions of account(%d): %w", accountID, err)
+	}
+
+	err := s.revocationRepository.RevokeAccountBefore(ctx, accountID, time.Now().UTC(), s.config.AccessTokenLifespan)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access tokens of account(%d): %w", accountID, err)
+	}
+
+	return nil
+}
+
+func (s v1) CreateAPIKey(
+	ctx context.Context, accountID uint64, name string, scopes types.Scopes, expiresAt *time.Time,
+) (string, *types.APIKey, error) {
+	if name == "" || len([]rune(name)) > types.MaxAPIKeyNameLength {
+		return "", nil, ErrInvalidAPIKeyName
+	}
+	if len(scopes) == 0 {
+		return "", nil, ErrNoScope
+	}
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", nil, ErrInvalidAPIKeyExpiry
+	}
+
+	acct, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	// a key can not do more than its owner
+	for _, scope := range scopes {
+		if !acct.Scopes().Contains(scope) {
+			return "", nil, fmt.Errorf("%w: %s", ErrScopeNotGranted, scope)
+		}
+	}
+
+	key := APIKeyPrefix + s.getRandomEncodedBytes(apiKeyLength)
+
+	apiKey, err := s.apiKeyRepository.Create(ctx, &types.APIKey{
+		AccountID: accountID,
+		Name:      name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   hashAccountToken(key),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	return key, apiKey, nil
+}
+
+func (s v1) GetAPIKeys(ctx context.Context, accountID uint64) ([]types.APIKey, error) {
+	apiKeys, err := s.apiKeyRepository.GetByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys of account(%d): %w", accountID, err)
+	}
+
+	return apiKeys, nil
+}
+
+func (s v1) RevokeAPIKey(ctx context.Context, accountID, apiKeyID uint64) error {
+	err := s.apiKeyRepository.Revoke(ctx, accountID, apiKeyID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key(%d): %w", apiKeyID, err)
+	}
+
+	return nil
+}
+
+func (s v1) GetAccountInfoFromAPIKey(ctx context.Context, key string) (*types.AccountInfo, error) {
+	apiKey, err := s.apiKeyRepository.GetByHash(ctx, hashAccountToken(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrWrongCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		return nil, ErrExpiredToken
+	}
+
+	if err := s.apiKeyRepository.Touch(ctx, apiKey.ID); err != nil {
+		// not knowing when a key was last used is no reason to turn its owner away
+		s.logger.Error("failed to record usage of api key", map[string]interface{}{
+			"apiKeyID":     apiKey.ID,
+			"errorMessage": err.Error(),
+		})
+	}
+
+	return &types.AccountInfo{ID: apiKey.AccountID, APIKeyID: apiKey.ID, Scopes: apiKey.Scopes}, nil
+}
+
+func generateRecoveryCode() (string, error) {
+	bytes := make([]byte, recoveryCodeLength*2)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	first := recoveryCodeEncoding.EncodeToString(bytes[:recoveryCodeLength])
+	second := recoveryCodeEncoding.EncodeToString(bytes[recoveryCodeLength:])
+
+	return strings.ToLower(first + "-" + second), nil
+}
+
+// normalizeRecoveryCode lets recovery codes be typed without the dash or in any case.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// checkTwoFactorCode accepts a TOTP code, or when allowed, one of the recovery codes of the account. each code is
+// only accepted once. wrong codes are throttled per account, a six digit code would not survive unlimited guesses.
+func (s v1) checkTwoFactorCode(ctx context.Context, accountID uint64, code string, allowRecoveryCode bool) error {
+	subject := twoFactorThrottleSubject(accountID, s.config.AccountLoginThrottle)
+	if err := s.checkLoginThrottle(ctx, subject); err != nil {
+		return err
+	}
+
+	err := s.verifyTwoFactorCode(ctx, accountID, code, allowRecoveryCode)
+	if errors.Is(err, ErrWrongTwoFactorCode) {
+		s.recordLoginFailure(ctx, subject)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	s.resetLoginFailures(ctx, subject)
+
+	return nil
+}
+
+func (s v1) verifyTwoFactorCode(ctx context.Context, accountID uint64, code string, allowRecoveryCode bool) error {
+	secret, err := s.twoFactorRepository.GetSecret(ctx, accountID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get two-factor secret: %w", err)
+	}
+
+	if counter, ok := totp.Validate(secret.Secret, code, time.Now(), twoFactorSkew); ok {
+		err := s.twoFactorRepository.UseCounter(ctx, accountID, counter)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrWrongTwoFactorCode
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record use of two-factor code: %w", err)
+		}
+
+		return nil
+	}
+
+	if !allowRecoveryCode {
+		return ErrWrongTwoFactorCode
+	}
+
+	err = s.twoFactorRepository.ConsumeRecoveryCode(ctx, accountID, hashAccountToken(normalizeRecoveryCode(code)))
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrWrongTwoFactorCode
+	}
+	if err != nil {
+		return fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+
+	s.logger.Info("account logged in with a recovery code", map[string]interface{}{"accountID": accountID})
+
+	return nil
+}
+
+func (s v1) BeginTwoFactorEnrollment(ctx context.Context, accountID uint64) (string, string, error) {
+	acct, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get account: %w", err)
+	}
+	if acct.TwoFactorEnabled {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	secret := make([]byte, totp.SecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate two-factor secret: %w", err)
+	}
+
+	if err := s.twoFactorRepository.SaveSecret(ctx, accountID, secret); err != nil {
+		return "", "", fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+
+	return totp.EncodeSecret(secret), totp.ProvisioningURI(s.config.TwoFactorIssuer, acct.EMail, secret), nil
+}
+
+func (s v1) ConfirmTwoFactorEnrollment(ctx context.Context, accountID uint64, code string) ([]string, error) {
+	acct, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if acct.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	// a code proves the authenticator holds the secret, recovery codes do not exist yet
+	if err := s.checkTwoFactorCode(ctx, accountID, code, false); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes := make([]string, 0, types.RecoveryCodeCount)
+	recoveryCodeHashes := make([]string, 0, types.RecoveryCodeCount)
+	for i := 0; i < types.RecoveryCodeCount; i++ {
+		recoveryCode, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+		recoveryCodeHashes = append(recoveryCodeHashes, hashAccountToken(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	if err := s.twoFactorRepository.Enable(ctx, accountID, recoveryCodeHashes); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return recoveryCodes, nil
+}
+
+func (s v1) DisableTwoFactor(ctx context.Context, accountID uint64, code string) error {
+	acct, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+	if !acct.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	// whoever took over a session should not be able to remove the second factor
+	if err := s.checkTwoFactorCode(ctx, accountID, code, true); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepository.Disable(ctx, accountID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	return nil
+}
+
+func (s v1) BeginSSOLogin(ctx context.Context) (string, string, error) {
+	if s.oidcProvider == nil {
+		return "", "", ErrSSODisabled
+	}
+
+	codeVerifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := jwt.NewNumericDate(time.Now().UTC())
+	claims := &jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    types.ServiceName,
+			Subject:   jwtSubjectSSOFlow,
+			Audience:  []string{types.ServiceName},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.SSOFlowLifespan)),
+			NotBefore: now,
+			IssuedAt:  now,
+			ID:        uuid.New().String(),
+		},
+		Nonce:        uuid.New().String(),
+		CodeVerifier: codeVerifier,
+	}
+
+	authorizationURL, err := s.oidcProvider.AuthorizationURL(
+		ctx, claims.ID, claims.Nonce, oidc.CodeChallenge(codeVerifier),
+	)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build authorization url: %w", err)
+	}
+
+	flowToken, err := s.signToken(claims)
+	if err != nil {
+		return "", "", err
+	}
+
+	return authorizationURL, flowToken, nil
+}
+
+func (s v1) CompleteSSOLogin(
+	ctx context.Context, flowToken, state, code string, clientInfo types.ClientInfo,
+) (*types.LoginResult, error) {
+	if s.oidcProvider == nil {
+		return nil, ErrSSODisabled
+	}
+
+	flow, err := s.parseToken(flowToken, jwtSubjectSSOFlow, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse single sign-on flow token: %w", err)
+	}
+	// the state ties what the provider redirected with to the browser that started the flow
+	if subtle.ConstantTimeCompare([]byte(flow.ID), []byte(state)) != 1 {
+		return nil, fmt.Errorf("%w: state does not match", ErrSSOFailed)
+	}
+
+	claims, err := s.oidcProvider.Exchange(ctx, code, flow.CodeVerifier, flow.Nonce)
+	if errors.Is(err, oidc.ErrProviderRejected) || errors.Is(err, oidc.ErrInvalidIDToken) {
+		return nil, fmt.Errorf("%w: %s", ErrSSOFailed, err.Error())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	acct, err := s.getOrProvisionSSOAccount(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.loginAs(ctx, acct, clientInfo)
+}
+
+func (s v1) getOrProvisionSSOAccount(ctx context.Context, claims *oidc.Claims) (*types.Account, error) {
+	issuer := s.oidcProvider.Issuer()
+
+	acct, err := s.accountRepository.GetByIdentity(ctx, issuer, claims.Subject)
+	if err == nil {
+		return acct, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get account by identity: %w", err)
+	}
+
+	// an unverified address could belong to anyone, linking it would hand them the account that owns it
+	if !claims.EMailVerified || claims.EMail == "" {
+		return nil, ErrSSOEMailNotVerified
+	}
+
+	acct, err = s.accountRepository.GetByEMail(ctx, claims.EMail)
+	if errors.Is(err, repository.ErrNotFound) {
+		acct, err = s.accountRepository.CreateWithIdentity(ctx, claims.EMail, issuer, claims.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to provision account: %w", err)
+		}
+
+		return acct, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account by email: %w", err)
+	}
+
+	if !acct.EMailVerified {
+		return nil, ErrSSOAccountNotVerified
+	}
+
+	if err := s.accountRepository.LinkIdentity(ctx, acct.ID, issuer, claims.Subject); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	return acct, nil
+}
 
-	return &types.AccountInfo{ID: claims.AccountID}, nil
+func (s v1) GetJSONWebKeySet() jwk.JSONWebKeySet {
+	return s.accessTokenKeyring.JSONWebKeySet()
 }
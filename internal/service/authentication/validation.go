@@ -0,0 +1,116 @@
+package authentication
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/breach"
+)
+
+// codes of field errors, which clients can tell rejected fields apart by.
+const (
+	FieldErrorInvalid   = "invalid"
+	FieldErrorTooShort  = "too-short"
+	FieldErrorTooLong   = "too-long"
+	FieldErrorGuessable = "guessable"
+	FieldErrorBreached  = "breached"
+)
+
+// FieldError tells why a field of a request is rejected. Field is named as in the request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// FieldValidationError lists every rejected field of a request. it is an ErrValidationFailed.
+type FieldValidationError struct {
+	Fields []FieldError
+}
+
+func (e *FieldValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrValidationFailed, strings.Join(messages, ", "))
+}
+
+func (e *FieldValidationError) Unwrap() error {
+	return ErrValidationFailed
+}
+
+// fieldValidationError returns the rejected fields as a *FieldValidationError, or nil if none is rejected.
+func fieldValidationError(fields ...*FieldError) error {
+	var rejected []FieldError
+	for _, field := range fields {
+		if field != nil {
+			rejected = append(rejected, *field)
+		}
+	}
+
+	if len(rejected) == 0 {
+		return nil
+	}
+
+	return &FieldValidationError{Fields: rejected}
+}
+
+// validateEMail accepts a bare RFC 5322 address, without a display name or comments.
+func validateEMail(field, email string) *FieldError {
+	if len(email) > types.MaxEMailLength {
+		return &FieldError{
+			Field: field, Code: FieldErrorTooLong,
+			Message: fmt.Sprintf("%s must be at most %d characters long", field, types.MaxEMailLength),
+		}
+	}
+
+	// a bare address is formatted back the way it was given, quoted local parts included
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.String() != "<"+email+">" {
+		return &FieldError{Field: field, Code: FieldErrorInvalid, Message: field + " is not a valid email address"}
+	}
+
+	return nil
+}
+
+// validatePassword checks a new password against the policy, and then against the breached passwords. email is the
+// address of the account, it is empty if it is not known.
+func (s v1) validatePassword(ctx context.Context, field, password, email string) (*FieldError, error) {
+	if utf8.RuneCountInString(password) < s.config.PasswordPolicy.MinLength {
+		return &FieldError{
+			Field: field, Code: FieldErrorTooShort,
+			Message: fmt.Sprintf("%s must be at least %d characters long", field, s.config.PasswordPolicy.MinLength),
+		}, nil
+	}
+	if len(password) > s.config.PasswordPolicy.MaxLength {
+		return &FieldError{
+			Field: field, Code: FieldErrorTooLong,
+			Message: fmt.Sprintf("%s must be at most %d bytes long", field, s.config.PasswordPolicy.MaxLength),
+		}, nil
+	}
+
+	if _, blocked := s.passwordBlocklist[strings.ToLower(password)]; blocked {
+		return &FieldError{Field: field, Code: FieldErrorGuessable, Message: field + " is too common"}, nil
+	}
+	if email != "" && strings.EqualFold(password, email) {
+		return &FieldError{Field: field, Code: FieldErrorGuessable, Message: field + " must not be the email address"}, nil
+	}
+
+	breached, err := breach.IsBreached(ctx, s.breachedPasswords, password)
+	if err != nil {
+		return nil, err
+	}
+	if breached {
+		return &FieldError{
+			Field: field, Code: FieldErrorBreached, Message: field + " has appeared in a data breach",
+		}, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,153 @@
+package authentication_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireFieldErrors(t *testing.T, err error, expected map[string]string) {
+	var fieldErr *authentication.FieldValidationError
+	require.True(t, errors.As(err, &fieldErr), "expected field errors, got %v", err)
+	assert.ErrorIs(t, err, authentication.ErrValidationFailed)
+
+	actual := make(map[string]string)
+	for _, field := range fieldErr.Fields {
+		actual[field.Field] = field.Code
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestRegistrationRejectsInvalidEMail(t *testing.T) {
+	tests := map[string]string{
+		"empty":            "",
+		"without at":       "h.kalantari.1997.gmail.com",
+		"with consecutive": "h..kalantari@gmail.com",
+		"with space":       "h kalantari@gmail.com",
+		"with name":        "Hossein <h.kalantari.1997@gmail.com>",
+		"in angle":         "<h.kalantari.1997@gmail.com>",
+		"with comment":     "h.kalantari.1997@gmail.com (Hossein)",
+		"padded":           " h.kalantari.1997@gmail.com",
+	}
+
+	for name, email := range tests {
+		email := email
+		t.Run(name, func(t *testing.T) {
+			sut := createSUT(t)
+			sut.accountRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			err := sut.service.Register(context.Background(), email, "123456")
+			requireFieldErrors(t, err, map[string]string{"email": authentication.FieldErrorInvalid})
+		})
+	}
+}
+
+func TestRegistrationRejectsTooLongEMail(t *testing.T) {
+	sut := createSUT(t)
+	sut.accountRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	email := strings.Repeat("h", types.MaxEMailLength-len("@gmail.com")+1) + "@gmail.com"
+	err := sut.service.Register(context.Background(), email, "123456")
+	requireFieldErrors(t, err, map[string]string{"email": authentication.FieldErrorTooLong})
+}
+
+func TestRegistrationAcceptsUnusualEMail(t *testing.T) {
+	for _, email := range []string{
+		"h.kalantari+short@gmail.com",
+		`"h kalantari"@gmail.com`,
+		"h.kalantari@[192.168.10.42]",
+	} {
+		email := email
+		t.Run(email, func(t *testing.T) {
+			sut := createSUT(t)
+			sut.accountRepo.EXPECT().Create(gomock.Any(), email, gomock.Any()).
+				Return(nil, repository.ErrUniquenessViolated).Times(1)
+
+			err := sut.service.Register(context.Background(), email, "123456")
+			assert.ErrorIs(t, err, authentication.ErrEMailAlreadyUsed)
+		})
+	}
+}
+
+func TestRegistrationRejectsWeakPassword(t *testing.T) {
+	const email = "h.kalantari.1997@gmail.com"
+
+	tests := map[string]struct {
+		password string
+		code     string
+	}{
+		"empty":          {password: "", code: authentication.FieldErrorTooShort},
+		"too short":      {password: "12345", code: authentication.FieldErrorTooShort},
+		"too many bytes": {password: strings.Repeat("گ", 37), code: authentication.FieldErrorTooLong},
+		"blocked":        {password: "PassWord", code: authentication.FieldErrorGuessable},
+		"email":          {password: strings.ToUpper(email), code: authentication.FieldErrorGuessable},
+		"breached":       {password: "correct horse battery staple", code: authentication.FieldErrorBreached},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			sut := createSUT(t)
+			sut.accountRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			err := sut.service.Register(context.Background(), email, test.password)
+			requireFieldErrors(t, err, map[string]string{"password": test.code})
+		})
+	}
+}
+
+func TestPasswordLengthIsCountedInCharacters(t *testing.T) {
+	sut := createSUT(t)
+
+	// six characters, but twelve bytes
+	sut.accountRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, repository.ErrUniquenessViolated).Times(1)
+
+	err := sut.service.Register(context.Background(), "h.kalantari.1997@gmail.com", "گذرواژ")
+	assert.ErrorIs(t, err, authentication.ErrEMailAlreadyUsed)
+}
+
+func TestRegistrationListsEveryInvalidField(t *testing.T) {
+	sut := createSUT(t)
+	sut.accountRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.Register(context.Background(), "h.kalantari.1997", "123")
+	requireFieldErrors(t, err, map[string]string{
+		"email":    authentication.FieldErrorInvalid,
+		"password": authentication.FieldErrorTooShort,
+	})
+}
+
+func TestResetPasswordToWeakPasswordKeepsToken(t *testing.T) {
+	sut := createSUT(t)
+	sut.accountTokenRepo.EXPECT().Consume(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.ResetPassword(context.Background(), "token", "qwerty123")
+	requireFieldErrors(t, err, map[string]string{"password": authentication.FieldErrorGuessable})
+}
+
+func TestChangePasswordToBreachedPassword(t *testing.T) {
+	sut := createSUT(t)
+	expectAccount(t, sut, "123456")
+
+	sut.accountRepo.EXPECT().SetPasswordHash(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.ChangePassword(context.Background(), 1, "123456", "correct horse battery staple", clientInfo)
+	requireFieldErrors(t, err, map[string]string{"newPassword": authentication.FieldErrorBreached})
+}
+
+func TestRequestEMailChangeToInvalidAddress(t *testing.T) {
+	sut := createSUT(t)
+	sut.accountRepo.EXPECT().SetPendingEMail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.RequestEMailChange(context.Background(), 1, "123456", "new.address")
+	requireFieldErrors(t, err, map[string]string{"email": authentication.FieldErrorInvalid})
+}
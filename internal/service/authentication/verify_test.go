@@ -0,0 +1,90 @@
+package authentication_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/service/authentication"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func TestVerifyEMailSuccessful(t *testing.T) {
+	const token = "verification-token"
+
+	sut := createSUT(t)
+
+	sut.accountTokenRepo.EXPECT().Consume(
+		gomock.Any(), types.AccountTokenPurposeEMailVerification, hashToken(token),
+	).Return(uint64(1), nil).Times(1)
+	sut.accountRepo.EXPECT().SetEMailVerified(gomock.Any(), uint64(1)).Return(nil).Times(1)
+
+	require.NoError(t, sut.service.VerifyEMail(context.Background(), token))
+}
+
+func TestVerifyEMailInvalidToken(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountTokenRepo.EXPECT().Consume(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(uint64(0), repository.ErrNotFound).Times(1)
+	sut.accountRepo.EXPECT().SetEMailVerified(gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.VerifyEMail(context.Background(), "used-or-expired-token")
+	assert.ErrorIs(t, err, authentication.ErrInvalidVerificationToken)
+}
+
+func TestResendVerificationEMail(t *testing.T) {
+	const email = "h.kalantari.1997@gmail.com"
+
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).
+		Return(&types.Account{ID: 1, EMail: email}, nil).Times(1)
+
+	revoke := sut.accountTokenRepo.EXPECT().RevokeAll(
+		gomock.Any(), uint64(1), types.AccountTokenPurposeEMailVerification,
+	).Return(nil).Times(1)
+
+	var tokenHash string
+	sut.accountTokenRepo.EXPECT().Create(
+		gomock.Any(), uint64(1), types.AccountTokenPurposeEMailVerification, gomock.Any(), gomock.Any(),
+	).Do(func(_ context.Context, _ uint64, _ types.AccountTokenPurpose, hash string, _ time.Duration) {
+		tokenHash = hash
+	}).Return(nil).After(revoke).Times(1)
+
+	sut.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, message mail.Message) error {
+			token := strings.TrimSpace(message.Body[strings.Index(message.Body, "?token=")+len("?token="):])
+			assert.Equal(t, tokenHash, hashToken(token), "mailed token does not match the stored one")
+			return nil
+		},
+	).Times(1)
+
+	require.NoError(t, sut.service.ResendVerificationEMail(context.Background(), 1))
+}
+
+func TestResendVerificationEMailAlreadyVerified(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).
+		Return(&types.Account{ID: 1, EMailVerified: true}, nil).Times(1)
+	sut.accountTokenRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.mailer.EXPECT().Send(gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.ResendVerificationEMail(context.Background(), 1)
+	assert.ErrorIs(t, err, authentication.ErrEMailAlreadyVerified)
+}
@@ -0,0 +1,219 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+// exportedProfile is the part of an account handed out in exports, its credentials are left out.
+type exportedProfile struct {
+	ID               uint64 `json:"id"`
+	EMail            string `json:"email"`
+	EMailVerified    bool   `json:"emailVerified"`
+	TwoFactorEnabled bool   `json:"twoFactorEnabled"`
+}
+
+// exportedVisits holds the daily visits of a url, in UTC.
+type exportedVisits struct {
+	Slug string                   `json:"slug"`
+	Days []types.VisitStatsBucket `json:"days"`
+}
+
+func (s *v1) buildArchive(ctx context.Context, accountID uint64) ([]byte, error) {
+	account, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	urls, err := s.getAllUrls(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.refreshTokenRepository.GetFamilies(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	now := time.Now().UTC()
+	visits := make([]exportedVisits, 0, len(urls))
+	for _, url := range urls {
+		// rollups start at the hour, the first one starts before the url is created.
+		days, err := s.visitRepository.GetStats(
+			ctx, url.ID, url.CreatedAt.Truncate(time.Hour), now, types.StatsGranularityDay, time.UTC,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get visits of url(%d): %w", url.ID, err)
+		}
+		if days == nil {
+			days = []types.VisitStatsBucket{}
+		}
+
+		visits = append(visits, exportedVisits{Slug: url.Slug, Days: days})
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	profile := exportedProfile{
+		ID:               account.ID,
+		EMail:            account.EMail,
+		EMailVerified:    account.EMailVerified,
+		TwoFactorEnabled: account.TwoFactorEnabled,
+	}
+	if err := writeJSON(archive, "profile.json", profile); err != nil {
+		return nil, err
+	}
+
+	if err := writeJSON(archive, "urls.json", urls); err != nil {
+		return nil, err
+	}
+	if err := writeCSV(archive, "urls.csv", urlRecords(urls)); err != nil {
+		return nil, err
+	}
+
+	if err := writeJSON(archive, "sessions.json", sessions); err != nil {
+		return nil, err
+	}
+	if err := writeCSV(archive, "sessions.csv", sessionRecords(sessions)); err != nil {
+		return nil, err
+	}
+
+	if err := writeJSON(archive, "visits.json", visits); err != nil {
+		return nil, err
+	}
+	if err := writeCSV(archive, "visits.csv", visitRecords(visits)); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (s *v1) getAllUrls(ctx context.Context, accountID uint64) ([]types.Url, error) {
+	urls := make([]types.Url, 0)
+
+	var cursor string
+	for {
+		page, nextCursor, err := s.urlRepository.GetByAccountID(ctx, accountID, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get urls: %w", err)
+		}
+
+		urls = append(urls, page...)
+		if nextCursor == "" {
+			return urls, nil
+		}
+		cursor = nextCursor
+	}
+}
+
+func writeJSON(archive *zip.Writer, name string, value interface{}) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// writeCSV writes records to a file of the archive, the first record being the header.
+func writeCSV(archive *zip.Writer, name string, records [][]string) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(records); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func urlRecords(urls []types.Url) [][]string {
+	records := [][]string{{
+		"id", "slug", "original_url", "disabled", "protected", "total_visits", "unique_visits", "bot_visits",
+		"max_visits", "expires_at", "created_at",
+	}}
+	for _, url := range urls {
+		var maxVisits string
+		if url.MaxVisits != nil {
+			maxVisits = strconv.FormatUint(*url.MaxVisits, 10)
+		}
+
+		records = append(records, []string{
+			strconv.FormatUint(url.ID, 10),
+			url.Slug,
+			url.OriginalUrl,
+			strconv.FormatBool(url.Disabled),
+			strconv.FormatBool(url.IsProtected()),
+			strconv.FormatUint(url.TotalVisits, 10),
+			strconv.FormatUint(url.UniqueVisits, 10),
+			strconv.FormatUint(url.BotVisits, 10),
+			maxVisits,
+			formatTime(url.ExpiresAt),
+			formatTime(&url.CreatedAt),
+		})
+	}
+
+	return records
+}
+
+func sessionRecords(sessions []types.Session) [][]string {
+	records := [][]string{{"id", "user_agent", "client_ip", "created_at", "last_used_at"}}
+	for _, session := range sessions {
+		records = append(records, []string{
+			strconv.FormatUint(session.ID, 10),
+			session.UserAgent,
+			session.ClientIP,
+			formatTime(&session.CreatedAt),
+			formatTime(&session.LastUsedAt),
+		})
+	}
+
+	return records
+}
+
+func visitRecords(visits []exportedVisits) [][]string {
+	records := [][]string{{"slug", "day", "total_visits", "unique_visits", "bot_visits"}}
+	for _, url := range visits {
+		for _, day := range url.Days {
+			records = append(records, []string{
+				url.Slug,
+				day.Bucket.Format("2006-01-02"),
+				strconv.FormatUint(day.TotalVisits, 10),
+				strconv.FormatUint(day.UniqueVisits, 10),
+				strconv.FormatUint(day.BotVisits, 10),
+			})
+		}
+	}
+
+	return records
+}
@@ -0,0 +1,30 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+var (
+	ErrValidationFailed     = errors.New("validation error")
+	ErrExportInProgress     = fmt.Errorf("%w: an export of the account is already being built", ErrValidationFailed)
+	ErrExportNotFound       = fmt.Errorf("%w: data export not found", ErrValidationFailed)
+	ErrInvalidDownloadToken = fmt.Errorf("%w: download link is invalid or expired", ErrValidationFailed)
+)
+
+// Service builds archives of everything kept on an account: its profile, urls, sessions and the daily visits of its
+// urls, each as both json and csv files in a zip archive.
+type Service interface {
+	// RequestExport queues an export of the account. it is built in the background, GetExport tells when it is ready.
+	RequestExport(ctx context.Context, accountID uint64) (*types.DataExport, error)
+	// GetExport returns an export of the account, along with its download url once it is ready.
+	GetExport(ctx context.Context, accountID, exportID uint64) (*types.DataExport, error)
+	// GetArchive returns the zip archive a download url points to. the url is all that is needed to download it.
+	GetArchive(ctx context.Context, downloadToken string) (fileName string, archive []byte, err error)
+	// Drain stops building exports and waits for the one being built. exports still pending are built once an
+	// instance is running again.
+	Drain(ctx context.Context) error
+}
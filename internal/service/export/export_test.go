@@ -0,0 +1,367 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	mockAccount "github.com/h3isenbug/url-shortener/internal/repository/account/mock"
+	mockDataExport "github.com/h3isenbug/url-shortener/internal/repository/dataExport/mock"
+	mockRefreshToken "github.com/h3isenbug/url-shortener/internal/repository/refreshToken/mock"
+	mockUrl "github.com/h3isenbug/url-shortener/internal/repository/url/mock"
+	mockVisit "github.com/h3isenbug/url-shortener/internal/repository/visit/mock"
+	"github.com/h3isenbug/url-shortener/internal/service/export"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/log"
+	"github.com/h3isenbug/url-shortener/pkg/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const downloadURL = "https://short.ir/api/export/download"
+
+const exportSecret = "export-secret-for-the-tests-of-exports"
+
+type sut struct {
+	service          export.Service
+	dataExportRepo   *mockDataExport.MockRepository
+	accountRepo      *mockAccount.MockRepository
+	urlRepo          *mockUrl.MockRepository
+	visitRepo        *mockVisit.MockRepository
+	refreshTokenRepo *mockRefreshToken.MockRepository
+}
+
+func createSUT(t *testing.T) sut {
+	return newSUT(t, func(sut) {})
+}
+
+// newSUT sets the expectations of the exports to build before the service starts building them.
+func newSUT(t *testing.T, expect func(s sut)) sut {
+	ctrl := gomock.NewController(t)
+
+	s := sut{
+		dataExportRepo:   mockDataExport.NewMockRepository(ctrl),
+		accountRepo:      mockAccount.NewMockRepository(ctrl),
+		urlRepo:          mockUrl.NewMockRepository(ctrl),
+		visitRepo:        mockVisit.NewMockRepository(ctrl),
+		refreshTokenRepo: mockRefreshToken.NewMockRepository(ctrl),
+	}
+
+	expect(s)
+	s.dataExportRepo.EXPECT().Claim(gomock.Any(), gomock.Any()).Return(nil, repository.ErrNotFound).AnyTimes()
+	s.dataExportRepo.EXPECT().DeleteExpired(gomock.Any()).Return(int64(0), nil).AnyTimes()
+
+	logger, err := log.NewZapLoggingService("")
+	require.NoError(t, err)
+
+	downloadTokenSigner, err := signer.NewHMACSignerV1([]byte(exportSecret))
+	require.NoError(t, err)
+
+	s.service = export.NewExportServiceV1(
+		logger,
+		s.dataExportRepo,
+		s.accountRepo,
+		s.urlRepo,
+		s.visitRepo,
+		s.refreshTokenRepo,
+		downloadTokenSigner,
+		downloadURL,
+		time.Hour*24,
+		time.Hour,
+	)
+
+	// the worker is stopped before the expectations are checked
+	t.Cleanup(func() {
+		assert.NoError(t, s.service.Drain(context.Background()))
+	})
+
+	return s
+}
+
+func waitFor(t *testing.T, done <-chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("data export was not built in time")
+	}
+}
+
+func readArchive(t *testing.T, archive []byte) map[string]string {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		opened, err := file.Open()
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(opened)
+		require.NoError(t, err)
+		require.NoError(t, opened.Close())
+
+		files[file.Name] = string(content)
+	}
+
+	return files
+}
+
+func readCSV(t *testing.T, content string) [][]string {
+	records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+	require.NoError(t, err)
+
+	return records
+}
+
+func TestRequestExport(t *testing.T) {
+	sut := createSUT(t)
+
+	var downloadNonce string
+	sut.dataExportRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ uint64, nonce string) (*types.DataExport, error) {
+			downloadNonce = nonce
+			return &types.DataExport{ID: 3, AccountID: 1, Status: types.DataExportStatusPending}, nil
+		},
+	).Times(1)
+
+	dataExport, err := sut.service.RequestExport(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, types.DataExportStatusPending, dataExport.Status)
+	assert.Len(t, downloadNonce, 43)
+}
+
+func TestRequestedExportsHaveDifferentNonces(t *testing.T) {
+	sut := createSUT(t)
+
+	nonces := map[string]bool{}
+	sut.dataExportRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ uint64, nonce string) (*types.DataExport, error) {
+			nonces[nonce] = true
+			return &types.DataExport{ID: 3, AccountID: 1, Status: types.DataExportStatusPending}, nil
+		},
+	).Times(2)
+
+	for i := 0; i < 2; i++ {
+		_, err := sut.service.RequestExport(context.Background(), 1)
+		require.NoError(t, err)
+	}
+	assert.Len(t, nonces, 2)
+}
+
+func TestRequestExportWhileOneIsUnfinished(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.dataExportRepo.EXPECT().Create(gomock.Any(), uint64(1), gomock.Any()).Return(nil, repository.ErrUniquenessViolated).Times(1)
+
+	_, err := sut.service.RequestExport(context.Background(), 1)
+	assert.ErrorIs(t, err, export.ErrExportInProgress)
+}
+
+func TestExportIsBuiltInBackground(t *testing.T) {
+	createdAt := time.Date(2021, 10, 5, 13, 25, 0, 0, time.UTC)
+	maxVisits := uint64(100)
+	passwordHash := "password hash"
+
+	var archive []byte
+	done := make(chan struct{})
+
+	expect := func(s sut) {
+		s.dataExportRepo.EXPECT().Claim(gomock.Any(), gomock.Any()).
+			Return(&types.DataExport{ID: 3, AccountID: 1, Status: types.DataExportStatusRunning}, nil).Times(1)
+
+		s.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).Return(&types.Account{
+			ID: 1, EMail: "h.kalantari.1997@gmail.com", PasswordHash: "account password hash", EMailVerified: true,
+		}, nil).Times(1)
+
+		// every page of urls is exported
+		s.urlRepo.EXPECT().GetByAccountID(gomock.Any(), uint64(1), "").Return([]types.Url{{
+			ID: 5, Slug: "abc", OriginalUrl: "https://example.com/a,b", AccountID: 1, TotalVisits: 12,
+			MaxVisits: &maxVisits, PasswordHash: &passwordHash, CreatedAt: createdAt,
+		}}, "1", nil).Times(1)
+		s.urlRepo.EXPECT().GetByAccountID(gomock.Any(), uint64(1), "1").Return([]types.Url{{
+			ID: 6, Slug: "def", OriginalUrl: "https://example.com/def", AccountID: 1, CreatedAt: createdAt,
+		}}, "", nil).Times(1)
+
+		s.refreshTokenRepo.EXPECT().GetFamilies(gomock.Any(), uint64(1)).Return([]types.Session{{
+			ID: 4, UserAgent: "curl/7.68.0", ClientIP: "192.168.10.42", CreatedAt: createdAt, LastUsedAt: createdAt,
+		}}, nil).Times(1)
+
+		// the rollup of the hour the url is created in is included
+		s.visitRepo.EXPECT().GetStats(
+			gomock.Any(), uint64(5), createdAt.Truncate(time.Hour), gomock.Any(), types.StatsGranularityDay, time.UTC,
+		).Return([]types.VisitStatsBucket{
+			{Bucket: time.Date(2021, 10, 5, 0, 0, 0, 0, time.UTC), TotalVisits: 7, UniqueVisits: 5, BotVisits: 1},
+			{Bucket: time.Date(2021, 10, 6, 0, 0, 0, 0, time.UTC), TotalVisits: 5, UniqueVisits: 2},
+		}, nil).Times(1)
+		s.visitRepo.EXPECT().GetStats(gomock.Any(), uint64(6), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, nil).Times(1)
+
+		s.dataExportRepo.EXPECT().Complete(gomock.Any(), uint64(3), gomock.Any(), time.Hour*24).DoAndReturn(
+			func(_ context.Context, _ uint64, built []byte, _ time.Duration) error {
+				archive = built
+				close(done)
+				return nil
+			},
+		).Times(1)
+	}
+	newSUT(t, expect)
+	waitFor(t, done)
+
+	files := readArchive(t, archive)
+	assert.ElementsMatch(t, []string{
+		"profile.json", "urls.json", "urls.csv", "sessions.json", "sessions.csv", "visits.json", "visits.csv",
+	}, keys(files))
+
+	// credentials are never exported
+	for name, content := range files {
+		assert.NotContains(t, content, "password hash", name)
+	}
+
+	var profile map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(files["profile.json"]), &profile))
+	assert.Equal(t, "h.kalantari.1997@gmail.com", profile["email"])
+
+	urls := readCSV(t, files["urls.csv"])
+	require.Len(t, urls, 3)
+	assert.Equal(t, []string{
+		"5", "abc", "https://example.com/a,b", "false", "true", "12", "0", "0", "100", "", "2021-10-05T13:25:00Z",
+	}, urls[1])
+
+	sessions := readCSV(t, files["sessions.csv"])
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "192.168.10.42", sessions[1][2])
+
+	assert.Equal(t, [][]string{
+		{"slug", "day", "total_visits", "unique_visits", "bot_visits"},
+		{"abc", "2021-10-05", "7", "5", "1"},
+		{"abc", "2021-10-06", "5", "2", "0"},
+	}, readCSV(t, files["visits.csv"]))
+
+	var visits []struct {
+		Slug string            `json:"slug"`
+		Days []json.RawMessage `json:"days"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(files["visits.json"]), &visits))
+	require.Len(t, visits, 2)
+	assert.Len(t, visits[0].Days, 2)
+	assert.NotNil(t, visits[1].Days)
+}
+
+func keys(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func TestFailedExportIsMarkedAsFailed(t *testing.T) {
+	done := make(chan struct{})
+
+	newSUT(t, func(s sut) {
+		s.dataExportRepo.EXPECT().Claim(gomock.Any(), gomock.Any()).
+			Return(&types.DataExport{ID: 3, AccountID: 1, Status: types.DataExportStatusRunning}, nil).Times(1)
+		s.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).Return(nil, errors.New("connection refused")).Times(1)
+
+		s.dataExportRepo.EXPECT().Complete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		s.dataExportRepo.EXPECT().Fail(gomock.Any(), uint64(3)).DoAndReturn(func(context.Context, uint64) error {
+			close(done)
+			return nil
+		}).Times(1)
+	})
+
+	waitFor(t, done)
+}
+
+func TestDownloadReadyExport(t *testing.T) {
+	sut := createSUT(t)
+
+	expiresAt := time.Now().Add(time.Hour)
+	sut.dataExportRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(3)).Return(&types.DataExport{
+		ID: 3, AccountID: 1, Status: types.DataExportStatusReady, ExpiresAt: &expiresAt, DownloadNonce: "nonce",
+	}, nil).Times(1)
+
+	dataExport, err := sut.service.GetExport(context.Background(), 1, 3)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(dataExport.DownloadURL, downloadURL+"?token="))
+
+	sut.dataExportRepo.EXPECT().GetArchive(gomock.Any(), uint64(3), "nonce").Return([]byte("archive"), nil).Times(1)
+
+	fileName, archive, err := sut.service.GetArchive(
+		context.Background(), strings.TrimPrefix(dataExport.DownloadURL, downloadURL+"?token="),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "url-shortener-export-3.zip", fileName)
+	assert.Equal(t, []byte("archive"), archive)
+}
+
+func TestUnfinishedExportHasNoDownloadURL(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.dataExportRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(3)).Return(&types.DataExport{
+		ID: 3, AccountID: 1, Status: types.DataExportStatusRunning,
+	}, nil).Times(1)
+
+	dataExport, err := sut.service.GetExport(context.Background(), 1, 3)
+	require.NoError(t, err)
+	assert.Empty(t, dataExport.DownloadURL)
+}
+
+func TestGetExportOfAnotherAccount(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.dataExportRepo.EXPECT().Get(gomock.Any(), uint64(2), uint64(3)).Return(nil, repository.ErrNotFound).Times(1)
+
+	_, err := sut.service.GetExport(context.Background(), 2, 3)
+	assert.ErrorIs(t, err, export.ErrExportNotFound)
+}
+
+func TestDownloadWithInvalidToken(t *testing.T) {
+	sut := createSUT(t)
+
+	ownSigner, err := signer.NewHMACSignerV1([]byte(exportSecret))
+	require.NoError(t, err)
+	otherSigner, err := signer.NewHMACSignerV1([]byte("another-export-secret-for-the-tests"))
+	require.NoError(t, err)
+	sut.dataExportRepo.EXPECT().GetArchive(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	for name, token := range map[string]string{
+		"malformed": "token",
+		"tampered":  otherSigner.Sign("3:nonce", time.Now().Add(time.Hour)),
+		// the signing key alone must not be enough to make up a link
+		"without nonce": ownSigner.Sign("3", time.Now().Add(time.Hour)),
+		"empty nonce":   ownSigner.Sign("3:", time.Now().Add(time.Hour)),
+	} {
+		_, _, err := sut.service.GetArchive(context.Background(), token)
+		assert.ErrorIs(t, err, export.ErrInvalidDownloadToken, name)
+	}
+}
+
+func TestDownloadDeletedExport(t *testing.T) {
+	sut := createSUT(t)
+
+	expiresAt := time.Now().Add(time.Hour)
+	sut.dataExportRepo.EXPECT().Get(gomock.Any(), uint64(1), uint64(3)).Return(&types.DataExport{
+		ID: 3, AccountID: 1, Status: types.DataExportStatusReady, ExpiresAt: &expiresAt, DownloadNonce: "nonce",
+	}, nil).Times(1)
+
+	dataExport, err := sut.service.GetExport(context.Background(), 1, 3)
+	require.NoError(t, err)
+
+	// the link outlives an archive deleted early, e.g. along with its account
+	sut.dataExportRepo.EXPECT().GetArchive(gomock.Any(), uint64(3), "nonce").Return(nil, repository.ErrNotFound).Times(1)
+
+	_, _, err = sut.service.GetArchive(
+		context.Background(), strings.TrimPrefix(dataExport.DownloadURL, downloadURL+"?token="),
+	)
+	assert.ErrorIs(t, err, export.ErrInvalidDownloadToken)
+}
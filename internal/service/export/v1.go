@@ -0,0 +1,280 @@
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	accountRepository "github.com/h3isenbug/url-shortener/internal/repository/account"
+	dataExportRepository "github.com/h3isenbug/url-shortener/internal/repository/dataExport"
+	refreshTokenRepository "github.com/h3isenbug/url-shortener/internal/repository/refreshToken"
+	urlRepository "github.com/h3isenbug/url-shortener/internal/repository/url"
+	visitRepository "github.com/h3isenbug/url-shortener/internal/repository/visit"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/log"
+	"github.com/h3isenbug/url-shortener/pkg/signer"
+)
+
+const (
+	buildTimeout = 5 * time.Minute
+	// exports running for longer than this were being built by an instance that is gone.
+	staleExportAfter = 3 * buildTimeout
+
+	downloadNonceLength = 32
+)
+
+type v1 struct {
+	logger                 log.Logger
+	dataExportRepository   dataExportRepository.Repository
+	accountRepository      accountRepository.Repository
+	urlRepository          urlRepository.Repository
+	visitRepository        visitRepository.Repository
+	refreshTokenRepository refreshTokenRepository.Repository
+
+	downloadTokenSigner signer.Signer
+	downloadURL         string
+	archiveLifespan     time.Duration
+
+	pollInterval time.Duration
+
+	// wake is nudged by new requests, so that they do not wait for the next poll.
+	wake     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	drained  chan struct{}
+}
+
+func NewExportServiceV1(
+	logger log.Logger,
+	dataExportRepository dataExportRepository.Repository,
+	accountRepository accountRepository.Repository,
+	urlRepository urlRepository.Repository,
+	visitRepository visitRepository.Repository,
+	refreshTokenRepository refreshTokenRepository.Repository,
+
+	downloadTokenSigner signer.Signer,
+	downloadURL string,
+	archiveLifespan time.Duration,
+
+	pollInterval time.Duration,
+) Service {
+	service := &v1{
+		logger:                 logger,
+		dataExportRepository:   dataExportRepository,
+		accountRepository:      accountRepository,
+		urlRepository:          urlRepository,
+		visitRepository:        visitRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		downloadTokenSigner:    downloadTokenSigner,
+		downloadURL:            downloadURL,
+		archiveLifespan:        archiveLifespan,
+		pollInterval:           pollInterval,
+		wake:                   make(chan struct{}, 1),
+		stop:                   make(chan struct{}),
+		drained:                make(chan struct{}),
+	}
+
+	go service.run()
+
+	return service
+}
+
+func (s *v1) RequestExport(ctx context.Context, accountID uint64) (*types.DataExport, error) {
+	downloadNonce, err := generateDownloadNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	export, err := s.dataExportRepository.Create(ctx, accountID, downloadNonce)
+	if errors.Is(err, repository.ErrUniquenessViolated) {
+		return nil, ErrExportInProgress
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data export: %w", err)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return export, nil
+}
+
+func (s *v1) GetExport(ctx context.Context, accountID, exportID uint64) (*types.DataExport, error) {
+	export, err := s.dataExportRepository.Get(ctx, accountID, exportID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+
+	if export.Status == types.DataExportStatusReady && export.ExpiresAt != nil {
+		token := s.downloadTokenSigner.Sign(
+			strconv.FormatUint(export.ID, 10)+":"+export.DownloadNonce, *export.ExpiresAt,
+		)
+		export.DownloadURL = s.downloadURL + "?token=" + token
+	}
+
+	return export, nil
+}
+
+func (s *v1) GetArchive(ctx context.Context, downloadToken string) (string, []byte, error) {
+	data, err := s.downloadTokenSigner.Verify(downloadToken)
+	if err != nil {
+		return "", nil, ErrInvalidDownloadToken
+	}
+
+	parts := strings.SplitN(data, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", nil, ErrInvalidDownloadToken
+	}
+
+	exportID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return "", nil, ErrInvalidDownloadToken
+	}
+
+	archive, err := s.dataExportRepository.GetArchive(ctx, exportID, parts[1])
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", nil, ErrInvalidDownloadToken
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get data export archive: %w", err)
+	}
+
+	return fmt.Sprintf("%s-export-%d.zip", types.ServiceName, exportID), archive, nil
+}
+
+func generateDownloadNonce() (string, error) {
+	bytes := make([]byte, downloadNonceLength)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func (s *v1) Drain(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+
+	select {
+	case <-s.drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to wait for data export to be built: %w", ctx.Err())
+	}
+}
+
+func (s *v1) run() {
+	defer close(s.drained)
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.deleteExpired()
+		s.buildPending()
+
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *v1) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *v1) deleteExpired() {
+	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout)
+	defer cancel()
+
+	deleted, err := s.dataExportRepository.DeleteExpired(ctx)
+	if err != nil {
+		s.logger.Error("failed to delete expired data exports", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+		return
+	}
+	if deleted > 0 {
+		s.logger.Debug("deleted expired data exports", map[string]interface{}{
+			"exports": deleted,
+		})
+	}
+}
+
+// buildPending builds exports until none is left to claim.
+func (s *v1) buildPending() {
+	for !s.stopped() && s.buildNext() {
+	}
+}
+
+// buildNext builds one export. it returns false if there was none to build.
+func (s *v1) buildNext() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), buildTimeout)
+	defer cancel()
+
+	export, err := s.dataExportRepository.Claim(ctx, staleExportAfter)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		s.logger.Error("failed to claim data export", map[string]interface{}{
+			"errorMessage": err.Error(),
+		})
+		return false
+	}
+
+	archive, err := s.buildArchive(ctx, export.AccountID)
+	if err != nil {
+		s.logger.Error("failed to build data export", map[string]interface{}{
+			"exportID":     export.ID,
+			"accountID":    export.AccountID,
+			"errorMessage": err.Error(),
+		})
+
+		if err := s.dataExportRepository.Fail(ctx, export.ID); err != nil {
+			s.logger.Error("failed to mark data export as failed", map[string]interface{}{
+				"exportID":     export.ID,
+				"errorMessage": err.Error(),
+			})
+		}
+		return true
+	}
+
+	if err := s.dataExportRepository.Complete(ctx, export.ID, archive, s.archiveLifespan); err != nil {
+		s.logger.Error("failed to save data export", map[string]interface{}{
+			"exportID":     export.ID,
+			"accountID":    export.AccountID,
+			"errorMessage": err.Error(),
+		})
+		return true
+	}
+
+	s.logger.Info("data export is ready", map[string]interface{}{
+		"exportID":  export.ID,
+		"accountID": export.AccountID,
+		"size":      len(archive),
+	})
+
+	return true
+}
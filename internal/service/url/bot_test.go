@@ -0,0 +1,72 @@
+package url_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func limitedUrl(slug string) *types.Url {
+	maxVisits := uint64(10)
+
+	return &types.Url{ID: 1, OriginalUrl: "https://google.com/", Slug: slug, MaxVisits: &maxVisits}
+}
+
+func TestBotVisitIsCountedSeparately(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUTWithBotPolicy(t, types.BotPolicySeparate)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(limitedUrl(slug), nil).Times(1)
+	// bots must not use up the visit limit, so the limited url is not counted right away.
+	sut.urlRepo.EXPECT().IncrementVisits(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.visitRecorder.EXPECT().Record(gomock.Any()).DoAndReturn(
+		func(visit types.Visit) error {
+			assert.True(t, visit.Bot)
+			assert.False(t, visit.Counted)
+			return nil
+		},
+	).Times(1)
+
+	originalUrl, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{Bot: true}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://google.com/", originalUrl)
+}
+
+func TestBotVisitIsCountedLikeAPerson(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUTWithBotPolicy(t, types.BotPolicyCount)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(limitedUrl(slug), nil).Times(1)
+	sut.urlRepo.EXPECT().IncrementVisits(gomock.Any(), gomock.Eq(slug), false).Return(nil).Times(1)
+	sut.visitRecorder.EXPECT().Record(gomock.Any()).DoAndReturn(
+		func(visit types.Visit) error {
+			assert.False(t, visit.Bot)
+			assert.True(t, visit.Counted)
+			return nil
+		},
+	).Times(1)
+
+	originalUrl, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{Bot: true}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://google.com/", originalUrl)
+}
+
+func TestBotVisitIsIgnored(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUTWithBotPolicy(t, types.BotPolicyIgnore)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(limitedUrl(slug), nil).Times(1)
+	sut.urlRepo.EXPECT().IncrementVisits(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.visitRecorder.EXPECT().Record(gomock.Any()).Times(0)
+
+	originalUrl, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{Bot: true}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://google.com/", originalUrl)
+}
@@ -0,0 +1,78 @@
+package url
+
+import (
+	"context"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+const (
+	MaxBreakdownLimit = 100
+
+	// breakdowns are computed from raw visits, so the range is capped to keep the scans bounded.
+	maxBreakdownRange = 366 * 24 * time.Hour
+)
+
+func validateBreakdown(dimension types.BreakdownDimension, from, to time.Time, limit int) error {
+	if !dimension.IsValid() {
+		return ErrInvalidDimension
+	}
+
+	return validateBreakdownRange(from, to, limit)
+}
+
+func validateBreakdownRange(from, to time.Time, limit int) error {
+	if limit <= 0 || limit > MaxBreakdownLimit {
+		return ErrInvalidBreakdownLimit
+	}
+	if !from.Before(to) {
+		return ErrInvalidStatsRange
+	}
+	if to.Sub(from) > maxBreakdownRange {
+		return ErrStatsRangeTooLarge
+	}
+
+	return nil
+}
+
+func (s v1) GetUrlBreakdown(
+	ctx context.Context, accountID uint64, slug string,
+	dimension types.BreakdownDimension, from, to time.Time, limit int,
+) ([]types.BreakdownItem, error) {
+	if err := validateBreakdown(dimension, from, to, limit); err != nil {
+		return nil, err
+	}
+
+	url, err := s.getOwnedUrl(ctx, accountID, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.visitRepository.GetUrlBreakdown(ctx, url.ID, dimension, from, to, limit)
+}
+
+func (s v1) GetAccountBreakdown(
+	ctx context.Context, accountID uint64, dimension types.BreakdownDimension, from, to time.Time, limit int,
+) ([]types.BreakdownItem, error) {
+	if err := validateBreakdown(dimension, from, to, limit); err != nil {
+		return nil, err
+	}
+
+	return s.visitRepository.GetAccountBreakdown(ctx, accountID, dimension, from, to, limit)
+}
+
+func (s v1) GetUrlGeoBreakdown(
+	ctx context.Context, accountID uint64, slug string, from, to time.Time, limit int,
+) ([]types.GeoBreakdownItem, error) {
+	if err := validateBreakdownRange(from, to, limit); err != nil {
+		return nil, err
+	}
+
+	url, err := s.getOwnedUrl(ctx, accountID, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.visitRepository.GetGeoBreakdown(ctx, url.ID, from, to, limit)
+}
@@ -0,0 +1,99 @@
+package url_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/service/url"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUrlBreakdownSuccessful(t *testing.T) {
+	const (
+		slug      = "goog"
+		accountID = 3
+	)
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -7)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID: 1, Slug: slug, AccountID: accountID,
+	}, nil).Times(1)
+	sut.visitRepo.EXPECT().GetUrlBreakdown(gomock.Any(), uint64(1), types.BreakdownDimensionBrowser, from, to, 5).
+		Return([]types.BreakdownItem{{Value: "Firefox", TotalVisits: 4, UniqueVisits: 2}}, nil).Times(1)
+
+	items, err := sut.service.GetUrlBreakdown(context.Background(), accountID, slug, types.BreakdownDimensionBrowser, from, to, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []types.BreakdownItem{{Value: "Firefox", TotalVisits: 4, UniqueVisits: 2}}, items)
+}
+
+func TestGetAccountBreakdownValidation(t *testing.T) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -7)
+
+	testCases := []struct {
+		name      string
+		dimension types.BreakdownDimension
+		from, to  time.Time
+		limit     int
+		err       error
+	}{
+		{"unknown dimension", "client_ip", from, to, 10, url.ErrInvalidDimension},
+		{"zero limit", types.BreakdownDimensionOS, from, to, 0, url.ErrInvalidBreakdownLimit},
+		{"limit too large", types.BreakdownDimensionOS, from, to, url.MaxBreakdownLimit + 1, url.ErrInvalidBreakdownLimit},
+		{"reversed range", types.BreakdownDimensionOS, to, from, 10, url.ErrInvalidStatsRange},
+		{"range too large", types.BreakdownDimensionOS, to.AddDate(-2, 0, 0), to, 10, url.ErrStatsRangeTooLarge},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			sut := createSUT(t)
+
+			_, err := sut.service.GetAccountBreakdown(
+				context.Background(), 3, testCase.dimension, testCase.from, testCase.to, testCase.limit,
+			)
+			assert.ErrorIs(t, err, testCase.err)
+		})
+	}
+}
+
+func TestGetUrlGeoBreakdownSuccessful(t *testing.T) {
+	const (
+		slug      = "goog"
+		accountID = 3
+	)
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -7)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID: 1, Slug: slug, AccountID: accountID,
+	}, nil).Times(1)
+	sut.visitRepo.EXPECT().GetGeoBreakdown(gomock.Any(), uint64(1), from, to, 10).
+		Return([]types.GeoBreakdownItem{{Country: "IR", City: "Tehran", TotalVisits: 7, UniqueVisits: 3}}, nil).Times(1)
+
+	items, err := sut.service.GetUrlGeoBreakdown(context.Background(), accountID, slug, from, to, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []types.GeoBreakdownItem{{Country: "IR", City: "Tehran", TotalVisits: 7, UniqueVisits: 3}}, items)
+}
+
+func TestGetUrlGeoBreakdownOfSomeoneElsesUrl(t *testing.T) {
+	const slug = "goog"
+	to := time.Now().UTC()
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID: 1, Slug: slug, AccountID: 4,
+	}, nil).Times(1)
+
+	_, err := sut.service.GetUrlGeoBreakdown(context.Background(), 3, slug, to.AddDate(0, 0, -7), to, 10)
+	assert.ErrorIs(t, err, url.ErrNotAuthorized)
+}
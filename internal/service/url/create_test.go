@@ -0,0 +1,111 @@
+package url_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/service/url"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateShortUrlWithLimits(t *testing.T) {
+	expiresAt := time.Now().UTC().Add(time.Hour)
+	maxVisits := uint64(5)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().CreateShortUrl(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, created *types.Url) error {
+			assert.Equal(t, "goog", created.Slug)
+			assert.Equal(t, uint64(1), created.AccountID)
+			assert.Equal(t, &expiresAt, created.ExpiresAt)
+			assert.Equal(t, &maxVisits, created.MaxVisits)
+			return nil
+		},
+	).Times(1)
+
+	slug, err := sut.service.CreateShortUrl(
+		context.Background(), "https://google.com/", "goog", 1,
+		url.ShortUrlOptions{ExpiresAt: &expiresAt, MaxVisits: &maxVisits},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "goog", slug)
+}
+
+func TestCreateShortUrlExpirationInPast(t *testing.T) {
+	expiresAt := time.Now().UTC().Add(-time.Hour)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().CreateShortUrl(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.CreateShortUrl(
+		context.Background(), "https://google.com/", "goog", 1,
+		url.ShortUrlOptions{ExpiresAt: &expiresAt},
+	)
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrExpirationInPast)
+	}
+}
+
+func TestCreateShortUrlZeroVisitLimit(t *testing.T) {
+	maxVisits := uint64(0)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().CreateShortUrl(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.CreateShortUrl(
+		context.Background(), "https://google.com/", "goog", 1,
+		url.ShortUrlOptions{MaxVisits: &maxVisits},
+	)
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrInvalidVisitLimit)
+	}
+}
+
+func TestCreateShortUrlPasswordTooLong(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().CreateShortUrl(gomock.Any(), gomock.Any()).Times(0)
+
+	// 24 three byte characters are 72 bytes
+	_, err := sut.service.CreateShortUrl(
+		context.Background(), "https://google.com/", "goog", 1,
+		url.ShortUrlOptions{Password: strings.Repeat("€", 24) + "a"},
+	)
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrPasswordTooLong)
+		assert.ErrorIs(t, err, url.ErrValidationFailed)
+	}
+}
+
+func TestCreateShortUrlUnverifiedEMail(t *testing.T) {
+	sut := createSUTRequiringVerifiedEMail(t)
+
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).
+		Return(&types.Account{ID: 1, EMailVerified: false}, nil).Times(1)
+	sut.urlRepo.EXPECT().CreateShortUrl(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.CreateShortUrl(context.Background(), "https://google.com/", "goog", 1, url.ShortUrlOptions{})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrEMailNotVerified)
+	}
+}
+
+func TestCreateShortUrlVerifiedEMail(t *testing.T) {
+	sut := createSUTRequiringVerifiedEMail(t)
+
+	sut.accountRepo.EXPECT().Get(gomock.Any(), uint64(1)).
+		Return(&types.Account{ID: 1, EMailVerified: true}, nil).Times(1)
+	sut.urlRepo.EXPECT().CreateShortUrl(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	slug, err := sut.service.CreateShortUrl(context.Background(), "https://google.com/", "goog", 1, url.ShortUrlOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "goog", slug)
+}
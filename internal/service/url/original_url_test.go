@@ -0,0 +1,180 @@
+package url_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/repository"
+	"github.com/h3isenbug/url-shortener/internal/service/url"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOriginalUrlSuccessful(t *testing.T) {
+	const slug = "goog"
+	expiresAt := time.Now().UTC().Add(time.Hour)
+	maxVisits := uint64(10)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID:          1,
+		OriginalUrl: "https://google.com/",
+		Slug:        slug,
+		TotalVisits: 9,
+		ExpiresAt:   &expiresAt,
+		MaxVisits:   &maxVisits,
+	}, nil).Times(1)
+	sut.visitorService.EXPECT().IsFirstVisit(gomock.Any(), uint64(1), "visitor", false).Return(true, nil).Times(1)
+	sut.urlRepo.EXPECT().IncrementVisits(gomock.Any(), gomock.Eq(slug), true).Return(nil).Times(1)
+	sut.visitRecorder.EXPECT().Record(gomock.Any()).DoAndReturn(
+		func(visit types.Visit) error {
+			assert.Equal(t, uint64(1), visit.UrlID)
+			assert.Equal(t, "192.168.10.0", visit.ClientIP)
+			assert.Equal(t, "https://twitter.com/", visit.Referrer)
+			assert.True(t, visit.Unique)
+			assert.True(t, visit.Counted)
+			assert.False(t, visit.VisitedAt.IsZero())
+			return nil
+		},
+	).Times(1)
+
+	originalUrl, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{
+		Referrer:  "https://twitter.com/",
+		ClientIP:  "192.168.10.42",
+		VisitorID: "visitor",
+	}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://google.com/", originalUrl)
+}
+
+func TestGetOriginalUrlUnlimitedIsNotCountedRightAway(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID:          1,
+		OriginalUrl: "https://google.com/",
+		Slug:        slug,
+	}, nil).Times(1)
+	sut.visitRecorder.EXPECT().Record(gomock.Any()).DoAndReturn(
+		func(visit types.Visit) error {
+			assert.False(t, visit.Counted)
+			return nil
+		},
+	).Times(1)
+
+	originalUrl, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://google.com/", originalUrl)
+}
+
+func TestGetOriginalUrlExpired(t *testing.T) {
+	const slug = "goog"
+	expiresAt := time.Now().UTC().Add(-time.Minute)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID:          1,
+		OriginalUrl: "https://google.com/",
+		Slug:        slug,
+		ExpiresAt:   &expiresAt,
+	}, nil).Times(1)
+	sut.urlRepo.EXPECT().IncrementVisits(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{}, "")
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrUrlExpired)
+		assert.ErrorIs(t, err, url.ErrUrlGone)
+	}
+}
+
+func TestGetOriginalUrlDisabled(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID:          1,
+		OriginalUrl: "https://google.com/",
+		Slug:        slug,
+		Disabled:    true,
+	}, nil).Times(1)
+	sut.urlRepo.EXPECT().IncrementVisits(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.visitRecorder.EXPECT().Record(gomock.Any()).Times(0)
+
+	_, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{}, "")
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrUrlDisabled)
+		assert.ErrorIs(t, err, url.ErrUrlGone)
+	}
+}
+
+func TestGetOriginalUrlExhausted(t *testing.T) {
+	const slug = "goog"
+	maxVisits := uint64(3)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID:          1,
+		OriginalUrl: "https://google.com/",
+		Slug:        slug,
+		TotalVisits: 3,
+		MaxVisits:   &maxVisits,
+	}, nil).Times(1)
+	sut.urlRepo.EXPECT().IncrementVisits(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{}, "")
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrVisitLimitReached)
+	}
+}
+
+func TestGetOriginalUrlLimitReachedConcurrently(t *testing.T) {
+	const slug = "goog"
+	maxVisits := uint64(3)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID:          1,
+		OriginalUrl: "https://google.com/",
+		Slug:        slug,
+		TotalVisits: 2,
+		MaxVisits:   &maxVisits,
+	}, nil).Times(1)
+	sut.urlRepo.EXPECT().IncrementVisits(gomock.Any(), gomock.Eq(slug), false).Return(repository.ErrLimitReached).Times(1)
+
+	_, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{}, "")
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrVisitLimitReached)
+	}
+}
+
+func TestGetOriginalUrlDeletedConcurrently(t *testing.T) {
+	const slug = "goog"
+	maxVisits := uint64(3)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID:          1,
+		OriginalUrl: "https://google.com/",
+		Slug:        slug,
+		TotalVisits: 2,
+		MaxVisits:   &maxVisits,
+	}, nil).Times(1)
+	sut.urlRepo.EXPECT().IncrementVisits(gomock.Any(), gomock.Eq(slug), false).Return(repository.ErrNotFound).Times(1)
+
+	_, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{}, "")
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.NotErrorIs(t, err, url.ErrUrlGone)
+	}
+}
@@ -0,0 +1,123 @@
+package url_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/service/url"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const clientIP = "192.168.10.42"
+
+func createProtectedUrl(t *testing.T, slug, password string) *types.Url {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	require.NoError(t, err)
+
+	hashString := string(hash)
+	return &types.Url{
+		ID:           1,
+		OriginalUrl:  "https://google.com/",
+		Slug:         slug,
+		PasswordHash: &hashString,
+	}
+}
+
+func TestProtectedUrlRequiresPassword(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(createProtectedUrl(t, slug, "open sesame"), nil).Times(2)
+	sut.urlRepo.EXPECT().IncrementVisits(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{}, "")
+	assert.ErrorIs(t, err, url.ErrPasswordRequired)
+
+	_, err = sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{}, "forged.token")
+	assert.ErrorIs(t, err, url.ErrPasswordRequired)
+}
+
+func TestUnlockUrlWrongPassword(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(createProtectedUrl(t, slug, "open sesame"), nil).Times(1)
+	sut.loginAttemptRepo.EXPECT().GetBlock(gomock.Any(), "unlock-goog-"+clientIP).Return(types.LoginBlock{}, nil).Times(1)
+	sut.loginAttemptRepo.EXPECT().AddFailure(
+		gomock.Any(), "unlock-goog-"+clientIP, unlockThrottle.FailureWindow, unlockThrottle.Delays(),
+	).Return(int64(1), nil).Times(1)
+
+	token, _, err := sut.service.UnlockUrl(context.Background(), slug, "close sesame", clientIP)
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrWrongPassword)
+	}
+	assert.Empty(t, token)
+}
+
+func TestUnlockUrlSuccessful(t *testing.T) {
+	const slug = "goog"
+	protectedUrl := createProtectedUrl(t, slug, "open sesame")
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(protectedUrl, nil).Times(2)
+	sut.visitRecorder.EXPECT().Record(gomock.Any()).Return(nil).Times(1)
+	sut.loginAttemptRepo.EXPECT().GetBlock(gomock.Any(), "unlock-goog-"+clientIP).Return(types.LoginBlock{}, nil).Times(1)
+	sut.loginAttemptRepo.EXPECT().ResetFailures(gomock.Any(), "unlock-goog-"+clientIP).Return(nil).Times(1)
+
+	token, _, err := sut.service.UnlockUrl(context.Background(), slug, "open sesame", clientIP)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	originalUrl, err := sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{}, token)
+	require.NoError(t, err)
+	assert.Equal(t, protectedUrl.OriginalUrl, originalUrl)
+}
+
+func TestUnlockTokenIsBoundToPassword(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUT(t)
+
+	gomock.InOrder(
+		sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(createProtectedUrl(t, slug, "open sesame"), nil),
+		sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(createProtectedUrl(t, slug, "new password"), nil),
+	)
+	sut.loginAttemptRepo.EXPECT().GetBlock(gomock.Any(), gomock.Any()).Return(types.LoginBlock{}, nil).Times(1)
+	sut.loginAttemptRepo.EXPECT().ResetFailures(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	token, _, err := sut.service.UnlockUrl(context.Background(), slug, "open sesame", clientIP)
+	require.NoError(t, err)
+
+	_, err = sut.service.GetOriginalUrl(context.Background(), slug, &types.Visit{}, token)
+	assert.ErrorIs(t, err, url.ErrPasswordRequired)
+}
+
+func TestUnlockUrlWhileThrottled(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(createProtectedUrl(t, slug, "open sesame"), nil).Times(1)
+	sut.loginAttemptRepo.EXPECT().GetBlock(gomock.Any(), "unlock-goog-"+clientIP).
+		Return(types.LoginBlock{RetryAfter: time.Second * 8}, nil).Times(1)
+	// even the right password is not checked
+	sut.loginAttemptRepo.EXPECT().AddFailure(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.loginAttemptRepo.EXPECT().ResetFailures(gomock.Any(), gomock.Any()).Times(0)
+
+	token, _, err := sut.service.UnlockUrl(context.Background(), slug, "open sesame", clientIP)
+	assert.ErrorIs(t, err, url.ErrTooManyUnlockAttempts)
+	assert.NotErrorIs(t, err, url.ErrWrongPassword)
+	assert.Empty(t, token)
+
+	var tooManyAttempts *url.TooManyUnlockAttemptsError
+	require.ErrorAs(t, err, &tooManyAttempts)
+	assert.Equal(t, time.Second*8, tooManyAttempts.RetryAfter)
+}
@@ -0,0 +1,92 @@
+package url_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/service/url"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackUrlSuccessful(t *testing.T) {
+	const slug = "goog"
+	const accountID = 1
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID:          10,
+		OriginalUrl: "https://duckduckgo.com/",
+		Slug:        slug,
+		AccountID:   accountID,
+	}, nil).Times(1)
+	sut.urlRepo.EXPECT().GetRevision(gomock.Any(), uint64(10), uint64(3)).Return(&types.UrlRevision{
+		ID:          3,
+		UrlID:       10,
+		OriginalUrl: "https://google.com/",
+		ChangedBy:   accountID,
+	}, nil).Times(1)
+	sut.urlRepo.EXPECT().UpdateOriginalUrl(
+		gomock.Any(), uint64(accountID), gomock.Eq(slug), gomock.Eq("https://google.com/"),
+	).Return(nil).Times(1)
+
+	require.NoError(t, sut.service.RollbackUrl(context.Background(), accountID, slug, 3))
+}
+
+func TestRollbackUrlOfAnotherAccount(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID:        10,
+		Slug:      slug,
+		AccountID: 2,
+	}, nil).Times(1)
+	sut.urlRepo.EXPECT().GetRevision(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.urlRepo.EXPECT().UpdateOriginalUrl(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.RollbackUrl(context.Background(), 1, slug, 3)
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrNotAuthorized)
+	}
+}
+
+func TestUpdateOriginalUrlEmpty(t *testing.T) {
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().UpdateOriginalUrl(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.UpdateOriginalUrl(context.Background(), 1, "goog", "")
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, url.ErrEmptyOriginalUrl)
+	}
+}
+
+func TestUpdateUrlAppliesEveryChangeAtOnce(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUT(t)
+
+	originalUrl, disabled := "https://google.com/", true
+	sut.urlRepo.EXPECT().UpdateUrl(gomock.Any(), uint64(1), slug, &originalUrl, &disabled).Return(nil).Times(1)
+	sut.urlRepo.EXPECT().UpdateOriginalUrl(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	sut.urlRepo.EXPECT().SetUrlState(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := sut.service.UpdateUrl(context.Background(), 1, slug, url.UrlUpdate{OriginalUrl: &originalUrl, Disabled: &disabled})
+	require.NoError(t, err)
+}
+
+func TestUpdateUrlWithEmptyOriginalUrl(t *testing.T) {
+	sut := createSUT(t)
+
+	// the url is not disabled either
+	sut.urlRepo.EXPECT().UpdateUrl(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	originalUrl, disabled := "", true
+	err := sut.service.UpdateUrl(context.Background(), 1, "goog", url.UrlUpdate{OriginalUrl: &originalUrl, Disabled: &disabled})
+	assert.ErrorIs(t, err, url.ErrEmptyOriginalUrl)
+}
@@ -0,0 +1,88 @@
+package url
+
+import (
+	"context"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+const maxStatsBuckets = 1000
+
+func bucketStart(t time.Time, granularity types.StatsGranularity, location *time.Location) time.Time {
+	t = t.In(location)
+	if granularity == types.StatsGranularityDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+	}
+
+	// rollups are kept per UTC hour, which is why the api refuses timezones with a fractional offset. were one given,
+	// its hours would start at the same minute as in UTC and its days would not line up with the rollups.
+	return t.Truncate(time.Hour)
+}
+
+func nextBucket(bucket time.Time, granularity types.StatsGranularity) time.Time {
+	if granularity == types.StatsGranularityDay {
+		// days are not always 24 hours long when daylight saving time kicks in.
+		return bucket.AddDate(0, 0, 1)
+	}
+
+	return bucket.Add(time.Hour)
+}
+
+// emptyBuckets returns every bucket overlapping [from, to), with no visits in them.
+func emptyBuckets(from, to time.Time, granularity types.StatsGranularity, location *time.Location) []types.VisitStatsBucket {
+	var buckets []types.VisitStatsBucket
+	for bucket := bucketStart(from, granularity, location); bucket.Before(to); bucket = nextBucket(bucket, granularity) {
+		buckets = append(buckets, types.VisitStatsBucket{Bucket: bucket})
+	}
+
+	return buckets
+}
+
+func (s v1) GetUrlStats(
+	ctx context.Context, accountID uint64, slug string,
+	from, to time.Time, granularity types.StatsGranularity, location *time.Location,
+) ([]types.VisitStatsBucket, error) {
+	bucketLength := time.Hour
+	switch granularity {
+	case types.StatsGranularityHour:
+	case types.StatsGranularityDay:
+		bucketLength = 24 * time.Hour
+	default:
+		return nil, ErrInvalidGranularity
+	}
+
+	if !from.Before(to) {
+		return nil, ErrInvalidStatsRange
+	}
+	if to.Sub(from) > maxStatsBuckets*bucketLength {
+		return nil, ErrStatsRangeTooLarge
+	}
+
+	url, err := s.getOwnedUrl(ctx, accountID, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := emptyBuckets(from, to, granularity, location)
+	rangeEnd := nextBucket(buckets[len(buckets)-1].Bucket, granularity)
+
+	stats, err := s.visitRepository.GetStats(ctx, url.ID, buckets[0].Bucket, rangeEnd, granularity, location)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[int64]int, len(buckets))
+	for i, bucket := range buckets {
+		indexes[bucket.Bucket.Unix()] = i
+	}
+	for _, stat := range stats {
+		if i, found := indexes[stat.Bucket.Unix()]; found {
+			buckets[i].TotalVisits = stat.TotalVisits
+			buckets[i].UniqueVisits = stat.UniqueVisits
+			buckets[i].BotVisits = stat.BotVisits
+		}
+	}
+
+	return buckets, nil
+}
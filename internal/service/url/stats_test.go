@@ -0,0 +1,115 @@
+package url_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/h3isenbug/url-shortener/internal/service/url"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUrlStatsFillsEmptyDays(t *testing.T) {
+	const (
+		slug      = "goog"
+		accountID = 3
+	)
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID: 1, Slug: slug, AccountID: accountID,
+	}, nil).Times(1)
+	sut.visitRepo.EXPECT().GetStats(
+		gomock.Any(), uint64(1),
+		time.Date(2021, 10, 1, 0, 0, 0, 0, moscow), time.Date(2021, 10, 4, 0, 0, 0, 0, moscow),
+		types.StatsGranularityDay, moscow,
+	).Return([]types.VisitStatsBucket{
+		{Bucket: time.Date(2021, 10, 2, 0, 0, 0, 0, moscow), TotalVisits: 5, UniqueVisits: 2, BotVisits: 4},
+	}, nil).Times(1)
+
+	buckets, err := sut.service.GetUrlStats(
+		context.Background(), accountID, slug,
+		time.Date(2021, 10, 1, 9, 0, 0, 0, moscow), time.Date(2021, 10, 3, 12, 0, 0, 0, moscow),
+		types.StatsGranularityDay, moscow,
+	)
+	require.NoError(t, err)
+	require.Len(t, buckets, 3)
+
+	assert.True(t, buckets[0].Bucket.Equal(time.Date(2021, 10, 1, 0, 0, 0, 0, moscow)))
+	assert.Zero(t, buckets[0].TotalVisits)
+	assert.EqualValues(t, 5, buckets[1].TotalVisits)
+	assert.EqualValues(t, 2, buckets[1].UniqueVisits)
+	assert.EqualValues(t, 4, buckets[1].BotVisits)
+	assert.Zero(t, buckets[2].TotalVisits)
+}
+
+func TestGetUrlStatsHoursAcrossDaylightSavingTime(t *testing.T) {
+	const (
+		slug      = "goog"
+		accountID = 3
+	)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID: 1, Slug: slug, AccountID: accountID,
+	}, nil).Times(1)
+	sut.visitRepo.EXPECT().GetStats(gomock.Any(), uint64(1), gomock.Any(), gomock.Any(), types.StatsGranularityHour, berlin).
+		Return(nil, nil).Times(1)
+
+	// clocks went back from 03:00 to 02:00 on this night, so the day had 25 hours.
+	buckets, err := sut.service.GetUrlStats(
+		context.Background(), accountID, slug,
+		time.Date(2021, 10, 31, 0, 0, 0, 0, berlin), time.Date(2021, 11, 1, 0, 0, 0, 0, berlin),
+		types.StatsGranularityHour, berlin,
+	)
+	require.NoError(t, err)
+	assert.Len(t, buckets, 25)
+}
+
+func TestGetUrlStatsValidation(t *testing.T) {
+	now := time.Now().UTC()
+
+	testCases := []struct {
+		name        string
+		from, to    time.Time
+		granularity types.StatsGranularity
+		err         error
+	}{
+		{"unknown granularity", now.Add(-time.Hour), now, "week", url.ErrInvalidGranularity},
+		{"reversed range", now, now.Add(-time.Hour), types.StatsGranularityHour, url.ErrInvalidStatsRange},
+		{"too many buckets", now.AddDate(-1, 0, 0), now, types.StatsGranularityHour, url.ErrStatsRangeTooLarge},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			sut := createSUT(t)
+
+			_, err := sut.service.GetUrlStats(context.Background(), 3, "goog", testCase.from, testCase.to, testCase.granularity, time.UTC)
+			assert.ErrorIs(t, err, testCase.err)
+			assert.ErrorIs(t, err, url.ErrValidationFailed)
+		})
+	}
+}
+
+func TestGetUrlStatsOfSomeoneElsesUrl(t *testing.T) {
+	const slug = "goog"
+
+	sut := createSUT(t)
+
+	sut.urlRepo.EXPECT().GetBySlug(gomock.Any(), gomock.Eq(slug)).Return(&types.Url{
+		ID: 1, Slug: slug, AccountID: 4,
+	}, nil).Times(1)
+
+	now := time.Now().UTC()
+	_, err := sut.service.GetUrlStats(context.Background(), 3, slug, now.Add(-time.Hour), now, types.StatsGranularityHour, time.UTC)
+	assert.ErrorIs(t, err, url.ErrNotAuthorized)
+}
@@ -3,17 +3,92 @@ package url
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/h3isenbug/url-shortener/internal/types"
 )
 
 var (
-	ErrNotAuthorized = errors.New("user is not authorized to do the given action")
+	ErrNotAuthorized    = errors.New("user is not authorized to do the given action")
+	ErrEMailNotVerified = fmt.Errorf("%w: email address of the account is not verified", ErrNotAuthorized)
+
+	ErrUrlGone           = errors.New("url is no longer available")
+	ErrUrlExpired        = fmt.Errorf("%w: url is expired", ErrUrlGone)
+	ErrVisitLimitReached = fmt.Errorf("%w: url has reached its visit limit", ErrUrlGone)
+	ErrUrlDisabled       = fmt.Errorf("%w: url is disabled", ErrUrlGone)
+
+	ErrPasswordRequired = errors.New("url is protected by a password")
+	ErrWrongPassword    = fmt.Errorf("%w: wrong password", ErrPasswordRequired)
+
+	// ErrTooManyUnlockAttempts is not ErrWrongPassword, the password is not checked while attempts are throttled.
+	ErrTooManyUnlockAttempts = errors.New("too many failed unlock attempts")
+
+	ErrValidationFailed  = errors.New("validation error")
+	ErrExpirationInPast  = fmt.Errorf("%w: expiration time is in the past", ErrValidationFailed)
+	ErrInvalidVisitLimit = fmt.Errorf("%w: visit limit must be greater than zero", ErrValidationFailed)
+	ErrEmptyOriginalUrl  = fmt.Errorf("%w: original url must not be empty", ErrValidationFailed)
+	ErrPasswordTooLong   = fmt.Errorf("%w: password must not be longer than 72 bytes", ErrValidationFailed)
+
+	ErrInvalidGranularity = fmt.Errorf("%w: granularity must be either hour or day", ErrValidationFailed)
+	ErrInvalidStatsRange  = fmt.Errorf("%w: start of the range must be before its end", ErrValidationFailed)
+	ErrStatsRangeTooLarge = fmt.Errorf("%w: range has too many buckets", ErrValidationFailed)
+
+	ErrInvalidDimension      = fmt.Errorf("%w: unknown breakdown dimension", ErrValidationFailed)
+	ErrInvalidBreakdownLimit = fmt.Errorf("%w: breakdown limit is out of range", ErrValidationFailed)
 )
 
+// TooManyUnlockAttemptsError is returned instead of checking the password of a url while failed attempts from the
+// client are throttled.
+type TooManyUnlockAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyUnlockAttemptsError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrTooManyUnlockAttempts.Error(), e.RetryAfter)
+}
+
+func (e *TooManyUnlockAttemptsError) Unwrap() error {
+	return ErrTooManyUnlockAttempts
+}
+
+type ShortUrlOptions struct {
+	ExpiresAt *time.Time
+	MaxVisits *uint64
+	Password  string
+}
+
+// UrlUpdate holds the changes to a url. fields left nil are not changed.
+type UrlUpdate struct {
+	OriginalUrl *string
+	Disabled    *bool
+}
+
 type Service interface {
-	GetOriginalUrl(ctx context.Context, slug string, newVisit bool) (originalUrl string, err error)
-	CreateShortUrl(ctx context.Context, originalUrl, recommendedSlug string, accountID uint64) (slug string, err error)
+	GetOriginalUrl(ctx context.Context, slug string, visit *types.Visit, unlockToken string) (originalUrl string, err error)
+	// UnlockUrl throttles failed attempts of a client ip on every url on its own.
+	UnlockUrl(ctx context.Context, slug, password, clientIP string) (unlockToken string, validUntil time.Time, err error)
+	CreateShortUrl(ctx context.Context, originalUrl, recommendedSlug string, accountID uint64, options ShortUrlOptions) (slug string, err error)
 	GetAccountUrls(ctx context.Context, accountID uint64, cursor string) (items []types.Url, nextCursor string, err error)
 	SetUrlState(ctx context.Context, accountID uint64, slug string, disabled bool) error
+	UpdateOriginalUrl(ctx context.Context, accountID uint64, slug, originalUrl string) error
+	// UpdateUrl applies every change of update or none of them.
+	UpdateUrl(ctx context.Context, accountID uint64, slug string, update UrlUpdate) error
+	GetUrlRevisions(ctx context.Context, accountID uint64, slug, cursor string) (items []types.UrlRevision, nextCursor string, err error)
+	RollbackUrl(ctx context.Context, accountID uint64, slug string, revisionID uint64) error
+	GetUrlVisits(ctx context.Context, accountID uint64, slug, cursor string) (items []types.Visit, nextCursor string, err error)
+	GetUrlStats(
+		ctx context.Context, accountID uint64, slug string,
+		from, to time.Time, granularity types.StatsGranularity, location *time.Location,
+	) ([]types.VisitStatsBucket, error)
+	GetUrlBreakdown(
+		ctx context.Context, accountID uint64, slug string,
+		dimension types.BreakdownDimension, from, to time.Time, limit int,
+	) ([]types.BreakdownItem, error)
+	GetAccountBreakdown(
+		ctx context.Context, accountID uint64, dimension types.BreakdownDimension, from, to time.Time, limit int,
+	) ([]types.BreakdownItem, error)
+	GetUrlGeoBreakdown(
+		ctx context.Context, accountID uint64, slug string, from, to time.Time, limit int,
+	) ([]types.GeoBreakdownItem, error)
 }
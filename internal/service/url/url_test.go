@@ -0,0 +1,86 @@
+package url_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockAccount "github.com/h3isenbug/url-shortener/internal/repository/account/mock"
+	mockLoginAttempt "github.com/h3isenbug/url-shortener/internal/repository/loginAttempt/mock"
+	mockUrl "github.com/h3isenbug/url-shortener/internal/repository/url/mock"
+	mockVisit "github.com/h3isenbug/url-shortener/internal/repository/visit/mock"
+	"github.com/h3isenbug/url-shortener/internal/service/url"
+	mockVisitService "github.com/h3isenbug/url-shortener/internal/service/visit/mock"
+	mockVisitor "github.com/h3isenbug/url-shortener/internal/service/visitor/mock"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/log"
+	"github.com/h3isenbug/url-shortener/pkg/signer"
+	"github.com/stretchr/testify/require"
+)
+
+const randomSlugLength = 7
+
+var unlockThrottle = types.LoginThrottlePolicy{
+	FreeAttempts: 5, BaseDelay: time.Second, LockoutThreshold: 20, LockoutDuration: time.Minute * 15,
+	FailureWindow: time.Hour,
+}
+
+type sut struct {
+	service          url.Service
+	accountRepo      *mockAccount.MockRepository
+	urlRepo          *mockUrl.MockRepository
+	loginAttemptRepo *mockLoginAttempt.MockRepository
+	visitRepo        *mockVisit.MockRepository
+	visitRecorder    *mockVisitService.MockRecorder
+	visitorService   *mockVisitor.MockService
+}
+
+func createSUT(t *testing.T) sut {
+	return newSUT(t, types.BotPolicySeparate, false)
+}
+
+func createSUTWithBotPolicy(t *testing.T, botPolicy types.BotPolicy) sut {
+	return newSUT(t, botPolicy, false)
+}
+
+func createSUTRequiringVerifiedEMail(t *testing.T) sut {
+	return newSUT(t, types.BotPolicySeparate, true)
+}
+
+func newSUT(t *testing.T, botPolicy types.BotPolicy, requireVerifiedEMail bool) sut {
+	ctrl := gomock.NewController(t)
+
+	s := sut{
+		accountRepo:      mockAccount.NewMockRepository(ctrl),
+		urlRepo:          mockUrl.NewMockRepository(ctrl),
+		loginAttemptRepo: mockLoginAttempt.NewMockRepository(ctrl),
+		visitRepo:        mockVisit.NewMockRepository(ctrl),
+		visitRecorder:    mockVisitService.NewMockRecorder(ctrl),
+		visitorService:   mockVisitor.NewMockService(ctrl),
+	}
+
+	logger, err := log.NewZapLoggingService("")
+	require.NoError(t, err)
+
+	unlockTokenSigner, err := signer.NewHMACSignerV1([]byte("unlock-secret-for-the-tests-of-urls"))
+	require.NoError(t, err)
+
+	s.service = url.NewUrlServiceV1(
+		logger,
+		s.accountRepo,
+		s.urlRepo,
+		s.visitRepo,
+		s.visitRecorder,
+		s.visitorService,
+		randomSlugLength,
+		unlockTokenSigner,
+		time.Hour,
+		s.loginAttemptRepo,
+		unlockThrottle,
+		true,
+		botPolicy,
+		requireVerifiedEMail,
+	)
+
+	return s
+}
@@ -3,55 +3,278 @@ package url
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	mathRand "math/rand"
 	"strings"
+	"time"
 
 	"github.com/h3isenbug/url-shortener/internal/repository"
+	accountRepository "github.com/h3isenbug/url-shortener/internal/repository/account"
+	loginAttemptRepository "github.com/h3isenbug/url-shortener/internal/repository/loginAttempt"
 	urlRepository "github.com/h3isenbug/url-shortener/internal/repository/url"
+	visitRepository "github.com/h3isenbug/url-shortener/internal/repository/visit"
+	visitService "github.com/h3isenbug/url-shortener/internal/service/visit"
+	"github.com/h3isenbug/url-shortener/internal/service/visitor"
 	"github.com/h3isenbug/url-shortener/internal/types"
 	"github.com/h3isenbug/url-shortener/pkg/log"
+	"github.com/h3isenbug/url-shortener/pkg/signer"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
 type v1 struct {
-	logger        log.Logger
-	urlRepository urlRepository.Repository
+	logger            log.Logger
+	accountRepository accountRepository.Repository
+	urlRepository     urlRepository.Repository
+	visitRepository   visitRepository.Repository
+	visitRecorder     visitService.Recorder
+	visitorService    visitor.Service
 
 	randomSlugLength int
+
+	unlockTokenSigner   signer.Signer
+	unlockTokenLifespan time.Duration
+	// failed unlock attempts are counted along with failed logins, under subjects of their own.
+	loginAttemptRepository loginAttemptRepository.Repository
+	unlockThrottle         types.LoginThrottlePolicy
+
+	anonymizeClientIPs bool
+	botPolicy          types.BotPolicy
+
+	requireVerifiedEMail bool
 }
 
-func NewUrlServiceV1(logger log.Logger, urlRepository urlRepository.Repository, randomSlugLength int) Service {
+func NewUrlServiceV1(
+	logger log.Logger,
+	accountRepository accountRepository.Repository,
+	urlRepository urlRepository.Repository,
+	visitRepository visitRepository.Repository,
+	visitRecorder visitService.Recorder,
+	visitorService visitor.Service,
+	randomSlugLength int,
+
+	unlockTokenSigner signer.Signer,
+	unlockTokenLifespan time.Duration,
+	loginAttemptRepository loginAttemptRepository.Repository,
+	unlockThrottle types.LoginThrottlePolicy,
+
+	anonymizeClientIPs bool,
+	botPolicy types.BotPolicy,
+
+	requireVerifiedEMail bool,
+) Service {
 	return &v1{
-		logger:           logger,
-		urlRepository:    urlRepository,
-		randomSlugLength: randomSlugLength,
+		logger:              logger,
+		accountRepository:   accountRepository,
+		urlRepository:       urlRepository,
+		visitRepository:     visitRepository,
+		visitRecorder:       visitRecorder,
+		visitorService:      visitorService,
+		randomSlugLength:    randomSlugLength,
+		unlockTokenSigner:   unlockTokenSigner,
+		unlockTokenLifespan: unlockTokenLifespan,
+
+		loginAttemptRepository: loginAttemptRepository,
+		unlockThrottle:         unlockThrottle,
+
+		anonymizeClientIPs: anonymizeClientIPs,
+		botPolicy:          botPolicy,
+
+		requireVerifiedEMail: requireVerifiedEMail,
 	}
 }
 
-func (s v1) GetOriginalUrl(ctx context.Context, slug string, newVisit bool) (originalUrl string, err error) {
+func (s v1) getAvailableUrl(ctx context.Context, slug string) (*types.Url, error) {
 	url, err := s.urlRepository.GetBySlug(ctx, slug)
 	if err != nil {
-		return "", fmt.Errorf("failed to get url by slug: %w", err)
+		return nil, fmt.Errorf("failed to get url by slug: %w", err)
 	}
 
-	if err := s.urlRepository.IncrementVisits(ctx, slug, newVisit); err != nil {
-		return "", fmt.Errorf("failed to increment visit metrics: %w", err)
+	if url.Disabled {
+		return nil, ErrUrlDisabled
+	}
+	if url.IsExpired(time.Now().UTC()) {
+		return nil, ErrUrlExpired
+	}
+	if url.IsExhausted() {
+		return nil, ErrVisitLimitReached
+	}
+
+	return url, nil
+}
+
+// unlockTokenData binds an unlock token to the current password of the url,
+// so that changing the password locks out everyone who unlocked the url before.
+func unlockTokenData(url *types.Url) string {
+	digest := sha256.Sum256([]byte(*url.PasswordHash))
+
+	return url.Slug + ":" + base64.RawURLEncoding.EncodeToString(digest[:8])
+}
+
+func (s v1) GetOriginalUrl(ctx context.Context, slug string, visit *types.Visit, unlockToken string) (originalUrl string, err error) {
+	url, err := s.getAvailableUrl(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+
+	if url.IsProtected() {
+		if unlockToken == "" {
+			return "", ErrPasswordRequired
+		}
+
+		data, err := s.unlockTokenSigner.Verify(unlockToken)
+		if err != nil || data != unlockTokenData(url) {
+			return "", ErrPasswordRequired
+		}
+	}
+
+	if visit.Bot {
+		switch s.botPolicy {
+		case types.BotPolicyIgnore:
+			return url.OriginalUrl, nil
+		case types.BotPolicyCount:
+			visit.Bot = false
+		}
+	}
+
+	visit.Unique = false
+	if visit.VisitorID != "" && !visit.Bot {
+		firstVisit, err := s.visitorService.IsFirstVisit(ctx, url.ID, visit.VisitorID, visit.VisitorHashed)
+		if err != nil {
+			s.logger.Warn("failed to tell whether the visit is unique", map[string]interface{}{
+				"slug":         slug,
+				"errorMessage": err.Error(),
+			})
+		}
+
+		visit.Unique = firstVisit
+	}
+
+	// limited urls are counted right away, since the limit can not be enforced on buffered visits.
+	// bots that are counted separately do not use up the limit.
+	if url.MaxVisits != nil && !visit.Bot {
+		err = s.urlRepository.IncrementVisits(ctx, slug, visit.Unique)
+		if errors.Is(err, repository.ErrLimitReached) {
+			return "", ErrVisitLimitReached
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to increment visit metrics: %w", err)
+		}
+
+		visit.Counted = true
+	}
+
+	visit.UrlID = url.ID
+	visit.VisitedAt = time.Now().UTC()
+	s.sanitizeVisit(visit)
+	if err := s.visitRecorder.Record(*visit); err != nil {
+		s.logger.Warn("failed to record visit", map[string]interface{}{
+			"slug":         slug,
+			"errorMessage": err.Error(),
+		})
 	}
 
 	return url.OriginalUrl, nil
 }
 
-func (s v1) CreateShortUrl(ctx context.Context, originalUrl, recommendedShortLink string, accountID uint64) (string, error) {
+func (s v1) UnlockUrl(ctx context.Context, slug, password, clientIP string) (string, time.Time, error) {
+	url, err := s.getAvailableUrl(ctx, slug)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if !url.IsProtected() {
+		return "", time.Time{}, nil
+	}
+
+	// the block is checked before bcrypt, which is what guessing would otherwise cost
+	throttleSubject := "unlock-" + url.Slug + "-" + clientIP
+	block, err := s.loginAttemptRepository.GetBlock(ctx, throttleSubject)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to check failed unlock attempts: %w", err)
+	}
+	if block.RetryAfter > 0 {
+		return "", time.Time{}, &TooManyUnlockAttemptsError{RetryAfter: block.RetryAfter}
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(*url.PasswordHash), []byte(password)); err != nil {
+		_, err := s.loginAttemptRepository.AddFailure(
+			ctx, throttleSubject, s.unlockThrottle.FailureWindow, s.unlockThrottle.Delays(),
+		)
+		if err != nil {
+			s.logger.Error("failed to record failed unlock attempt", map[string]interface{}{
+				"subject":      throttleSubject,
+				"errorMessage": err.Error(),
+			})
+		}
+
+		return "", time.Time{}, ErrWrongPassword
+	}
+
+	if err := s.loginAttemptRepository.ResetFailures(ctx, throttleSubject); err != nil {
+		s.logger.Error("failed to reset failed unlock attempts", map[string]interface{}{
+			"subject":      throttleSubject,
+			"errorMessage": err.Error(),
+		})
+	}
+
+	validUntil := time.Now().UTC().Add(s.unlockTokenLifespan)
+
+	return s.unlockTokenSigner.Sign(unlockTokenData(url), validUntil), validUntil, nil
+}
+
+func (s v1) CreateShortUrl(
+	ctx context.Context, originalUrl, recommendedShortLink string, accountID uint64, options ShortUrlOptions,
+) (string, error) {
+	if s.requireVerifiedEMail {
+		account, err := s.accountRepository.Get(ctx, accountID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get account: %w", err)
+		}
+		if !account.EMailVerified {
+			return "", ErrEMailNotVerified
+		}
+	}
+
+	if options.ExpiresAt != nil && !options.ExpiresAt.After(time.Now().UTC()) {
+		return "", ErrExpirationInPast
+	}
+	if options.MaxVisits != nil && *options.MaxVisits == 0 {
+		return "", ErrInvalidVisitLimit
+	}
+	if len(options.Password) > types.MaxPasswordLength {
+		return "", ErrPasswordTooLong
+	}
+
+	var passwordHash *string
+	if options.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(options.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+
+		hashString := string(hash)
+		passwordHash = &hashString
+	}
+
 	var shortLink string
 	if recommendedShortLink != "" {
 		shortLink = recommendedShortLink
 	} else {
 		shortLink = s.generateRandomString(s.randomSlugLength)
 	}
-	err := s.urlRepository.CreateShortUrl(ctx, originalUrl, shortLink, accountID)
+	err := s.urlRepository.CreateShortUrl(ctx, &types.Url{
+		OriginalUrl:  originalUrl,
+		Slug:         shortLink,
+		AccountID:    accountID,
+		ExpiresAt:    options.ExpiresAt,
+		MaxVisits:    options.MaxVisits,
+		PasswordHash: passwordHash,
+	})
 	if errors.Is(err, repository.ErrUniquenessViolated) {
 		if recommendedShortLink == "" {
 			return "", fmt.Errorf("generated random string(%s) collided. this is very unlikely", shortLink)
@@ -78,6 +301,77 @@ func (s v1) SetUrlState(ctx context.Context, accountID uint64, slug string, disa
 	return nil
 }
 
+func (s v1) UpdateOriginalUrl(ctx context.Context, accountID uint64, slug, originalUrl string) error {
+	if originalUrl == "" {
+		return ErrEmptyOriginalUrl
+	}
+
+	if err := s.urlRepository.UpdateOriginalUrl(ctx, accountID, slug, originalUrl); err != nil {
+		return fmt.Errorf("failed to update original url of url(%s) of account(%d): %w", slug, accountID, err)
+	}
+
+	return nil
+}
+
+func (s v1) UpdateUrl(ctx context.Context, accountID uint64, slug string, update UrlUpdate) error {
+	if update.OriginalUrl != nil && *update.OriginalUrl == "" {
+		return ErrEmptyOriginalUrl
+	}
+
+	err := s.urlRepository.UpdateUrl(ctx, accountID, slug, update.OriginalUrl, update.Disabled)
+	if err != nil {
+		return fmt.Errorf("failed to update url(%s) of account(%d): %w", slug, accountID, err)
+	}
+
+	return nil
+}
+
+func (s v1) getOwnedUrl(ctx context.Context, accountID uint64, slug string) (*types.Url, error) {
+	url, err := s.urlRepository.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get url by slug: %w", err)
+	}
+
+	if url.AccountID != accountID {
+		return nil, ErrNotAuthorized
+	}
+
+	return url, nil
+}
+
+func (s v1) GetUrlRevisions(ctx context.Context, accountID uint64, slug, cursor string) ([]types.UrlRevision, string, error) {
+	url, err := s.getOwnedUrl(ctx, accountID, slug)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.urlRepository.GetRevisions(ctx, url.ID, cursor)
+}
+
+func (s v1) RollbackUrl(ctx context.Context, accountID uint64, slug string, revisionID uint64) error {
+	url, err := s.getOwnedUrl(ctx, accountID, slug)
+	if err != nil {
+		return err
+	}
+
+	revision, err := s.urlRepository.GetRevision(ctx, url.ID, revisionID)
+	if err != nil {
+		return fmt.Errorf("failed to get revision(%d) of url(%s): %w", revisionID, slug, err)
+	}
+
+	// rolling back is an update on its own, so the destination being replaced is kept in the history as well.
+	return s.UpdateOriginalUrl(ctx, accountID, slug, revision.OriginalUrl)
+}
+
+func (s v1) GetUrlVisits(ctx context.Context, accountID uint64, slug, cursor string) ([]types.Visit, string, error) {
+	url, err := s.getOwnedUrl(ctx, accountID, slug)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.visitRepository.GetByUrlID(ctx, url.ID, cursor)
+}
+
 func (s v1) randomUint64() uint64 {
 	bytes := make([]byte, 8)
 	if _, err := rand.Read(bytes); err != nil {
@@ -0,0 +1,47 @@
+package url
+
+import (
+	"net"
+	"unicode/utf8"
+
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+// anonymizeIP zeroes the host part of an ip address, keeping a /24 of IPv4 and a /48 of IPv6 addresses.
+func anonymizeIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+func truncate(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+
+	value = value[:maxLength]
+	for len(value) > 0 && !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+
+	return value
+}
+
+func (s v1) sanitizeVisit(visit *types.Visit) {
+	visit.Referrer = truncate(visit.Referrer, types.MaxVisitReferrerLength)
+	visit.UserAgent = truncate(visit.UserAgent, types.MaxVisitUserAgentLength)
+	visit.AcceptLanguage = truncate(visit.AcceptLanguage, types.MaxVisitAcceptLanguageLength)
+	visit.ReferrerDomain = truncate(visit.ReferrerDomain, types.MaxVisitReferrerDomainLength)
+	visit.City = truncate(visit.City, types.MaxVisitCityLength)
+
+	if s.anonymizeClientIPs {
+		visit.ClientIP = anonymizeIP(visit.ClientIP)
+	}
+}
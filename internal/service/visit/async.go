@@ -0,0 +1,161 @@
+package visit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	visitRepository "github.com/h3isenbug/url-shortener/internal/repository/visit"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/log"
+)
+
+var ErrBufferFull = errors.New("visit buffer is full")
+
+const flushTimeout = 10 * time.Second
+
+type asyncV1 struct {
+	logger          log.Logger
+	visitRepository visitRepository.Repository
+
+	batchSize     int
+	flushInterval time.Duration
+
+	// lock guards closed, so that no visit is sent on the channel after it is closed.
+	lock    sync.RWMutex
+	closed  bool
+	visits  chan types.Visit
+	drained chan struct{}
+}
+
+func NewAsyncRecorderV1(
+	logger log.Logger,
+	visitRepository visitRepository.Repository,
+	bufferSize, batchSize int,
+	flushInterval time.Duration,
+) Recorder {
+	recorder := &asyncV1{
+		logger:          logger,
+		visitRepository: visitRepository,
+		batchSize:       batchSize,
+		flushInterval:   flushInterval,
+		visits:          make(chan types.Visit, bufferSize),
+		drained:         make(chan struct{}),
+	}
+
+	go recorder.run()
+
+	return recorder
+}
+
+func (r *asyncV1) Record(visit types.Visit) error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.closed {
+		return ErrRecorderClosed
+	}
+
+	select {
+	case r.visits <- visit:
+		return nil
+	default:
+		return ErrBufferFull
+	}
+}
+
+func (r *asyncV1) Drain(ctx context.Context) error {
+	r.lock.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.visits)
+	}
+	r.lock.Unlock()
+
+	select {
+	case <-r.drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain visit buffer: %w", ctx.Err())
+	}
+}
+
+func (r *asyncV1) run() {
+	defer close(r.drained)
+
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]types.Visit, 0, r.batchSize)
+	for {
+		select {
+		case visit, ok := <-r.visits:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+
+			batch = append(batch, visit)
+			if len(batch) >= r.batchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// countVisits sums up the visits of a batch per url, skipping those that are already counted.
+func countVisits(visits []types.Visit) []types.VisitCount {
+	var (
+		counts  []types.VisitCount
+		indexes = make(map[uint64]int)
+	)
+	for _, visit := range visits {
+		if visit.Counted {
+			continue
+		}
+
+		index, found := indexes[visit.UrlID]
+		if !found {
+			index = len(counts)
+			indexes[visit.UrlID] = index
+			counts = append(counts, types.VisitCount{UrlID: visit.UrlID})
+		}
+
+		switch {
+		case visit.Bot:
+			counts[index].BotVisits++
+		case visit.Unique:
+			counts[index].TotalVisits++
+			counts[index].UniqueVisits++
+		default:
+			counts[index].TotalVisits++
+		}
+	}
+
+	return counts
+}
+
+func (r *asyncV1) flush(batch []types.Visit) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	// visits and counters are written together, so a failed flush leaves neither of them behind.
+	if err := r.visitRepository.CreateBatch(ctx, batch, countVisits(batch)); err != nil {
+		r.logger.Error("failed to flush url visits", map[string]interface{}{
+			"visits":       len(batch),
+			"errorMessage": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package visit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockVisit "github.com/h3isenbug/url-shortener/internal/repository/visit/mock"
+	"github.com/h3isenbug/url-shortener/internal/service/visit"
+	"github.com/h3isenbug/url-shortener/internal/types"
+	"github.com/h3isenbug/url-shortener/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createSUT(t *testing.T, bufferSize, batchSize int, flushInterval time.Duration) (visit.Recorder, *mockVisit.MockRepository) {
+	ctrl := gomock.NewController(t)
+
+	visitRepo := mockVisit.NewMockRepository(ctrl)
+
+	logger, err := log.NewZapLoggingService("")
+	require.NoError(t, err)
+
+	return visit.NewAsyncRecorderV1(logger, visitRepo, bufferSize, batchSize, flushInterval), visitRepo
+}
+
+func TestRecorderFlushesFullBatch(t *testing.T) {
+	recorder, visitRepo := createSUT(t, 10, 3, time.Hour)
+
+	flushed := make(chan struct{})
+	visitRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Len(3), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ []types.Visit, counts []types.VisitCount) error {
+			assert.ElementsMatch(t, []types.VisitCount{
+				{UrlID: 1, TotalVisits: 2, UniqueVisits: 1},
+				{UrlID: 2, TotalVisits: 1, UniqueVisits: 0},
+			}, counts)
+			close(flushed)
+			return nil
+		},
+	).Times(1)
+
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 1, Unique: true}))
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 2}))
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 1}))
+
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatal("batch was not flushed")
+	}
+}
+
+func TestRecorderDoesNotCountCountedVisits(t *testing.T) {
+	recorder, visitRepo := createSUT(t, 10, 10, time.Hour)
+
+	visitRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Len(2), gomock.Eq([]types.VisitCount{
+		{UrlID: 1, TotalVisits: 1, UniqueVisits: 1},
+	})).Return(nil).Times(1)
+
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 1, Unique: true}))
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 2, Unique: true, Counted: true}))
+
+	require.NoError(t, recorder.Drain(context.Background()))
+}
+
+func TestRecorderFlushesOnInterval(t *testing.T) {
+	recorder, visitRepo := createSUT(t, 10, 10, 10*time.Millisecond)
+
+	flushed := make(chan struct{})
+	visitRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Len(1), gomock.Len(1)).DoAndReturn(
+		func(context.Context, []types.Visit, []types.VisitCount) error {
+			close(flushed)
+			return nil
+		},
+	).Times(1)
+
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 1}))
+
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatal("batch was not flushed")
+	}
+}
+
+func TestRecorderDrain(t *testing.T) {
+	recorder, visitRepo := createSUT(t, 10, 10, time.Hour)
+
+	visitRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Len(2), gomock.Len(1)).Return(nil).Times(1)
+
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 1}))
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 1}))
+
+	require.NoError(t, recorder.Drain(context.Background()))
+	assert.ErrorIs(t, recorder.Record(types.Visit{UrlID: 1}), visit.ErrRecorderClosed)
+}
+
+func TestRecorderDropsVisitsWhenBufferIsFull(t *testing.T) {
+	recorder, visitRepo := createSUT(t, 0, 10, time.Hour)
+
+	// an unbuffered channel only accepts a visit while the worker is waiting for one,
+	// so at least one of a burst of visits is dropped.
+	var dropped bool
+	visitRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).MaxTimes(1)
+	for i := 0; i < 1000 && !dropped; i++ {
+		dropped = recorder.Record(types.Visit{UrlID: 1}) == visit.ErrBufferFull
+	}
+
+	require.NoError(t, recorder.Drain(context.Background()))
+	assert.True(t, dropped)
+}
+
+func TestRecorderCountsBotsSeparately(t *testing.T) {
+	recorder, visitRepo := createSUT(t, 10, 10, time.Hour)
+
+	visitRepo.EXPECT().CreateBatch(gomock.Any(), gomock.Len(3), gomock.Eq([]types.VisitCount{
+		{UrlID: 1, TotalVisits: 1, UniqueVisits: 1, BotVisits: 2},
+	})).Return(nil).Times(1)
+
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 1, Unique: true}))
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 1, Bot: true}))
+	require.NoError(t, recorder.Record(types.Visit{UrlID: 1, Unique: true, Bot: true}))
+
+	require.NoError(t, recorder.Drain(context.Background()))
+}
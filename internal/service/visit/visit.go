@@ -0,0 +1,18 @@
+package visit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/h3isenbug/url-shortener/internal/types"
+)
+
+var ErrRecorderClosed = errors.New("visit recorder is closed")
+
+// Recorder takes visits off the redirect path and persists them in the background.
+type Recorder interface {
+	// Record enqueues a visit. it never blocks; visits are dropped when the buffer is full.
+	Record(visit types.Visit) error
+	// Drain stops accepting visits and waits until every buffered visit is persisted.
+	Drain(ctx context.Context) error
+}
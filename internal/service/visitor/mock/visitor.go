@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/visitor/visitor.go
+
+// Package mock_visitor is a generated GoMock package.
+package mock_visitor
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	visitor "github.com/h3isenbug/url-shortener/internal/service/visitor"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// Identify mocks base method.
+func (m *MockService) Identify(ctx context.Context, request visitor.Request) (visitor.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Identify", ctx, request)
+	ret0, _ := ret[0].(visitor.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Identify indicates an expected call of Identify.
+func (mr *MockServiceMockRecorder) Identify(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Identify", reflect.TypeOf((*MockService)(nil).Identify), ctx, request)
+}
+
+// IsFirstVisit mocks base method.
+func (m *MockService) IsFirstVisit(ctx context.Context, urlID uint64, visitorID string, hashed bool) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsFirstVisit", ctx, urlID, visitorID, hashed)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsFirstVisit indicates an expected call of IsFirstVisit.
+func (mr *MockServiceMockRecorder) IsFirstVisit(ctx, urlID, visitorID, hashed interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsFirstVisit", reflect.TypeOf((*MockService)(nil).IsFirstVisit), ctx, urlID, visitorID, hashed)
+}
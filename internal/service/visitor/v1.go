@@ -0,0 +1,121 @@
+package visitor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	visitorRepository "github.com/h3isenbug/url-shortener/internal/repository/visitor"
+	"github.com/h3isenbug/url-shortener/pkg/signer"
+)
+
+const visitorIDLength = 16
+
+type v1 struct {
+	visitorRepository visitorRepository.Repository
+
+	mode           Mode
+	cookieSigner   signer.Signer
+	cookieLifespan time.Duration
+
+	saltLock sync.Mutex
+	saltDay  string
+	salt     []byte
+}
+
+// NewVisitorServiceV1 returns a visitor service. visits of a visitor to a url are only unique once per cookieLifespan.
+func NewVisitorServiceV1(
+	visitorRepository visitorRepository.Repository,
+	mode Mode,
+	cookieSigner signer.Signer,
+	cookieLifespan time.Duration,
+) Service {
+	return &v1{
+		visitorRepository: visitorRepository,
+		mode:              mode,
+		cookieSigner:      cookieSigner,
+		cookieLifespan:    cookieLifespan,
+	}
+}
+
+func (s *v1) getSalt(ctx context.Context) ([]byte, error) {
+	day := time.Now().UTC().Format("2006-01-02")
+
+	s.saltLock.Lock()
+	defer s.saltLock.Unlock()
+
+	if s.saltDay == day {
+		return s.salt, nil
+	}
+
+	salt, err := s.visitorRepository.GetSalt(ctx, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get salt of %s: %w", day, err)
+	}
+
+	s.saltDay, s.salt = day, salt
+
+	return salt, nil
+}
+
+// hashVisitor derives an id from the ip address and user agent of a visitor. since the salt changes every day and
+// is never kept for long, the id can neither be traced back to the visitor nor linked to the ids of other days.
+func (s *v1) hashVisitor(ctx context.Context, clientIP, userAgent string) (string, error) {
+	salt, err := s.getSalt(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(clientIP))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:visitorIDLength]), nil
+}
+
+func (s *v1) Identify(ctx context.Context, request Request) (Identity, error) {
+	if s.mode == ModeNone || request.DoNotTrack {
+		return Identity{}, nil
+	}
+
+	if s.mode == ModeCookie && request.Cookie != "" {
+		if id, err := s.cookieSigner.Verify(request.Cookie); err == nil {
+			return Identity{ID: id}, nil
+		}
+	}
+
+	id, err := s.hashVisitor(ctx, request.ClientIP, request.UserAgent)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if s.mode != ModeCookie {
+		return Identity{ID: id, Hashed: true}, nil
+	}
+
+	// the cookie carries the id this visit is counted with, so the next visit is not taken for a new visitor.
+	expiresAt := time.Now().UTC().Add(s.cookieLifespan)
+
+	return Identity{ID: id, Hashed: true, Cookie: s.cookieSigner.Sign(id, expiresAt), CookieExpiresAt: expiresAt}, nil
+}
+
+func (s *v1) IsFirstVisit(ctx context.Context, urlID uint64, visitorID string, hashed bool) (bool, error) {
+	ttl := s.cookieLifespan
+	if hashed {
+		// a hashed id can not be derived again once the salt of the day is replaced, so it is not kept any longer.
+		now := time.Now().UTC()
+		ttl = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC).Sub(now)
+	}
+
+	firstVisit, err := s.visitorRepository.MarkSeen(ctx, urlID, visitorID, ttl)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark visitor as seen: %w", err)
+	}
+
+	return firstVisit, nil
+}
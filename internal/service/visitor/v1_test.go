@@ -0,0 +1,131 @@
+package visitor_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockVisitor "github.com/h3isenbug/url-shortener/internal/repository/visitor/mock"
+	"github.com/h3isenbug/url-shortener/internal/service/visitor"
+	"github.com/h3isenbug/url-shortener/pkg/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cookieLifespan = time.Hour
+
+// the secret is long enough for the signer, which is never nil.
+var cookieSigner, _ = signer.NewHMACSignerV1([]byte("visitor-cookie-secret-for-the-tests"))
+
+func createSUT(t *testing.T, mode visitor.Mode) (visitor.Service, *mockVisitor.MockRepository) {
+	ctrl := gomock.NewController(t)
+
+	visitorRepo := mockVisitor.NewMockRepository(ctrl)
+
+	return visitor.NewVisitorServiceV1(visitorRepo, mode, cookieSigner, cookieLifespan), visitorRepo
+}
+
+func TestIdentifyUntracked(t *testing.T) {
+	service, visitorRepo := createSUT(t, visitor.ModeNone)
+	visitorRepo.EXPECT().GetSalt(gomock.Any(), gomock.Any()).Times(0)
+
+	identity, err := service.Identify(context.Background(), visitor.Request{ClientIP: "192.168.10.0", UserAgent: "Firefox"})
+	require.NoError(t, err)
+	assert.Equal(t, visitor.Identity{}, identity)
+
+	service, visitorRepo = createSUT(t, visitor.ModeCookie)
+	visitorRepo.EXPECT().GetSalt(gomock.Any(), gomock.Any()).Times(0)
+
+	identity, err = service.Identify(context.Background(), visitor.Request{
+		ClientIP: "192.168.10.0", UserAgent: "Firefox", DoNotTrack: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, visitor.Identity{}, identity)
+}
+
+func TestIdentifyByCookie(t *testing.T) {
+	service, visitorRepo := createSUT(t, visitor.ModeCookie)
+	visitorRepo.EXPECT().GetSalt(gomock.Any(), gomock.Any()).Times(0)
+
+	identity, err := service.Identify(context.Background(), visitor.Request{
+		Cookie:   cookieSigner.Sign("known-visitor", time.Now().Add(time.Minute)),
+		ClientIP: "192.168.10.0",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "known-visitor", identity.ID)
+	assert.False(t, identity.Hashed)
+	assert.Empty(t, identity.Cookie)
+}
+
+func TestIdentifyNewVisitorGetsCookie(t *testing.T) {
+	service, visitorRepo := createSUT(t, visitor.ModeCookie)
+	visitorRepo.EXPECT().GetSalt(gomock.Any(), gomock.Any()).Return([]byte("salt"), nil).Times(1)
+
+	identity, err := service.Identify(context.Background(), visitor.Request{
+		Cookie:   "forged.cookie",
+		ClientIP: "192.168.10.0",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, identity.ID)
+	assert.True(t, identity.Hashed)
+
+	id, err := cookieSigner.Verify(identity.Cookie)
+	require.NoError(t, err)
+	assert.Equal(t, identity.ID, id)
+	assert.WithinDuration(t, time.Now().Add(cookieLifespan), identity.CookieExpiresAt, time.Minute)
+}
+
+func TestIdentifyByHash(t *testing.T) {
+	service, visitorRepo := createSUT(t, visitor.ModeHash)
+	// the salt is fetched once and kept for the rest of the day
+	visitorRepo.EXPECT().GetSalt(gomock.Any(), gomock.Any()).Return([]byte("salt"), nil).Times(1)
+
+	request := visitor.Request{ClientIP: "192.168.10.0", UserAgent: "Firefox"}
+
+	first, err := service.Identify(context.Background(), request)
+	require.NoError(t, err)
+	second, err := service.Identify(context.Background(), request)
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.True(t, first.Hashed)
+	assert.Empty(t, first.Cookie)
+	assert.Equal(t, first.ID, second.ID)
+
+	request.UserAgent = "Chrome"
+	other, err := service.Identify(context.Background(), request)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, other.ID)
+}
+
+func TestIsFirstVisit(t *testing.T) {
+	service, visitorRepo := createSUT(t, visitor.ModeCookie)
+	visitorRepo.EXPECT().MarkSeen(gomock.Any(), uint64(1), "visitor", cookieLifespan).Return(true, nil).Times(1)
+	visitorRepo.EXPECT().MarkSeen(gomock.Any(), uint64(1), "visitor", cookieLifespan).Return(false, nil).Times(1)
+
+	firstVisit, err := service.IsFirstVisit(context.Background(), 1, "visitor", false)
+	require.NoError(t, err)
+	assert.True(t, firstVisit)
+
+	firstVisit, err = service.IsFirstVisit(context.Background(), 1, "visitor", false)
+	require.NoError(t, err)
+	assert.False(t, firstVisit)
+}
+
+func TestIsFirstVisitOfHashedVisitor(t *testing.T) {
+	service, visitorRepo := createSUT(t, visitor.ModeHash)
+	visitorRepo.EXPECT().MarkSeen(gomock.Any(), uint64(1), "visitor", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ uint64, _ string, ttl time.Duration) (bool, error) {
+			// the id is only kept until the salt it is derived from is replaced, at the end of the day
+			assert.Positive(t, ttl)
+			assert.LessOrEqual(t, ttl, 24*time.Hour)
+			expiresAt := time.Now().UTC().Add(ttl)
+			assert.WithinDuration(t, expiresAt.Round(24*time.Hour), expiresAt, time.Second)
+			return true, nil
+		},
+	).Times(1)
+
+	firstVisit, err := service.IsFirstVisit(context.Background(), 1, "visitor", true)
+	require.NoError(t, err)
+	assert.True(t, firstVisit)
+}
@@ -0,0 +1,50 @@
+package visitor
+
+import (
+	"context"
+	"time"
+)
+
+type Mode string
+
+const (
+	// ModeCookie identifies visitors by a signed cookie, falling back to a hash for visitors without one.
+	ModeCookie Mode = "cookie"
+	// ModeHash identifies visitors by a hash of their ip address and user agent, salted with a daily salt.
+	ModeHash Mode = "hash"
+	// ModeNone does not identify visitors at all.
+	ModeNone Mode = "none"
+)
+
+func (m Mode) IsValid() bool {
+	switch m {
+	case ModeCookie, ModeHash, ModeNone:
+		return true
+	}
+
+	return false
+}
+
+type Request struct {
+	Cookie     string
+	ClientIP   string
+	UserAgent  string
+	DoNotTrack bool
+}
+
+type Identity struct {
+	// ID is empty when the visitor is not tracked.
+	ID string
+	// Hashed is set when ID is derived from the ip address and user agent rather than taken from a visitor cookie.
+	Hashed bool
+	// Cookie is set when a new visitor cookie has to be handed to the visitor.
+	Cookie          string
+	CookieExpiresAt time.Time
+}
+
+type Service interface {
+	Identify(ctx context.Context, request Request) (Identity, error)
+	// IsFirstVisit tells whether this is the first visit of the visitor to the url. hashed is Identity.Hashed of the
+	// visitor.
+	IsFirstVisit(ctx context.Context, urlID uint64, visitorID string, hashed bool) (bool, error)
+}
@@ -1,6 +1,11 @@
 package types
 
-import "time"
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
 
 const ServiceName = "url-shortener"
 
@@ -9,23 +14,265 @@ type TokenPair struct {
 	RefreshToken string
 }
 
+// LoginResult holds the tokens of the new session, or a challenge to complete with a code when the account has
+// two-factor authentication enabled.
+type LoginResult struct {
+	TokenPair      *TokenPair
+	ChallengeToken string
+}
+
+// TwoFactorSecret is the TOTP secret of an account, which may still be waiting for its enrollment to be confirmed.
+type TwoFactorSecret struct {
+	AccountID       uint64 `db:"account_id"`
+	Secret          []byte `db:"secret"`
+	LastUsedCounter uint64 `db:"last_used_counter"`
+}
+
+// RecoveryCodeCount is the number of one-time codes handed out for when the authenticator is lost.
+const RecoveryCodeCount = 10
+
 type Account struct {
-	ID           uint64 `db:"id"`
-	EMail        string `db:"email"`
-	PasswordHash string `db:"password_hash"`
+	ID            uint64 `db:"id"`
+	EMail         string `db:"email"`
+	PasswordHash  string `db:"password_hash"`
+	EMailVerified bool   `db:"email_verified"`
+	Admin         bool   `db:"admin"`
+	// TwoFactorEnabled is set once enrollment is confirmed, after that logging in needs a code.
+	TwoFactorEnabled bool `db:"two_factor_enabled"`
+}
+
+// Scopes are the permissions sessions of the account are granted.
+func (a Account) Scopes() Scopes {
+	if a.Admin {
+		return AllScopes
+	}
+
+	return DefaultScopes
 }
 
 type AccountInfo struct {
 	ID uint64
+	// SessionID is the refresh token family the access token was issued for.
+	SessionID uint64
+	// APIKeyID is set when the request is authenticated with an api key instead of an access token.
+	APIKeyID uint64
+	Scopes   Scopes
+}
+
+func (a AccountInfo) HasScope(scope Scope) bool {
+	return a.Scopes.Contains(scope)
+}
+
+// Scope is a permission granted to the holder of a credential.
+type Scope string
+
+const (
+	ScopeUrlsRead   Scope = "urls:read"
+	ScopeUrlsCreate Scope = "urls:create"
+	ScopeUrlsWrite  Scope = "urls:write"
+	ScopeStatsRead  Scope = "stats:read"
+	ScopeAdmin      Scope = "admin"
+)
+
+// DefaultScopes are granted to every account.
+var DefaultScopes = Scopes{ScopeUrlsRead, ScopeUrlsCreate, ScopeUrlsWrite, ScopeStatsRead}
+
+// AllScopes are granted to administrators.
+var AllScopes = append(Scopes{ScopeAdmin}, DefaultScopes...)
+
+func (s Scope) IsValid() bool {
+	return AllScopes.Contains(s)
+}
+
+// Scopes is stored as a comma separated list.
+type Scopes []Scope
+
+func (s Scopes) Contains(scope Scope) bool {
+	for _, granted := range s {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s Scopes) Value() (driver.Value, error) {
+	values := make([]string, 0, len(s))
+	for _, scope := range s {
+		values = append(values, string(scope))
+	}
+
+	return strings.Join(values, ","), nil
+}
+
+func (s *Scopes) Scan(src interface{}) error {
+	var value string
+	switch src := src.(type) {
+	case string:
+		value = src
+	case []byte:
+		value = string(src)
+	default:
+		return fmt.Errorf("can not scan %T into scopes", src)
+	}
+
+	*s = Scopes{}
+	if value == "" {
+		return nil
+	}
+	for _, scope := range strings.Split(value, ",") {
+		*s = append(*s, Scope(scope))
+	}
+
+	return nil
+}
+
+// APIKey is a long-lived credential of an account. the key itself is only shown once, when it is created.
+type APIKey struct {
+	ID        uint64 `db:"id" json:"id"`
+	AccountID uint64 `db:"account_id" json:"-"`
+	Name      string `db:"name" json:"name"`
+	// Prefix is the beginning of the key, kept so that owners can tell their keys apart.
+	Prefix     string     `db:"prefix" json:"prefix"`
+	KeyHash    string     `db:"key_hash" json:"-"`
+	Scopes     Scopes     `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expiresAt"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"lastUsedAt"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+}
+
+const MaxAPIKeyNameLength = 64
+
+const MaxClientUserAgentLength = 512
+
+// MaxEMailLength is as long as stored email addresses can be.
+const MaxEMailLength = 64
+
+// MaxPasswordLength is in bytes. bcrypt ignores everything after the 72nd byte of a password.
+const MaxPasswordLength = 72
+
+// PasswordPolicy is what new passwords are checked against.
+type PasswordPolicy struct {
+	// MinLength is in characters, MaxLength in bytes, which can not be more than MaxPasswordLength.
+	MinLength int
+	MaxLength int
+	// Blocklist holds common passwords, they are rejected regardless of case.
+	Blocklist []string
+}
+
+// ClientInfo describes the client a session was opened from.
+type ClientInfo struct {
+	UserAgent string
+	ClientIP  string
+}
+
+// Revocation tells which access tokens of a session are no longer accepted.
+type Revocation struct {
+	// SessionRevoked rejects every access token of the session.
+	SessionRevoked bool
+	// RevokedBefore rejects access tokens of the account issued before it. it is zero if none is revoked this way.
+	RevokedBefore time.Time
+}
+
+// LoginThrottlePolicy limits the failed attempts of a subject, an account or a client ip. failures are forgotten
+// FailureWindow after the first one.
+type LoginThrottlePolicy struct {
+	// FreeAttempts may fail without delay. every failure after them doubles the delay, which starts at BaseDelay.
+	FreeAttempts int
+	BaseDelay    time.Duration
+	// LockoutThreshold failures lock the subject out for LockoutDuration.
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	FailureWindow    time.Duration
+}
+
+// Delays lists the delay after every failure up to the lockout, which is the last one.
+func (p LoginThrottlePolicy) Delays() []time.Duration {
+	delays := []time.Duration{p.delay(1)}
+	for failures := int64(2); failures <= int64(p.LockoutThreshold); failures++ {
+		delays = append(delays, p.delay(failures))
+	}
+
+	return delays
+}
+
+// delay is zero for the free attempts, and never longer than the lockout.
+func (p LoginThrottlePolicy) delay(failures int64) time.Duration {
+	if failures >= int64(p.LockoutThreshold) {
+		return p.LockoutDuration
+	}
+	if failures <= int64(p.FreeAttempts) {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := int64(p.FreeAttempts) + 1; i < failures && delay < p.LockoutDuration; i++ {
+		delay *= 2
+	}
+	if delay > p.LockoutDuration {
+		return p.LockoutDuration
+	}
+
+	return delay
+}
+
+// LoginBlock tells how long attempts of a subject are refused. it is zero if they are not.
+type LoginBlock struct {
+	// LockedOut is set once the lockout threshold is reached, it is unset for the delays before it.
+	LockedOut  bool
+	RetryAfter time.Duration
+}
+
+// Session is a refresh token family.
+type Session struct {
+	ID         uint64    `db:"family" json:"id"`
+	UserAgent  string    `db:"user_agent" json:"userAgent"`
+	ClientIP   string    `db:"client_ip" json:"clientIP"`
+	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+	LastUsedAt time.Time `db:"last_used_at" json:"lastUsedAt"`
 }
 
 type RefreshToken struct {
 	ID          uint64    `db:"id"`
 	AccountID   uint64    `db:"account_id"`
-	Token       string    `db:"token"`
+	TokenHash   string    `db:"token_hash"`
 	ValidUntil  time.Time `db:"valid_until"`
 	Compromised bool      `db:"compromised"`
 	Disabled    bool      `db:"disabled"`
+	Revoked     bool      `db:"revoked"`
 	Family      uint64    `db:"family"`
 	CreatedAt   time.Time `db:"created_at"`
 }
+
+// AccountTokenPurpose tells single-use account tokens apart, so that a token issued for one purpose can not be
+// redeemed for another.
+type AccountTokenPurpose string
+
+const (
+	AccountTokenPurposeEMailVerification AccountTokenPurpose = "email-verification"
+	AccountTokenPurposePasswordReset     AccountTokenPurpose = "password-reset"
+	AccountTokenPurposeEMailChange       AccountTokenPurpose = "email-change"
+)
+
+// AccountDeletionPolicy decides what happens to the urls of a deleted account.
+type AccountDeletionPolicy string
+
+const (
+	// AccountDeletionPolicyAnonymize disables the urls and removes their destinations and owner. their slugs stay
+	// taken, so that links shared before can not be taken over.
+	AccountDeletionPolicyAnonymize AccountDeletionPolicy = "anonymize"
+	// AccountDeletionPolicyTransfer hands the urls over to another account.
+	AccountDeletionPolicyTransfer AccountDeletionPolicy = "transfer"
+	// AccountDeletionPolicyDelete deletes the urls along with their visits, which frees their slugs.
+	AccountDeletionPolicyDelete AccountDeletionPolicy = "delete"
+)
+
+func (p AccountDeletionPolicy) IsValid() bool {
+	switch p {
+	case AccountDeletionPolicyAnonymize, AccountDeletionPolicyTransfer, AccountDeletionPolicyDelete:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+type DataExportStatus string
+
+const (
+	DataExportStatusPending DataExportStatus = "pending"
+	DataExportStatusRunning DataExportStatus = "running"
+	DataExportStatusReady   DataExportStatus = "ready"
+	DataExportStatusFailed  DataExportStatus = "failed"
+)
+
+// DataExport is an archive of everything kept on an account, built in the background. the archive can be downloaded
+// until ExpiresAt once it is ready.
+type DataExport struct {
+	ID          uint64           `db:"id" json:"id"`
+	AccountID   uint64           `db:"account_id" json:"-"`
+	Status      DataExportStatus `db:"status" json:"status"`
+	CreatedAt   time.Time        `db:"created_at" json:"createdAt"`
+	CompletedAt *time.Time       `db:"completed_at" json:"completedAt"`
+	ExpiresAt   *time.Time       `db:"expires_at" json:"expiresAt"`
+	// DownloadNonce is random and bound into the download link, which can not be made up without it.
+	DownloadNonce string `db:"download_nonce" json:"-"`
+	// DownloadURL is only set on ready exports. it expires along with the archive.
+	DownloadURL string `db:"-" json:"downloadUrl,omitempty"`
+}
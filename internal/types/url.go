@@ -6,18 +6,43 @@ import (
 )
 
 type Url struct {
-	ID           uint64    `db:"id" json:"id"`
-	OriginalUrl  string    `db:"original_url" json:"original_url"`
-	Slug         string    `db:"slug" json:"slug"`
-	TotalVisits  uint64    `db:"total_visits" json:"total_visits"`
-	UniqueVisits uint64    `db:"unique_visits" json:"unique_visits"`
-	AccountID    uint64    `db:"account_id" json:"account_id"`
-	Disabled     bool      `db:"disabled" json:"disabled"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	ID           uint64     `db:"id" json:"id"`
+	OriginalUrl  string     `db:"original_url" json:"original_url"`
+	Slug         string     `db:"slug" json:"slug"`
+	TotalVisits  uint64     `db:"total_visits" json:"total_visits"`
+	UniqueVisits uint64     `db:"unique_visits" json:"unique_visits"`
+	BotVisits    uint64     `db:"bot_visits" json:"bot_visits"`
+	AccountID    uint64     `db:"account_id" json:"account_id"`
+	Disabled     bool       `db:"disabled" json:"disabled"`
+	ExpiresAt    *time.Time `db:"expires_at" json:"expires_at"`
+	MaxVisits    *uint64    `db:"max_visits" json:"max_visits"`
+	PasswordHash *string    `db:"password_hash" json:"-"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
+
+// urlCacheEntry is the serialized form of Url. unlike api responses, it has to keep the password hash.
+type urlCacheEntry struct {
+	*Url
+	PasswordHash *string `json:"password_hash"`
+}
+
+// IsProtected reports whether visitors have to enter a password before being redirected.
+func (u *Url) IsProtected() bool {
+	return u.PasswordHash != nil
+}
+
+// IsExpired reports whether the url has passed its expiration time at the given moment.
+func (u *Url) IsExpired(now time.Time) bool {
+	return u.ExpiresAt != nil && !now.Before(*u.ExpiresAt)
+}
+
+// IsExhausted reports whether the url has used up all of its allowed visits.
+func (u *Url) IsExhausted() bool {
+	return u.MaxVisits != nil && u.TotalVisits >= *u.MaxVisits
 }
 
 func (u *Url) String() string {
-	bytes, err := json.Marshal(u)
+	bytes, err := json.Marshal(&urlCacheEntry{Url: u, PasswordHash: u.PasswordHash})
 	if err != nil {
 		panic(err) // This cant happen.
 	}
@@ -26,5 +51,20 @@ func (u *Url) String() string {
 }
 
 func (u *Url) FromString(str string) error {
-	return json.Unmarshal([]byte(str), u)
+	entry := urlCacheEntry{Url: u}
+	if err := json.Unmarshal([]byte(str), &entry); err != nil {
+		return err
+	}
+	u.PasswordHash = entry.PasswordHash
+
+	return nil
+}
+
+// UrlRevision holds a destination that a url used to point to, before ChangedBy replaced it at ChangedAt.
+type UrlRevision struct {
+	ID          uint64    `db:"id" json:"id"`
+	UrlID       uint64    `db:"url_id" json:"url_id"`
+	OriginalUrl string    `db:"original_url" json:"original_url"`
+	ChangedBy   uint64    `db:"changed_by" json:"changed_by"`
+	ChangedAt   time.Time `db:"changed_at" json:"changed_at"`
 }
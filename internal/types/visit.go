@@ -0,0 +1,116 @@
+package types
+
+import "time"
+
+const (
+	MaxVisitReferrerLength       = 2048
+	MaxVisitUserAgentLength      = 512
+	MaxVisitAcceptLanguageLength = 256
+	MaxVisitReferrerDomainLength = 255
+	MaxVisitCityLength           = 128
+)
+
+type Visit struct {
+	ID             uint64    `db:"id" json:"id"`
+	UrlID          uint64    `db:"url_id" json:"url_id"`
+	VisitedAt      time.Time `db:"visited_at" json:"visited_at"`
+	Referrer       string    `db:"referrer" json:"referrer"`
+	UserAgent      string    `db:"user_agent" json:"user_agent"`
+	ClientIP       string    `db:"client_ip" json:"client_ip"`
+	AcceptLanguage string    `db:"accept_language" json:"accept_language"`
+	Unique         bool      `db:"unique_visit" json:"unique"`
+
+	ReferrerDomain string `db:"referrer_domain" json:"referrer_domain"`
+	Device         string `db:"device" json:"device"`
+	Browser        string `db:"browser" json:"browser"`
+	OS             string `db:"os" json:"os"`
+	Country        string `db:"country" json:"country"`
+	City           string `db:"city" json:"city"`
+	Bot            bool   `db:"bot" json:"bot"`
+
+	// Counted is set when the visit is already reflected in the url's visit counters.
+	Counted bool `db:"-" json:"-"`
+	// VisitorID identifies the visitor for telling unique visits apart. it is never stored.
+	VisitorID string `db:"-" json:"-"`
+	// VisitorHashed is set when VisitorID is derived from the ip address and user agent of the visitor.
+	VisitorHashed bool `db:"-" json:"-"`
+}
+
+// VisitCount is an increment to the visit counters of a url.
+type VisitCount struct {
+	UrlID        uint64
+	TotalVisits  uint64
+	UniqueVisits uint64
+	BotVisits    uint64
+}
+
+// BotPolicy decides how visits of bots are accounted for.
+type BotPolicy string
+
+const (
+	// BotPolicyCount counts bots like people.
+	BotPolicyCount BotPolicy = "count"
+	// BotPolicySeparate records visits of bots, but counts them apart from those of people.
+	BotPolicySeparate BotPolicy = "separate"
+	// BotPolicyIgnore does not record visits of bots at all.
+	BotPolicyIgnore BotPolicy = "ignore"
+)
+
+func (p BotPolicy) IsValid() bool {
+	switch p {
+	case BotPolicyCount, BotPolicySeparate, BotPolicyIgnore:
+		return true
+	}
+
+	return false
+}
+
+type StatsGranularity string
+
+const (
+	StatsGranularityHour StatsGranularity = "hour"
+	StatsGranularityDay  StatsGranularity = "day"
+)
+
+// VisitStatsBucket holds the visits of a url in the hour or day starting at Bucket.
+type VisitStatsBucket struct {
+	Bucket       time.Time `db:"bucket" json:"bucket"`
+	TotalVisits  uint64    `db:"total_visits" json:"total_visits"`
+	UniqueVisits uint64    `db:"unique_visits" json:"unique_visits"`
+	BotVisits    uint64    `db:"bot_visits" json:"bot_visits"`
+}
+
+type BreakdownDimension string
+
+const (
+	BreakdownDimensionReferrer BreakdownDimension = "referrer"
+	BreakdownDimensionDevice   BreakdownDimension = "device"
+	BreakdownDimensionBrowser  BreakdownDimension = "browser"
+	BreakdownDimensionOS       BreakdownDimension = "os"
+	BreakdownDimensionCountry  BreakdownDimension = "country"
+)
+
+func (d BreakdownDimension) IsValid() bool {
+	switch d {
+	case BreakdownDimensionReferrer, BreakdownDimensionDevice, BreakdownDimensionBrowser, BreakdownDimensionOS,
+		BreakdownDimensionCountry:
+		return true
+	}
+
+	return false
+}
+
+// BreakdownItem holds the visits having Value as their value of a breakdown dimension.
+type BreakdownItem struct {
+	Value        string `db:"value" json:"value"`
+	TotalVisits  uint64 `db:"total_visits" json:"total_visits"`
+	UniqueVisits uint64 `db:"unique_visits" json:"unique_visits"`
+}
+
+// GeoBreakdownItem holds the visits coming from a city. City is empty for visits only known by their country.
+type GeoBreakdownItem struct {
+	Country      string `db:"country" json:"country"`
+	City         string `db:"city" json:"city"`
+	TotalVisits  uint64 `db:"total_visits" json:"total_visits"`
+	UniqueVisits uint64 `db:"unique_visits" json:"unique_visits"`
+}
@@ -0,0 +1,3 @@
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE NULL,
+    ADD COLUMN IF NOT EXISTS max_visits BIGINT                   NULL;
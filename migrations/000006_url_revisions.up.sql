@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS url_revisions
+(
+    id           SERIAL PRIMARY KEY,
+    url_id       INTEGER                  NOT NULL REFERENCES urls (id),
+    original_url VARCHAR(2048)            NOT NULL,
+    changed_by   INTEGER                  NOT NULL REFERENCES accounts (id),
+    changed_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS url_revisions_url_id ON url_revisions USING btree (url_id, id);
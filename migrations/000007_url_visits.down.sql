@@ -0,0 +1 @@
+DROP TABLE IF EXISTS url_visits;
\ No newline at end of file
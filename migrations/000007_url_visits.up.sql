@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS url_visits
+(
+    id              BIGSERIAL PRIMARY KEY,
+    url_id          INTEGER                  NOT NULL REFERENCES urls (id),
+    visited_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    referrer        VARCHAR(2048)            NOT NULL DEFAULT '',
+    user_agent      VARCHAR(512)             NOT NULL DEFAULT '',
+    client_ip       VARCHAR(45)              NOT NULL DEFAULT '',
+    accept_language VARCHAR(256)             NOT NULL DEFAULT '',
+    unique_visit    BOOLEAN                  NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS url_visits_url_id ON url_visits USING btree (url_id, id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS url_visit_rollups;
\ No newline at end of file
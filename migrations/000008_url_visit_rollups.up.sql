@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS url_visit_rollups
+(
+    url_id        INTEGER                  NOT NULL REFERENCES urls (id),
+    bucket        TIMESTAMP WITH TIME ZONE NOT NULL,
+    total_visits  BIGINT                   NOT NULL DEFAULT 0,
+    unique_visits BIGINT                   NOT NULL DEFAULT 0,
+    PRIMARY KEY (url_id, bucket)
+);
+
+INSERT INTO url_visit_rollups(url_id, bucket, total_visits, unique_visits)
+SELECT url_id,
+       date_trunc('hour', visited_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+       COUNT(*),
+       COUNT(*) FILTER (WHERE unique_visit)
+FROM url_visits
+GROUP BY 1, 2
+ON CONFLICT DO NOTHING;
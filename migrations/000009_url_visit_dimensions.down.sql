@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS url_visits_url_id_visited_at;
+
+ALTER TABLE url_visits
+    DROP COLUMN IF EXISTS referrer_domain,
+    DROP COLUMN IF EXISTS device,
+    DROP COLUMN IF EXISTS browser,
+    DROP COLUMN IF EXISTS os;
\ No newline at end of file
@@ -0,0 +1,12 @@
+ALTER TABLE url_visits
+    ADD COLUMN IF NOT EXISTS referrer_domain VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS device          VARCHAR(16)  NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS browser         VARCHAR(32)  NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS os              VARCHAR(32)  NOT NULL DEFAULT '';
+
+-- user agents of earlier visits are not classified, since the parser lives in the application.
+UPDATE url_visits
+SET referrer_domain = lower(coalesce(substring(referrer FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?(?:www\.)?([^/:?#]+)'), ''))
+WHERE referrer <> '';
+
+CREATE INDEX IF NOT EXISTS url_visits_url_id_visited_at ON url_visits USING btree (url_id, visited_at);
@@ -0,0 +1,3 @@
+ALTER TABLE url_visits
+    DROP COLUMN IF EXISTS country,
+    DROP COLUMN IF EXISTS city;
\ No newline at end of file
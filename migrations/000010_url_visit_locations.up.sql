@@ -0,0 +1,3 @@
+ALTER TABLE url_visits
+    ADD COLUMN IF NOT EXISTS country VARCHAR(2)   NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS city    VARCHAR(128) NOT NULL DEFAULT '';
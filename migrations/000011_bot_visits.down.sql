@@ -0,0 +1,8 @@
+ALTER TABLE url_visit_rollups
+    DROP COLUMN IF EXISTS bot_visits;
+
+ALTER TABLE url_visits
+    DROP COLUMN IF EXISTS bot;
+
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS bot_visits;
\ No newline at end of file
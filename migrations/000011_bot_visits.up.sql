@@ -0,0 +1,8 @@
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS bot_visits BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE url_visits
+    ADD COLUMN IF NOT EXISTS bot BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE url_visit_rollups
+    ADD COLUMN IF NOT EXISTS bot_visits BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS account_tokens;
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS email_verified;
\ No newline at end of file
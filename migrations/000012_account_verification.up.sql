@@ -0,0 +1,19 @@
+-- accounts that existed before verification was introduced are considered verified.
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE accounts
+    ALTER COLUMN email_verified SET DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS account_tokens
+(
+    id          SERIAL PRIMARY KEY,
+    account_id  INTEGER                  NOT NULL REFERENCES accounts (id),
+    purpose     VARCHAR(32)              NOT NULL,
+    token_hash  VARCHAR(64)              NOT NULL UNIQUE,
+    valid_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at     TIMESTAMP WITH TIME ZONE,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS account_tokens_account_id ON account_tokens USING btree (account_id, purpose);
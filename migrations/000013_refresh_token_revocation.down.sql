@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS refresh_tokens_account_id;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS revoked;
\ No newline at end of file
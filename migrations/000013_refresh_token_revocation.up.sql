@@ -0,0 +1,4 @@
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS revoked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_account_id ON refresh_tokens USING btree (account_id);
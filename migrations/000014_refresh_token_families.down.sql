@@ -0,0 +1 @@
+DROP TABLE IF EXISTS refresh_token_families;
\ No newline at end of file
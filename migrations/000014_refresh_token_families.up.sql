@@ -0,0 +1,18 @@
+-- every refresh token family is a session of an account.
+CREATE TABLE IF NOT EXISTS refresh_token_families
+(
+    family       INTEGER PRIMARY KEY,
+    account_id   INTEGER                  NOT NULL REFERENCES accounts (id),
+    user_agent   VARCHAR(512)             NOT NULL DEFAULT '',
+    client_ip    VARCHAR(64)              NOT NULL DEFAULT '',
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS refresh_token_families_account_id ON refresh_token_families USING btree (account_id);
+
+INSERT INTO refresh_token_families (family, account_id, created_at, last_used_at)
+SELECT family, MIN(account_id), MIN(created_at), MAX(created_at)
+FROM refresh_tokens
+GROUP BY family
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           SERIAL PRIMARY KEY,
+    account_id   INTEGER                  NOT NULL REFERENCES accounts (id),
+    name         VARCHAR(64)              NOT NULL,
+    prefix       VARCHAR(16)              NOT NULL,
+    key_hash     VARCHAR(64)              NOT NULL UNIQUE,
+    scopes       VARCHAR(256)             NOT NULL,
+    expires_at   TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked      BOOLEAN                  NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_account_id ON api_keys USING btree (account_id);
@@ -0,0 +1,5 @@
+UPDATE api_keys
+SET scopes = TRIM(BOTH ',' FROM REPLACE(',' || scopes || ',', ',stats:read,', ','));
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS admin;
\ No newline at end of file
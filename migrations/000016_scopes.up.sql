@@ -0,0 +1,8 @@
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- reading stats used to be part of urls:read
+UPDATE api_keys
+SET scopes = scopes || ',stats:read'
+WHERE ',' || scopes || ',' LIKE '%,urls:read,%'
+  AND ',' || scopes || ',' NOT LIKE '%,stats:read,%';
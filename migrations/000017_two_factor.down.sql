@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS two_factor_secrets;
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS two_factor_enabled;
\ No newline at end of file
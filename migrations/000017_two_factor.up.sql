@@ -0,0 +1,21 @@
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS two_factor_secrets
+(
+    account_id        INTEGER PRIMARY KEY REFERENCES accounts (id),
+    secret            BYTEA                    NOT NULL,
+    -- codes can only be used once, so only codes of later periods are accepted
+    last_used_counter BIGINT                   NOT NULL DEFAULT 0,
+    created_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes
+(
+    id         SERIAL PRIMARY KEY,
+    account_id INTEGER     NOT NULL REFERENCES accounts (id),
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS recovery_codes_account_id ON recovery_codes USING btree (account_id);
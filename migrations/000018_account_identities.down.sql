@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS account_identities;
+
+-- an empty hash matches no password
+UPDATE accounts
+SET password_hash = ''
+WHERE password_hash IS NULL;
+
+ALTER TABLE accounts
+    ALTER COLUMN password_hash SET NOT NULL;
\ No newline at end of file
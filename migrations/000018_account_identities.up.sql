@@ -0,0 +1,14 @@
+-- accounts created through single sign-on have no password
+ALTER TABLE accounts
+    ALTER COLUMN password_hash DROP NOT NULL;
+
+CREATE TABLE IF NOT EXISTS account_identities
+(
+    issuer     VARCHAR(256)             NOT NULL,
+    subject    VARCHAR(256)             NOT NULL,
+    account_id INTEGER                  NOT NULL REFERENCES accounts (id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS account_identities_account_id ON account_identities USING btree (account_id);
@@ -0,0 +1,2 @@
+ALTER TABLE refresh_tokens
+    RENAME COLUMN token_hash TO token;
\ No newline at end of file
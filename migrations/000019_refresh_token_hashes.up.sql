@@ -0,0 +1,8 @@
+-- refresh tokens were saved as they were handed out, and hashing them needs a key the database does not have.
+-- they are revoked instead, so every session has to log in once more.
+UPDATE refresh_tokens
+SET token   = 'revoked:' || id,
+    revoked = TRUE;
+
+ALTER TABLE refresh_tokens
+    RENAME COLUMN token TO token_hash;
@@ -0,0 +1,21 @@
+-- urls and revisions left behind by deleted accounts have no owner to go back to, and deleting them would destroy
+-- links that are still being visited. they have to be dealt with by hand before going down.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM urls WHERE account_id IS NULL) THEN
+        RAISE EXCEPTION 'urls of deleted accounts exist, transfer or delete them before migrating down';
+    END IF;
+    IF EXISTS (SELECT 1 FROM url_revisions WHERE changed_by IS NULL) THEN
+        RAISE EXCEPTION 'url revisions made by deleted accounts exist, delete them before migrating down';
+    END IF;
+END
+$$;
+
+ALTER TABLE url_revisions
+    ALTER COLUMN changed_by SET NOT NULL;
+
+ALTER TABLE urls
+    ALTER COLUMN account_id SET NOT NULL;
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS pending_email;
@@ -0,0 +1,9 @@
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS pending_email VARCHAR(64) NULL;
+
+-- urls and revisions outlive accounts deleted with the anonymize policy
+ALTER TABLE urls
+    ALTER COLUMN account_id DROP NOT NULL;
+
+ALTER TABLE url_revisions
+    ALTER COLUMN changed_by DROP NOT NULL;
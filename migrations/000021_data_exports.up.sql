@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS data_exports
+(
+    id           SERIAL PRIMARY KEY,
+    account_id   INTEGER                  NOT NULL REFERENCES accounts (id),
+    status       VARCHAR(16)              NOT NULL DEFAULT 'pending',
+    archive      BYTEA,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at   TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at   TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS data_exports_account_id ON data_exports USING btree (account_id);
+
+-- an account has at most one export waiting to be built.
+CREATE UNIQUE INDEX IF NOT EXISTS data_exports_account_id_unfinished ON data_exports USING btree (account_id)
+    WHERE status IN ('pending', 'running');
@@ -0,0 +1,2 @@
+ALTER TABLE data_exports
+    DROP COLUMN IF EXISTS download_nonce;
@@ -0,0 +1,4 @@
+-- download links are bound to a nonce of their export, so that the signing key alone is not enough to forge one.
+-- exports from before have none and can no longer be downloaded.
+ALTER TABLE data_exports
+    ADD COLUMN IF NOT EXISTS download_nonce VARCHAR(64) NOT NULL DEFAULT '';
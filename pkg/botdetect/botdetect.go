@@ -0,0 +1,48 @@
+package botdetect
+
+import (
+	"net/http"
+	"strings"
+)
+
+type Detector interface {
+	// IsBot tells whether a request is made by an automated client rather than a person.
+	IsBot(r *http.Request) bool
+}
+
+type v1 struct {
+	signatures []string
+}
+
+// NewDetectorV1 returns a detector that looks for any of the signatures in the User-Agent header, case-insensitively.
+func NewDetectorV1(signatures []string) Detector {
+	lowered := make([]string, 0, len(signatures))
+	for _, signature := range signatures {
+		signature = strings.ToLower(strings.TrimSpace(signature))
+		if signature != "" {
+			lowered = append(lowered, signature)
+		}
+	}
+
+	return &v1{signatures: lowered}
+}
+
+func (d v1) IsBot(r *http.Request) bool {
+	// browsers follow links with GET and always send an Accept header. previews and link checkers often do not.
+	if r.Method == http.MethodHead || r.Header.Get("Accept") == "" {
+		return true
+	}
+
+	userAgent := strings.ToLower(r.UserAgent())
+	if userAgent == "" {
+		return true
+	}
+
+	for _, signature := range d.signatures {
+		if strings.Contains(userAgent, signature) {
+			return true
+		}
+	}
+
+	return false
+}
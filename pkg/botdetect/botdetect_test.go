@@ -0,0 +1,44 @@
+package botdetect_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/h3isenbug/url-shortener/pkg/botdetect"
+	"github.com/stretchr/testify/assert"
+)
+
+const firefox = "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:93.0) Gecko/20100101 Firefox/93.0"
+
+func TestIsBot(t *testing.T) {
+	detector := botdetect.NewDetectorV1([]string{"Slackbot", " TelegramBot ", "", "facebookexternalhit"})
+
+	testCases := []struct {
+		name      string
+		method    string
+		userAgent string
+		accept    string
+		isBot     bool
+	}{
+		{"browser", http.MethodGet, firefox, "text/html", false},
+		{"head request", http.MethodHead, firefox, "text/html", true},
+		{"missing accept header", http.MethodGet, firefox, "", true},
+		{"missing user agent", http.MethodGet, "", "*/*", true},
+		{"signature", http.MethodGet, "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", "*/*", true},
+		{"signature in another case", http.MethodGet, "telegrambot (like TwitterBot)", "*/*", true},
+		{"unlisted client", http.MethodGet, "Twitterbot/1.0", "*/*", false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := httptest.NewRequest(testCase.method, "/goog", nil)
+			r.Header.Set("User-Agent", testCase.userAgent)
+			if testCase.accept != "" {
+				r.Header.Set("Accept", testCase.accept)
+			}
+
+			assert.Equal(t, testCase.isBot, detector.IsBot(r))
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package breach
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var ErrListNotFound = errors.New("breached password list file not found")
+
+const (
+	hashLength = sha1.Size * 2
+	// PrefixLength is how much of the hash of a password is handed to a source. every hash sharing it is returned,
+	// so the source can not tell which password is checked.
+	PrefixLength = 5
+)
+
+// Source looks up breached passwords by k-anonymity, the way range apis of breach corpora do.
+type Source interface {
+	// Range returns the suffixes of the upper case hex SHA-1 hashes of breached passwords starting with prefix.
+	Range(ctx context.Context, prefix string) ([]string, error)
+	Close() error
+}
+
+// IsBreached tells whether a password is known to be breached. only the prefix of its hash is handed to source.
+func IsBreached(ctx context.Context, source Source, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := source.Range(ctx, hash[:PrefixLength])
+	if err != nil {
+		return false, fmt.Errorf("failed to look up breached passwords: %w", err)
+	}
+
+	for _, suffix := range suffixes {
+		if suffix == hash[PrefixLength:] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+type listV1 struct {
+	suffixes map[string][]string
+}
+
+// NewListSourceV1 reads a list of SHA-1 hashes of breached passwords, one per line. like downloaded breach corpora,
+// a line may end with the number of times the password was seen, e.g. "HASH:42". the count is ignored.
+// the whole list is kept in memory, taking about a hundred bytes per hash. it is meant for lists of up to a few
+// hundred thousand hashes, full corpora are looked up in place by NewFileSourceV1.
+func NewListSourceV1(list io.Reader) (Source, error) {
+	source := &listV1{suffixes: make(map[string][]string)}
+
+	scanner := bufio.NewScanner(list)
+	for line := 1; scanner.Scan(); line++ {
+		hash := strings.TrimSpace(scanner.Text())
+		if hash == "" {
+			continue
+		}
+		if separator := strings.IndexByte(hash, ':'); separator != -1 {
+			hash = hash[:separator]
+		}
+
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != hashLength {
+			return nil, fmt.Errorf("line %d of breached password list is not a SHA-1 hash", line)
+		}
+
+		hash = strings.ToUpper(hash)
+		source.suffixes[hash[:PrefixLength]] = append(source.suffixes[hash[:PrefixLength]], hash[PrefixLength:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+
+	return source, nil
+}
+
+func (s listV1) Range(_ context.Context, prefix string) ([]string, error) {
+	return s.suffixes[strings.ToUpper(prefix)], nil
+}
+
+func (listV1) Close() error {
+	return nil
+}
+
+// readBufferSize fits a few lines of a corpus, which are at most a hash and a count long.
+const readBufferSize = 256
+
+type fileV1 struct {
+	file *os.File
+	size int64
+}
+
+// NewFileSourceV1 looks hashes up in a file of upper case SHA-1 hashes sorted in ascending order, formatted as
+// NewListSourceV1 reads them. corpora are downloaded ordered by hash for this. the file is binary searched on every
+// lookup rather than loaded, so it may be as large as the disk allows, and is kept open until the source is closed.
+// it returns ErrListNotFound if there is no file at the given path.
+func NewFileSourceV1(path string) (Source, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrListNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat breached password list: %w", err)
+	}
+
+	source := &fileV1{file: file, size: info.Size()}
+
+	// a list that is not made of hashes is better found out at start up than by lookups that find nothing
+	if _, hash, err := source.lineAt(0); err != nil || hash == "" && source.size > 0 {
+		file.Close()
+		return nil, errors.New("breached password list has no SHA-1 hashes")
+	}
+
+	return source, nil
+}
+
+func (s fileV1) Close() error {
+	return s.file.Close()
+}
+
+// lineAt returns the first hash whose line starts at or after offset, skipping lines that are not hashes. start is
+// the size of the file if there is no such line.
+func (s fileV1) lineAt(offset int64) (start int64, hash string, err error) {
+	start = offset
+	if offset > 0 {
+		// the byte before offset tells whether offset is at the start of a line
+		start--
+	}
+
+	reader := bufio.NewReaderSize(io.NewSectionReader(s.file, start, s.size-start), readBufferSize)
+	if offset > 0 {
+		skipped, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return 0, "", fmt.Errorf("failed to read breached password list: %w", err)
+		}
+		start += int64(len(skipped))
+	}
+
+	for start < s.size {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return 0, "", fmt.Errorf("failed to read breached password list: %w", err)
+		}
+		if hash := parseLine(line); hash != "" {
+			return start, hash, nil
+		}
+		start += int64(len(line))
+	}
+
+	return s.size, "", nil
+}
+
+func (s fileV1) Range(ctx context.Context, prefix string) ([]string, error) {
+	prefix = strings.ToUpper(prefix)
+
+	// the smallest offset whose next hash is not before prefix
+	low, high := int64(0), s.size
+	for low < high {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		middle := low + (high-low)/2
+		start, hash, err := s.lineAt(middle)
+		if err != nil {
+			return nil, err
+		}
+
+		if start < s.size && hash[:PrefixLength] < prefix {
+			low = middle + 1
+		} else {
+			high = middle
+		}
+	}
+
+	start, _, err := s.lineAt(low)
+	if err != nil {
+		return nil, err
+	}
+
+	var suffixes []string
+	scanner := bufio.NewScanner(io.NewSectionReader(s.file, start, s.size-start))
+	for scanner.Scan() {
+		hash := parseLine(scanner.Text())
+		if hash == "" {
+			continue
+		}
+		if hash[:PrefixLength] != prefix {
+			break
+		}
+
+		suffixes = append(suffixes, hash[PrefixLength:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+
+	return suffixes, nil
+}
+
+// parseLine returns the upper case hash of a line, or an empty string if the line is not a hash.
+func parseLine(line string) string {
+	hash := strings.TrimSpace(line)
+	if separator := strings.IndexByte(hash, ':'); separator != -1 {
+		hash = hash[:separator]
+	}
+	if !isHash(hash) {
+		return ""
+	}
+
+	return strings.ToUpper(hash)
+}
+
+func isHash(hash string) bool {
+	if len(hash) != hashLength {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+
+	return err == nil
+}
+
+type noop struct{}
+
+// NewNoopSource returns a source that knows no breached passwords, for when there is no list to check against.
+func NewNoopSource() Source {
+	return noop{}
+}
+
+func (noop) Range(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func (noop) Close() error {
+	return nil
+}
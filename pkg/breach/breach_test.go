@@ -0,0 +1,149 @@
+package breach_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/h3isenbug/url-shortener/pkg/breach"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the sha-1 hashes of "password1" and "correct horse battery staple"
+const list = `
+E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D:2413945
+abf7aad6438836dbe526aa231abde2d0eef74d42
+`
+
+// recordingSource remembers what it is asked for.
+type recordingSource struct {
+	breach.Source
+	prefixes []string
+}
+
+func (s *recordingSource) Range(ctx context.Context, prefix string) ([]string, error) {
+	s.prefixes = append(s.prefixes, prefix)
+
+	return s.Source.Range(ctx, prefix)
+}
+
+func TestIsBreached(t *testing.T) {
+	source, err := breach.NewListSourceV1(strings.NewReader(list))
+	require.NoError(t, err)
+
+	tests := map[string]bool{
+		"password1":                    true,
+		"correct horse battery staple": true,
+		"Password1":                    false,
+		"kQ9#vLr2!xWm":                 false,
+	}
+
+	for password, breached := range tests {
+		actual, err := breach.IsBreached(context.Background(), source, password)
+		require.NoError(t, err)
+		assert.Equal(t, breached, actual, password)
+	}
+}
+
+func TestOnlyPrefixIsHandedToSource(t *testing.T) {
+	listSource, err := breach.NewListSourceV1(strings.NewReader(list))
+	require.NoError(t, err)
+	source := &recordingSource{Source: listSource}
+
+	breached, err := breach.IsBreached(context.Background(), source, "password1")
+	require.NoError(t, err)
+	assert.True(t, breached)
+	assert.Equal(t, []string{"E38AD"}, source.prefixes)
+}
+
+func TestInvalidList(t *testing.T) {
+	_, err := breach.NewListSourceV1(strings.NewReader(list + "password1\n"))
+	assert.EqualError(t, err, "line 4 of breached password list is not a SHA-1 hash")
+}
+
+func TestFileSource(t *testing.T) {
+	var hashes []string
+	for i := 0; i < 500; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("password%d", i)))
+		hashes = append(hashes, fmt.Sprintf("%X:%d", sum, i+1))
+	}
+	sort.Strings(hashes)
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(hashes, "\r\n")), 0600))
+
+	source, err := breach.NewFileSourceV1(path)
+	require.NoError(t, err)
+
+	for i := 0; i < 500; i++ {
+		breached, err := breach.IsBreached(context.Background(), source, fmt.Sprintf("password%d", i))
+		require.NoError(t, err)
+		assert.True(t, breached, i)
+	}
+
+	breached, err := breach.IsBreached(context.Background(), source, "kQ9#vLr2!xWm")
+	require.NoError(t, err)
+	assert.False(t, breached)
+
+	// every hash sharing the prefix is returned
+	suffixes, err := source.Range(context.Background(), hashes[0][:breach.PrefixLength])
+	require.NoError(t, err)
+	assert.Contains(t, suffixes, hashes[0][breach.PrefixLength:40])
+	suffixes, err = source.Range(context.Background(), "00000")
+	require.NoError(t, err)
+	assert.Empty(t, suffixes)
+	suffixes, err = source.Range(context.Background(), "FFFFF")
+	require.NoError(t, err)
+	assert.Empty(t, suffixes)
+
+	assert.NoError(t, source.Close())
+}
+
+func TestFileSourceSharedPrefix(t *testing.T) {
+	const sorted = `0000000000000000000000000000000000000001:4
+E38AC00000000000000000000000000000000000:1
+E38AD00000000000000000000000000000000000:7
+E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D:2413945
+
+E38ADFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:3
+E38AE00000000000000000000000000000000000:1
+`
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(sorted), 0600))
+
+	source, err := breach.NewFileSourceV1(path)
+	require.NoError(t, err)
+
+	suffixes, err := source.Range(context.Background(), "e38ad")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"00000000000000000000000000000000000",
+		"214943DAAD1D64C102FAEC29DE4AFE9DA3D",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+	}, suffixes)
+}
+
+func TestInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("password1\n"), 0600))
+
+	_, err := breach.NewFileSourceV1(path)
+	assert.Error(t, err)
+}
+
+func TestMissingFile(t *testing.T) {
+	_, err := breach.NewFileSourceV1(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.ErrorIs(t, err, breach.ErrListNotFound)
+}
+
+func TestNoopSource(t *testing.T) {
+	breached, err := breach.IsBreached(context.Background(), breach.NewNoopSource(), "password1")
+	require.NoError(t, err)
+	assert.False(t, breached)
+}
@@ -0,0 +1,86 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var ErrDatabaseNotFound = errors.New("geoip database file not found")
+
+type Location struct {
+	// Country is the ISO 3166-1 alpha-2 code of the country.
+	Country string
+	// City is the english name of the city.
+	City string
+}
+
+type Locator interface {
+	// Lookup returns the location of an ip address. unknown parts of the location are left empty.
+	Lookup(ip string) Location
+	Close() error
+}
+
+type maxMindRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+type maxMindV1 struct {
+	reader *maxminddb.Reader
+}
+
+// NewMaxMindLocatorV1 reads a MaxMind-format (.mmdb) country or city database.
+// it returns ErrDatabaseNotFound if there is no file at the given path.
+func NewMaxMindLocatorV1(path string) (Locator, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrDatabaseNotFound, path)
+	}
+
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geoip database: %w", err)
+	}
+
+	return &maxMindV1{reader: reader}, nil
+}
+
+func (l maxMindV1) Lookup(ip string) Location {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Location{}
+	}
+
+	var record maxMindRecord
+	if err := l.reader.Lookup(parsed, &record); err != nil {
+		return Location{}
+	}
+
+	return Location{Country: record.Country.ISOCode, City: record.City.Names["en"]}
+}
+
+func (l maxMindV1) Close() error {
+	return l.reader.Close()
+}
+
+type noop struct{}
+
+// NewNoopLocator returns a locator that knows no locations, for when there is no geoip database.
+func NewNoopLocator() Locator {
+	return noop{}
+}
+
+func (noop) Lookup(string) Location {
+	return Location{}
+}
+
+func (noop) Close() error {
+	return nil
+}
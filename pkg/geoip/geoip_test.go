@@ -0,0 +1,32 @@
+package geoip_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/h3isenbug/url-shortener/pkg/geoip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingDatabase(t *testing.T) {
+	_, err := geoip.NewMaxMindLocatorV1(filepath.Join(t.TempDir(), "missing.mmdb"))
+	assert.ErrorIs(t, err, geoip.ErrDatabaseNotFound)
+}
+
+func TestInvalidDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("not a maxmind database"), 0600))
+
+	_, err := geoip.NewMaxMindLocatorV1(path)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, geoip.ErrDatabaseNotFound)
+}
+
+func TestNoopLocator(t *testing.T) {
+	locator := geoip.NewNoopLocator()
+
+	assert.Equal(t, geoip.Location{}, locator.Lookup("8.8.8.8"))
+	assert.NoError(t, locator.Close())
+}
@@ -0,0 +1,138 @@
+package jwk_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/h3isenbug/url-shortener/pkg/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustEncodePKCS8(t *testing.T, privateKey crypto.Signer) string {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func indent(value string) string {
+	return "      " + strings.ReplaceAll(strings.TrimSpace(value), "\n", "\n      ")
+}
+
+func TestLoadKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaPKCS1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+
+	keys, currentKID, err := jwk.LoadKeys(strings.NewReader(`current: "ed-key"
+secrets:
+  - name: "hmac-key"
+    value: "gvZfYsBDq55bQGVuYuKAfo5RBmZrc0JPAa8LaSZcuqQqiAhMXdvUZw=="
+  - name: "rsa-key"
+    algorithm: "RS256"
+    privateKey: |
+` + indent(string(rsaPKCS1)) + `
+  - name: "ec-key"
+    algorithm: "ES256"
+    privateKey: |
+` + indent(mustEncodePKCS8(t, ecKey)) + `
+  - name: "ed-key"
+    algorithm: "EdDSA"
+    privateKey: |
+` + indent(mustEncodePKCS8(t, edKey)) + `
+`))
+	require.NoError(t, err)
+	require.Len(t, keys, 4)
+	assert.Equal(t, "ed-key", currentKID)
+
+	assert.True(t, keys["hmac-key"].Symmetric())
+	assert.Equal(t, "HS512", keys["hmac-key"].Method.Alg())
+	assert.Equal(t, "RS256", keys["rsa-key"].Method.Alg())
+	assert.Equal(t, "ES256", keys["ec-key"].Method.Alg())
+	assert.Equal(t, "EdDSA", keys["ed-key"].Method.Alg())
+	assert.Equal(t, edKey.Public(), keys["ed-key"].VerificationKey())
+
+	keySet := keys.JSONWebKeySet()
+	require.Len(t, keySet.Keys, 3)
+	assert.Equal(t, []string{"ec-key", "ed-key", "rsa-key"}, []string{
+		keySet.Keys[0].KeyID, keySet.Keys[1].KeyID, keySet.Keys[2].KeyID,
+	})
+
+	for _, key := range keySet.Keys {
+		publicKey, err := key.PublicKey()
+		require.NoError(t, err)
+		assert.Equal(t, keys[key.KeyID].VerificationKey(), publicKey)
+	}
+}
+
+func TestLoadKeysRejectsInvalidFiles(t *testing.T) {
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"not yaml": "secrets: [",
+		"duplicate name": `secrets:
+  - name: "key"
+    value: "Zmlyc3Q="
+  - name: "key"
+    value: "c2Vjb25k"`,
+		"unknown current key": `current: "other"
+secrets:
+  - name: "key"
+    value: "Zmlyc3Q="`,
+		"missing name": `secrets:
+  - value: "Zmlyc3Q="`,
+		"bad base64": `secrets:
+  - name: "key"
+    value: "not base64"`,
+		"unknown algorithm": `secrets:
+  - name: "key"
+    algorithm: "none"
+    privateKey: |
+` + indent(mustEncodePKCS8(t, edKey)),
+		"key does not match algorithm": `secrets:
+  - name: "key"
+    algorithm: "RS256"
+    privateKey: |
+` + indent(mustEncodePKCS8(t, edKey)),
+		"wrong curve": `secrets:
+  - name: "key"
+    algorithm: "ES256"
+    privateKey: |
+` + indent(mustEncodePKCS8(t, p384Key)),
+		"weak rsa key": `secrets:
+  - name: "key"
+    algorithm: "RS256"
+    privateKey: |
+` + indent(mustEncodePKCS8(t, smallRSAKey)),
+		"not pem": `secrets:
+  - name: "key"
+    algorithm: "EdDSA"
+    privateKey: "MC4CAQAwBQYDK2VwBCIEIA=="`,
+	}
+
+	for name, file := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := jwk.LoadKeys(strings.NewReader(file))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package jwk
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/pkg/log"
+)
+
+// Keyring hands out the keys tokens are signed and verified with. it is safe for concurrent use, and what it hands
+// out may change between calls.
+type Keyring interface {
+	// Current returns the key new tokens are signed with.
+	Current() *SigningKey
+	Get(kid string) (*SigningKey, bool)
+	JSONWebKeySet() JSONWebKeySet
+}
+
+// keyringState is never modified once stored, so the keys and the current kid always change together.
+type keyringState struct {
+	keys    Keys
+	current *SigningKey
+	keySet  JSONWebKeySet
+}
+
+func newKeyringState(keys Keys, currentKID string) (*keyringState, error) {
+	current, ok := keys[currentKID]
+	if !ok {
+		return nil, fmt.Errorf("no secret was found with kid %s", currentKID)
+	}
+
+	return &keyringState{keys: keys, current: current, keySet: keys.JSONWebKeySet()}, nil
+}
+
+type staticKeyring struct {
+	state *keyringState
+}
+
+func NewStaticKeyring(keys Keys, currentKID string) (Keyring, error) {
+	state, err := newKeyringState(keys, currentKID)
+	if err != nil {
+		return nil, err
+	}
+
+	return staticKeyring{state: state}, nil
+}
+
+func (k staticKeyring) Current() *SigningKey {
+	return k.state.current
+}
+
+func (k staticKeyring) Get(kid string) (*SigningKey, bool) {
+	key, ok := k.state.keys[kid]
+	return key, ok
+}
+
+func (k staticKeyring) JSONWebKeySet() JSONWebKeySet {
+	return k.state.keySet
+}
+
+// FileKeyring reads its keys from a secrets file, and reads it again on SIGHUP or once it changes. a file that can
+// not be loaded is logged and otherwise ignored, the keys loaded before it stay in use.
+type FileKeyring struct {
+	logger     log.Logger
+	path       string
+	defaultKID string
+
+	state atomic.Value
+
+	// lock serializes reloads, so that an older read of the file is never stored over a newer one.
+	lock sync.Mutex
+	// modTime and size are of the file last read, loaded or not, so that a rejected file is only reported once.
+	modTime time.Time
+	size    int64
+
+	hangup  chan os.Signal
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewFileKeyring fails if the file can not be loaded. defaultKID is signed with unless the file names another key.
+// the file is checked for changes every pollInterval until Close is called.
+func NewFileKeyring(logger log.Logger, path, defaultKID string, pollInterval time.Duration) (*FileKeyring, error) {
+	keyring := &FileKeyring{
+		logger:     logger,
+		path:       path,
+		defaultKID: defaultKID,
+		hangup:     make(chan os.Signal, 1),
+		stop:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+
+	if err := keyring.Reload(); err != nil {
+		return nil, err
+	}
+
+	// registered before returning, a SIGHUP nobody listens to would terminate the process
+	signal.Notify(keyring.hangup, syscall.SIGHUP)
+	go keyring.watch(pollInterval)
+
+	return keyring, nil
+}
+
+func (k *FileKeyring) loadedState() *keyringState {
+	return k.state.Load().(*keyringState)
+}
+
+func (k *FileKeyring) Current() *SigningKey {
+	return k.loadedState().current
+}
+
+func (k *FileKeyring) Get(kid string) (*SigningKey, bool) {
+	key, ok := k.loadedState().keys[kid]
+	return key, ok
+}
+
+func (k *FileKeyring) JSONWebKeySet() JSONWebKeySet {
+	return k.loadedState().keySet
+}
+
+// Reload reads the file and swaps the keys in, unless the file is invalid.
+func (k *FileKeyring) Reload() error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	file, err := os.Open(k.path)
+	if err != nil {
+		return fmt.Errorf("failed to read secrets: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read secrets: %w", err)
+	}
+	k.modTime, k.size = info.ModTime(), info.Size()
+
+	keys, currentKID, err := LoadKeys(file)
+	if err != nil {
+		return fmt.Errorf("failed to load secrets: %w", err)
+	}
+	if currentKID == "" {
+		currentKID = k.defaultKID
+	}
+
+	state, err := newKeyringState(keys, currentKID)
+	if err != nil {
+		return fmt.Errorf("secrets are not configured correctly: %w", err)
+	}
+
+	k.state.Store(state)
+
+	kids := make([]string, 0, len(keys))
+	for kid := range keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	k.logger.Info("loaded signing keys", map[string]interface{}{
+		"path":       k.path,
+		"kids":       kids,
+		"currentKID": currentKID,
+	})
+
+	return nil
+}
+
+// changed compares the file with what was last read. secrets mounted by kubernetes are swapped through a symlink,
+// which file watches lose track of, so the file is polled instead.
+func (k *FileKeyring) changed() bool {
+	info, err := os.Stat(k.path)
+	if err != nil {
+		return false
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	return !info.ModTime().Equal(k.modTime) || info.Size() != k.size
+}
+
+func (k *FileKeyring) reload(reason string) {
+	if err := k.Reload(); err != nil {
+		k.logger.Error("rejected signing keys, previous keys are kept", map[string]interface{}{
+			"path":         k.path,
+			"reason":       reason,
+			"errorMessage": err.Error(),
+		})
+	}
+}
+
+func (k *FileKeyring) watch(pollInterval time.Duration) {
+	defer close(k.stopped)
+	defer signal.Stop(k.hangup)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-k.hangup:
+			k.reload("SIGHUP")
+		case <-ticker.C:
+			if k.changed() {
+				k.reload("file changed")
+			}
+		case <-k.stop:
+			return
+		}
+	}
+}
+
+// Close stops watching the file. the keys loaded last stay in use.
+func (k *FileKeyring) Close() {
+	close(k.stop)
+	<-k.stopped
+}
@@ -0,0 +1,148 @@
+package jwk_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/pkg/jwk"
+	"github.com/h3isenbug/url-shortener/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const firstSecretsFile = `secrets:
+  - name: "first"
+    value: "Zmlyc3Qtc2VjcmV0"
+`
+
+const secondSecretsFile = `current: "second"
+secrets:
+  - name: "first"
+    value: "Zmlyc3Qtc2VjcmV0"
+  - name: "second"
+    value: "c2Vjb25kLXNlY3JldA=="
+`
+
+func createKeyring(t *testing.T, pollInterval time.Duration) (*jwk.FileKeyring, string) {
+	logger, err := log.NewZapLoggingService("")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(firstSecretsFile), 0600))
+
+	keyring, err := jwk.NewFileKeyring(logger, path, "first", pollInterval)
+	require.NoError(t, err)
+	t.Cleanup(keyring.Close)
+
+	return keyring, path
+}
+
+func TestFileKeyringRejectsUnknownCurrentKID(t *testing.T) {
+	logger, err := log.NewZapLoggingService("")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(firstSecretsFile), 0600))
+
+	_, err = jwk.NewFileKeyring(logger, path, "second", time.Hour)
+	assert.Error(t, err)
+}
+
+func TestFileKeyringReload(t *testing.T) {
+	keyring, path := createKeyring(t, time.Hour)
+	assert.Equal(t, "first", keyring.Current().ID)
+
+	_, ok := keyring.Get("second")
+	assert.False(t, ok)
+
+	require.NoError(t, os.WriteFile(path, []byte(secondSecretsFile), 0600))
+	require.NoError(t, keyring.Reload())
+
+	// the kid named by the file wins over the default one
+	assert.Equal(t, "second", keyring.Current().ID)
+	_, ok = keyring.Get("first")
+	assert.True(t, ok)
+}
+
+func TestFileKeyringKeepsKeysOfInvalidFile(t *testing.T) {
+	keyring, path := createKeyring(t, time.Hour)
+
+	for _, file := range []string{"secrets: [", `current: "third"` + "\n" + firstSecretsFile} {
+		require.NoError(t, os.WriteFile(path, []byte(file), 0600))
+		assert.Error(t, keyring.Reload())
+
+		assert.Equal(t, "first", keyring.Current().ID)
+		_, ok := keyring.Get("first")
+		assert.True(t, ok)
+	}
+
+	require.NoError(t, os.Remove(path))
+	assert.Error(t, keyring.Reload())
+	assert.Equal(t, "first", keyring.Current().ID)
+}
+
+func TestFileKeyringReloadsChangedFile(t *testing.T) {
+	keyring, path := createKeyring(t, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(path, []byte(secondSecretsFile), 0600))
+
+	assert.Eventually(t, func() bool {
+		return keyring.Current().ID == "second"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestFileKeyringReloadsOnSIGHUP(t *testing.T) {
+	keyring, path := createKeyring(t, time.Hour)
+
+	require.NoError(t, os.WriteFile(path, []byte(secondSecretsFile), 0600))
+
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(syscall.SIGHUP))
+
+	assert.Eventually(t, func() bool {
+		return keyring.Current().ID == "second"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestFileKeyringIsReadWhileReloading(t *testing.T) {
+	keyring, path := createKeyring(t, time.Hour)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				// both files know the first key, so it is never missing in between
+				_, ok := keyring.Get("first")
+				if !assert.True(t, ok) || !assert.Contains(t, []string{"first", "second"}, keyring.Current().ID) {
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		file := firstSecretsFile
+		if i%2 == 0 {
+			file = secondSecretsFile
+		}
+		require.NoError(t, os.WriteFile(path, []byte(file), 0600))
+		require.NoError(t, keyring.Reload())
+	}
+
+	close(stop)
+	wg.Wait()
+}
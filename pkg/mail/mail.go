@@ -0,0 +1,136 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidMessage = errors.New("message can not be sent")
+
+type Message struct {
+	To      string
+	Subject string
+	// Body is sent as plain text.
+	Body string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+type smtpV1 struct {
+	host    string
+	address string
+	from    string
+	auth    smtp.Auth
+}
+
+// NewSMTPMailerV1 returns a mailer that delivers messages through an SMTP server. STARTTLS is used whenever the
+// server offers it and authentication is skipped when username is empty(e.g. for a local test server).
+func NewSMTPMailerV1(host string, port int, username, password, from string) Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpV1{
+		host:    host,
+		address: net.JoinHostPort(host, strconv.Itoa(port)),
+		from:    from,
+		auth:    auth,
+	}
+}
+
+func (m smtpV1) Send(ctx context.Context, message Message) error {
+	if strings.ContainsAny(message.To, "\r\n") || strings.ContainsAny(message.Subject, "\r\n") {
+		return fmt.Errorf("%w: headers must not contain line breaks", ErrInvalidMessage)
+	}
+
+	content, err := m.compose(message)
+	if err != nil {
+		return fmt.Errorf("failed to compose message: %w", err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to set deadline of smtp connection: %w", err)
+		}
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+	if m.auth != nil {
+		if err := client.Auth(m.auth); err != nil {
+			return fmt.Errorf("failed to authenticate to smtp server: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return fmt.Errorf("smtp server rejected sender: %w", err)
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return fmt.Errorf("smtp server rejected recipient: %w", err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start sending message: %w", err)
+	}
+	if _, err := writer.Write(content); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("smtp server rejected message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (m smtpV1) compose(message Message) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "From: %s\r\n", m.from)
+	fmt.Fprintf(&buffer, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buffer.WriteString("\r\n")
+
+	writer := quotedprintable.NewWriter(&buffer)
+	if _, err := writer.Write([]byte(message.Body)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
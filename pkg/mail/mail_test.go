@@ -0,0 +1,130 @@
+package mail_test
+
+import (
+	"context"
+	"io/ioutil"
+	"mime/quotedprintable"
+	"net"
+	netMail "net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type received struct {
+	from string
+	to   string
+	data string
+}
+
+// startSMTPServer accepts a single session and hands over what it received once the session is over.
+func startSMTPServer(t *testing.T) (host string, port int, messages <-chan received) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	output := make(chan received, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		session := textproto.NewConn(conn)
+		var message received
+
+		session.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := session.ReadLine()
+			if err != nil {
+				return
+			}
+
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case command == "EHLO" || command == "HELO":
+				session.PrintfLine("250 localhost")
+			case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+				message.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				session.PrintfLine("250 OK")
+			case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+				message.to = strings.Trim(line[len("RCPT TO:"):], "<>")
+				session.PrintfLine("250 OK")
+			case command == "DATA":
+				session.PrintfLine("354 go ahead")
+				data, err := ioutil.ReadAll(session.DotReader())
+				if err != nil {
+					return
+				}
+				message.data = string(data)
+				session.PrintfLine("250 OK")
+			case command == "QUIT":
+				session.PrintfLine("221 bye")
+				output <- message
+				return
+			default:
+				session.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+
+	address := listener.Addr().(*net.TCPAddr)
+
+	return address.IP.String(), address.Port, output
+}
+
+func TestSendOverSMTP(t *testing.T) {
+	host, port, messages := startSMTPServer(t)
+	mailer := mail.NewSMTPMailerV1(host, port, "", "", "no-reply@short.ir")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	err := mailer.Send(ctx, mail.Message{
+		To:      "h.kalantari.1997@gmail.com",
+		Subject: "Verify your email address",
+		Body:    "Open this link to verify your email address: https://short.ir/verify?token=abc",
+	})
+	require.NoError(t, err)
+
+	message := <-messages
+	assert.Equal(t, "no-reply@short.ir", message.from)
+	assert.Equal(t, "h.kalantari.1997@gmail.com", message.to)
+
+	parsed, err := netMail.ReadMessage(strings.NewReader(message.data))
+	require.NoError(t, err)
+	assert.Equal(t, "h.kalantari.1997@gmail.com", parsed.Header.Get("To"))
+	assert.Equal(t, "Verify your email address", parsed.Header.Get("Subject"))
+
+	body, err := ioutil.ReadAll(quotedprintable.NewReader(parsed.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "Open this link to verify your email address: https://short.ir/verify?token=abc", strings.TrimSpace(string(body)))
+}
+
+func TestSendRejectsHeaderInjection(t *testing.T) {
+	mailer := mail.NewSMTPMailerV1("127.0.0.1", 1, "", "", "no-reply@short.ir")
+
+	err := mailer.Send(context.Background(), mail.Message{
+		To:      "victim@example.com\r\nBcc: everyone@example.com",
+		Subject: "hello",
+	})
+	assert.ErrorIs(t, err, mail.ErrInvalidMessage)
+}
+
+func TestSendToUnreachableServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	mailer := mail.NewSMTPMailerV1("127.0.0.1", port, "", "", "no-reply@short.ir")
+
+	err = mailer.Send(context.Background(), mail.Message{To: "h.kalantari.1997@gmail.com", Subject: "hello"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,22 @@
+package oidc
+
+import "github.com/h3isenbug/url-shortener/pkg/jwk"
+
+// publicKeys returns the signing keys of the set by kid. keys that can not be used to verify signatures are skipped.
+func publicKeys(keySet jwk.JSONWebKeySet) map[string]interface{} {
+	keys := make(map[string]interface{}, len(keySet.Keys))
+	for _, key := range keySet.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := key.PublicKey()
+		if err != nil {
+			continue
+		}
+
+		keys[key.KeyID] = publicKey
+	}
+
+	return keys
+}
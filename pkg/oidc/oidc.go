@@ -0,0 +1,345 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/h3isenbug/url-shortener/pkg/jwk"
+)
+
+var (
+	ErrInvalidIDToken = errors.New("id token is invalid")
+	// ErrProviderRejected is returned when the provider refuses to exchange the code(e.g. it is expired or was used).
+	ErrProviderRejected = errors.New("provider rejected the authorization code")
+)
+
+// keyRefreshInterval keeps tokens signed with unknown keys from making every request fetch the keys again.
+const keyRefreshInterval = time.Minute
+
+const maxResponseSize = 1 << 20
+
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Claims are what is known about the user once they are authenticated by the provider.
+type Claims struct {
+	Subject       string
+	EMail         string
+	EMailVerified bool
+}
+
+// Provider is an OpenID Connect provider that users are sent to for the authorization code flow.
+type Provider interface {
+	Issuer() string
+	// AuthorizationURL returns where users are sent to log in. codeChallenge is the S256 PKCE challenge.
+	AuthorizationURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange redeems the code the provider redirected back with and verifies the id token it returns.
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error)
+}
+
+// NewCodeVerifier returns a random PKCE code verifier.
+func NewCodeVerifier() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge of verifier.
+func CodeChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+
+	Nonce           string `json:"nonce"`
+	AuthorizedParty string `json:"azp"`
+	EMail           string `json:"email"`
+	// EMailVerified is a boolean, though some providers send it as a string.
+	EMailVerified interface{} `json:"email_verified"`
+}
+
+type providerV1 struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+
+	lock          sync.Mutex
+	discovery     *discoveryDocument
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// NewProviderV1 returns a provider that is configured through OpenID Connect discovery. discovery happens on first
+// use, so an unreachable provider does not keep the service from starting.
+func NewProviderV1(issuer, clientID, clientSecret, redirectURL string, client *http.Client) Provider {
+	return &providerV1{
+		issuer:       issuer,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		client:       client,
+	}
+}
+
+func (p *providerV1) Issuer() string {
+	return p.issuer
+}
+
+func (p *providerV1) getJSON(ctx context.Context, url string, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(response.Body, maxResponseSize)).Decode(target)
+}
+
+func (p *providerV1) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var document discoveryDocument
+	err := p.getJSON(ctx, strings.TrimSuffix(p.issuer, "/")+"/.well-known/openid-configuration", &document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover provider %s: %w", p.issuer, err)
+	}
+	if document.Issuer != p.issuer {
+		return nil, fmt.Errorf("provider claims to be %s instead of %s", document.Issuer, p.issuer)
+	}
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.JWKSURI == "" {
+		return nil, errors.New("discovery document of provider is missing endpoints")
+	}
+
+	p.discovery = &document
+
+	return p.discovery, nil
+}
+
+func (p *providerV1) AuthorizationURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authorizationURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse authorization endpoint: %w", err)
+	}
+
+	query := authorizationURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientID)
+	query.Set("redirect_uri", p.redirectURL)
+	query.Set("scope", "openid email")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authorizationURL.RawQuery = query.Encode()
+
+	return authorizationURL.String(), nil
+}
+
+func (p *providerV1) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := p.redeemCode(ctx, discovery, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.verifyIDToken(ctx, discovery, idToken, nonce)
+}
+
+func (p *providerV1) redeemCode(ctx context.Context, discovery *discoveryDocument, code, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.clientID)
+
+	// client_secret_basic is the default of the spec, some providers only accept client_secret_post.
+	useBasicAuth := true
+	if len(discovery.TokenEndpointAuthMethodsSupported) != 0 {
+		useBasicAuth = false
+		for _, method := range discovery.TokenEndpointAuthMethodsSupported {
+			if method == "client_secret_basic" {
+				useBasicAuth = true
+			}
+		}
+	}
+	if !useBasicAuth {
+		form.Set("client_secret", p.clientSecret)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if useBasicAuth {
+		request.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to send token request: %w", err)
+	}
+	defer response.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxResponseSize)).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode token response with status code %d: %w", response.StatusCode, err)
+	}
+
+	if response.StatusCode == http.StatusBadRequest || response.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("%w: %s %s", ErrProviderRejected, body.Error, body.ErrorDescription)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d from token endpoint", response.StatusCode)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: token response has no id token", ErrInvalidIDToken)
+	}
+
+	return body.IDToken, nil
+}
+
+func (p *providerV1) verifyIDToken(
+	ctx context.Context, discovery *discoveryDocument, idToken, nonce string,
+) (*Claims, error) {
+	var claims idTokenClaims
+
+	parser := &jwt.Parser{ValidMethods: signingMethods}
+	_, err := parser.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		return p.getKey(ctx, discovery, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidIDToken, err.Error())
+	}
+
+	if claims.Issuer != discovery.Issuer {
+		return nil, fmt.Errorf("%w: issued by %s", ErrInvalidIDToken, claims.Issuer)
+	}
+	if !claims.VerifyAudience(p.clientID, true) {
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.clientID {
+		return nil, fmt.Errorf("%w: authorized party is %s", ErrInvalidIDToken, claims.AuthorizedParty)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: id token does not expire", ErrInvalidIDToken)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce does not match", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: id token has no subject", ErrInvalidIDToken)
+	}
+
+	emailVerified := false
+	switch value := claims.EMailVerified.(type) {
+	case bool:
+		emailVerified = value
+	case string:
+		emailVerified = value == "true"
+	}
+
+	return &Claims{Subject: claims.Subject, EMail: claims.EMail, EMailVerified: emailVerified}, nil
+}
+
+// getKey returns the key of the provider with the given kid. keys are fetched again when an unknown kid shows up,
+// since providers rotate their keys.
+func (p *providerV1) getKey(ctx context.Context, discovery *discoveryDocument, kid string) (interface{}, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if key, ok := p.findKey(kid); ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchedAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("no key of provider has kid %q", kid)
+	}
+
+	var keySet jwk.JSONWebKeySet
+	if err := p.getJSON(ctx, discovery.JWKSURI, &keySet); err != nil {
+		return nil, fmt.Errorf("failed to fetch keys of provider: %w", err)
+	}
+
+	p.keys = publicKeys(keySet)
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.findKey(kid); ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("no key of provider has kid %q", kid)
+}
+
+// findKey must be called with the lock held. tokens without a kid are accepted when the provider has a single key.
+func (p *providerV1) findKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, ok := p.keys[kid]
+
+	return key, ok
+}
@@ -0,0 +1,226 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/h3isenbug/url-shortener/pkg/oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	clientID     = "url-shortener"
+	clientSecret = "secret"
+	redirectURL  = "https://short.ir/sso/callback"
+)
+
+// fakeProvider is a minimal OpenID Connect provider. it issues an id token for whatever code it is given, as long
+// as the PKCE verifier matches the challenge of the last authorization request.
+type fakeProvider struct {
+	t      *testing.T
+	server *httptest.Server
+
+	signingMethod jwt.SigningMethod
+	privateKey    crypto.PrivateKey
+	jwk           map[string]string
+
+	codeChallenge string
+	claims        jwt.MapClaims
+}
+
+func encodeBigInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+func startFakeProvider(t *testing.T, signingMethod jwt.SigningMethod) *fakeProvider {
+	provider := &fakeProvider{t: t, signingMethod: signingMethod}
+
+	switch signingMethod {
+	case jwt.SigningMethodRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		provider.privateKey = key
+		provider.jwk = map[string]string{
+			"kty": "RSA", "kid": "rsa-key", "use": "sig",
+			"n": encodeBigInt(key.N), "e": encodeBigInt(big.NewInt(int64(key.E))),
+		}
+	case jwt.SigningMethodES256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		provider.privateKey = key
+		provider.jwk = map[string]string{
+			"kty": "EC", "kid": "ec-key", "crv": "P-256", "x": encodeBigInt(key.X), "y": encodeBigInt(key.Y),
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                provider.server.URL,
+			"authorization_endpoint":                provider.server.URL + "/authorize",
+			"token_endpoint":                        provider.server.URL + "/token",
+			"jwks_uri":                              provider.server.URL + "/jwks",
+			"token_endpoint_auth_methods_supported": []string{"client_secret_basic"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{provider.jwk}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != clientID || password != clientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		if oidc.CodeChallenge(r.PostFormValue("code_verifier")) != provider.codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(provider.signingMethod, provider.claims)
+		token.Header["kid"] = provider.jwk["kid"]
+		idToken, err := token.SignedString(provider.privateKey)
+		require.NoError(t, err)
+
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "opaque", "token_type": "Bearer", "id_token": idToken})
+	})
+
+	provider.server = httptest.NewServer(mux)
+	t.Cleanup(provider.server.Close)
+
+	return provider
+}
+
+func (f *fakeProvider) newClient() oidc.Provider {
+	return oidc.NewProviderV1(f.server.URL, clientID, clientSecret, redirectURL, f.server.Client())
+}
+
+func (f *fakeProvider) validClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            f.server.URL,
+		"sub":            "248289761001",
+		"aud":            clientID,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "h.kalantari.1997@gmail.com",
+		"email_verified": true,
+	}
+}
+
+// authorize goes through the authorization request the way a browser would, returning the verifier of the flow.
+func (f *fakeProvider) authorize(provider oidc.Provider, nonce string) string {
+	verifier, err := oidc.NewCodeVerifier()
+	require.NoError(f.t, err)
+
+	authorizationURL, err := provider.AuthorizationURL(context.Background(), "state", nonce, oidc.CodeChallenge(verifier))
+	require.NoError(f.t, err)
+
+	parsed, err := url.Parse(authorizationURL)
+	require.NoError(f.t, err)
+	assert.Equal(f.t, f.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(f.t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.Equal(f.t, redirectURL, parsed.Query().Get("redirect_uri"))
+	assert.Equal(f.t, "state", parsed.Query().Get("state"))
+	f.codeChallenge = parsed.Query().Get("code_challenge")
+
+	return verifier
+}
+
+func TestExchange(t *testing.T) {
+	for _, signingMethod := range []jwt.SigningMethod{jwt.SigningMethodRS256, jwt.SigningMethodES256} {
+		t.Run(signingMethod.Alg(), func(t *testing.T) {
+			fake := startFakeProvider(t, signingMethod)
+			provider := fake.newClient()
+
+			verifier := fake.authorize(provider, "nonce")
+			fake.claims = fake.validClaims("nonce")
+
+			claims, err := provider.Exchange(context.Background(), "code", verifier, "nonce")
+			require.NoError(t, err)
+			assert.Equal(t, &oidc.Claims{
+				Subject: "248289761001", EMail: "h.kalantari.1997@gmail.com", EMailVerified: true,
+			}, claims)
+		})
+	}
+}
+
+func TestExchangeWithWrongVerifier(t *testing.T) {
+	fake := startFakeProvider(t, jwt.SigningMethodRS256)
+	provider := fake.newClient()
+
+	fake.authorize(provider, "nonce")
+	fake.claims = fake.validClaims("nonce")
+
+	_, err := provider.Exchange(context.Background(), "code", "someone-elses-verifier", "nonce")
+	assert.ErrorIs(t, err, oidc.ErrProviderRejected)
+}
+
+func TestExchangeRejectsInvalidIDTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(claims jwt.MapClaims)
+	}{
+		{"wrong nonce", func(claims jwt.MapClaims) { claims["nonce"] = "replayed" }},
+		{"wrong audience", func(claims jwt.MapClaims) { claims["aud"] = "another-client" }},
+		{"wrong issuer", func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }},
+		{"expired", func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{"no expiry", func(claims jwt.MapClaims) { delete(claims, "exp") }},
+		{"other authorized party", func(claims jwt.MapClaims) {
+			claims["aud"] = []string{clientID, "another-client"}
+			claims["azp"] = "another-client"
+		}},
+	}
+
+	fake := startFakeProvider(t, jwt.SigningMethodRS256)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := fake.newClient()
+			verifier := fake.authorize(provider, "nonce")
+
+			fake.claims = fake.validClaims("nonce")
+			test.mutate(fake.claims)
+
+			_, err := provider.Exchange(context.Background(), "code", verifier, "nonce")
+			assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+		})
+	}
+}
+
+func TestExchangeRejectsTokensSignedByOthers(t *testing.T) {
+	fake := startFakeProvider(t, jwt.SigningMethodRS256)
+	provider := fake.newClient()
+	verifier := fake.authorize(provider, "nonce")
+	fake.claims = fake.validClaims("nonce")
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	fake.privateKey = otherKey
+
+	_, err = provider.Exchange(context.Background(), "code", verifier, "nonce")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestDiscoveryRejectsMismatchingIssuer(t *testing.T) {
+	fake := startFakeProvider(t, jwt.SigningMethodRS256)
+	provider := oidc.NewProviderV1(fake.server.URL+"/", clientID, clientSecret, redirectURL, fake.server.Client())
+
+	_, err := provider.AuthorizationURL(context.Background(), "state", "nonce", "challenge")
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("value is malformed or its signature is invalid")
+	ErrExpired          = errors.New("signed value is expired")
+	ErrSecretTooShort   = fmt.Errorf("secret must be at least %d bytes long", MinSecretLength)
+)
+
+const separator = "."
+
+// MinSecretLength is the size of the HMAC-SHA256 output, a shorter secret is easier to guess than the signature.
+const MinSecretLength = 32
+
+// Signer produces tamper-proof, expiring string values that can be handed to untrusted parties(e.g. as cookies).
+type Signer interface {
+	Sign(data string, validUntil time.Time) string
+	Verify(signed string) (data string, err error)
+}
+
+type hmacV1 struct {
+	secret []byte
+}
+
+// NewHMACSignerV1 returns ErrSecretTooShort for secrets shorter than MinSecretLength, an empty one included.
+func NewHMACSignerV1(secret []byte) (Signer, error) {
+	if len(secret) < MinSecretLength {
+		return nil, ErrSecretTooShort
+	}
+
+	return &hmacV1{secret: secret}, nil
+}
+
+func (s hmacV1) mac(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s hmacV1) Sign(data string, validUntil time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(data)) + separator + strconv.FormatInt(validUntil.Unix(), 10)
+
+	return payload + separator + s.mac(payload)
+}
+
+func (s hmacV1) Verify(signed string) (string, error) {
+	lastSeparator := strings.LastIndex(signed, separator)
+	if lastSeparator == -1 {
+		return "", ErrInvalidSignature
+	}
+
+	payload, signature := signed[:lastSeparator], signed[lastSeparator+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.mac(payload))) {
+		return "", ErrInvalidSignature
+	}
+
+	parts := strings.Split(payload, separator)
+	if len(parts) != 2 {
+		return "", ErrInvalidSignature
+	}
+
+	validUntil, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid expiration time", ErrInvalidSignature)
+	}
+	if time.Now().Unix() >= validUntil {
+		return "", ErrExpired
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid data encoding", ErrInvalidSignature)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,61 @@
+package signer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/pkg/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "a-secret-of-at-least-thirty-two-bytes"
+
+func newSigner(t *testing.T, secret string) signer.Signer {
+	s, err := signer.NewHMACSignerV1([]byte(secret))
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestSignAndVerify(t *testing.T) {
+	s := newSigner(t, secret)
+
+	signed := s.Sign("goog:abc", time.Now().Add(time.Minute))
+
+	data, err := s.Verify(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "goog:abc", data)
+}
+
+func TestVerifyExpired(t *testing.T) {
+	s := newSigner(t, secret)
+
+	signed := s.Sign("goog", time.Now().Add(-time.Second))
+
+	_, err := s.Verify(signed)
+	assert.ErrorIs(t, err, signer.ErrExpired)
+}
+
+func TestVerifyTampered(t *testing.T) {
+	s := newSigner(t, secret)
+
+	signed := s.Sign("goog", time.Now().Add(time.Minute))
+
+	_, err := s.Verify("a" + signed)
+	assert.ErrorIs(t, err, signer.ErrInvalidSignature)
+
+	_, err = newSigner(t, "another-secret-of-at-least-32-bytes").Verify(signed)
+	assert.ErrorIs(t, err, signer.ErrInvalidSignature)
+
+	_, err = s.Verify("garbage")
+	assert.ErrorIs(t, err, signer.ErrInvalidSignature)
+}
+
+func TestShortSecret(t *testing.T) {
+	for _, secret := range []string{"", "secret", strings.Repeat("a", signer.MinSecretLength-1)} {
+		_, err := signer.NewHMACSignerV1([]byte(secret))
+		assert.ErrorIs(t, err, signer.ErrSecretTooShort, secret)
+	}
+}
@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	// Period and Digits are what authenticator apps assume when a provisioning uri does not say otherwise.
+	Period = 30 * time.Second
+	Digits = 6
+
+	// SecretLength is the number of bytes in secrets, as recommended by RFC 4226 for HMAC-SHA1.
+	SecretLength = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EncodeSecret returns the form of secret that users type into authenticator apps.
+func EncodeSecret(secret []byte) string {
+	return encoding.EncodeToString(secret)
+}
+
+// Counter returns the number of periods between unix epoch and t.
+func Counter(t time.Time) uint64 {
+	return uint64(t.Unix()) / uint64(Period/time.Second)
+}
+
+// GenerateForCounter returns the RFC 4226 code of secret for a counter, with the given number of digits.
+func GenerateForCounter(secret []byte, counter uint64, digits int) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+// Generate returns the RFC 6238 code of secret at t.
+func Generate(secret []byte, t time.Time) string {
+	return GenerateForCounter(secret, Counter(t), Digits)
+}
+
+// Validate checks code against the periods around t, allowing skew periods of clock drift in either direction. the
+// matching counter is returned, so that callers can refuse codes that were already used.
+func Validate(secret []byte, code string, t time.Time, skew int) (counter uint64, ok bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	current := Counter(t)
+	for i := -skew; i <= skew; i++ {
+		candidate := current + uint64(i)
+		expected := GenerateForCounter(secret, candidate, Digits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return candidate, true
+		}
+	}
+
+	return 0, false
+}
+
+// ProvisioningURI returns the otpauth uri that authenticator apps read, usually from a QR code.
+func ProvisioningURI(issuer, accountName string, secret []byte) string {
+	query := url.Values{}
+	query.Set("secret", EncodeSecret(secret))
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period/time.Second)))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(accountName)
+
+	return (&url.URL{Scheme: "otpauth", Opaque: "//totp/" + label, RawQuery: query.Encode()}).String()
+}
@@ -0,0 +1,70 @@
+package totp_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/h3isenbug/url-shortener/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secret of the test vectors in RFC 4226 and RFC 6238
+var secret = []byte("12345678901234567890")
+
+func TestGenerateForCounterMatchesRFC4226(t *testing.T) {
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range expected {
+		assert.Equal(t, code, totp.GenerateForCounter(secret, uint64(counter), 6), "counter %d", counter)
+	}
+}
+
+func TestCounterMatchesRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, test := range tests {
+		counter := totp.Counter(time.Unix(test.unix, 0))
+		assert.Equal(t, test.code, totp.GenerateForCounter(secret, counter, 8), "time %d", test.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code := totp.Generate(secret, now)
+
+	counter, ok := totp.Validate(secret, code, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Counter(now), counter)
+
+	_, ok = totp.Validate(secret, code, now.Add(totp.Period), 1)
+	assert.True(t, ok, "a code of the previous period should be accepted")
+
+	_, ok = totp.Validate(secret, code, now.Add(totp.Period*2), 1)
+	assert.False(t, ok, "a code older than the allowed skew should be rejected")
+
+	_, ok = totp.Validate(secret, "12345", now, 1)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(totp.ProvisioningURI("short.ir", "h.kalantari.1997@gmail.com", secret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/short.ir:h.kalantari.1997@gmail.com", uri.Path)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", uri.Query().Get("secret"))
+	assert.Equal(t, "short.ir", uri.Query().Get("issuer"))
+}
@@ -0,0 +1,109 @@
+package useragent
+
+import "strings"
+
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceOther   = "other"
+
+	BrowserChrome           = "Chrome"
+	BrowserEdge             = "Edge"
+	BrowserFirefox          = "Firefox"
+	BrowserInternetExplorer = "Internet Explorer"
+	BrowserOpera            = "Opera"
+	BrowserSafari           = "Safari"
+	BrowserSamsungInternet  = "Samsung Internet"
+	BrowserOther            = "Other"
+
+	OSAndroid  = "Android"
+	OSChromeOS = "Chrome OS"
+	OSIOS      = "iOS"
+	OSLinux    = "Linux"
+	OSMacOS    = "macOS"
+	OSWindows  = "Windows"
+	OSOther    = "Other"
+)
+
+type UserAgent struct {
+	Device  string
+	Browser string
+	OS      string
+}
+
+type rule struct {
+	tokens []string
+	family string
+}
+
+var (
+	// rules are checked in order, since user agents carry the tokens of the browsers they are derived from.
+	browserRules = []rule{
+		{tokens: []string{"edg/", "edge/", "edga/", "edgios/"}, family: BrowserEdge},
+		{tokens: []string{"opr/", "opera"}, family: BrowserOpera},
+		{tokens: []string{"samsungbrowser/"}, family: BrowserSamsungInternet},
+		{tokens: []string{"firefox/", "fxios/"}, family: BrowserFirefox},
+		{tokens: []string{"chrome/", "crios/", "chromium/"}, family: BrowserChrome},
+		{tokens: []string{"msie ", "trident/"}, family: BrowserInternetExplorer},
+		{tokens: []string{"safari/"}, family: BrowserSafari},
+	}
+	osRules = []rule{
+		{tokens: []string{"iphone", "ipad", "ipod"}, family: OSIOS},
+		{tokens: []string{"android"}, family: OSAndroid},
+		{tokens: []string{"windows"}, family: OSWindows},
+		{tokens: []string{"cros "}, family: OSChromeOS},
+		{tokens: []string{"mac os x", "macintosh"}, family: OSMacOS},
+		{tokens: []string{"linux", "x11"}, family: OSLinux},
+	}
+)
+
+func matchRules(userAgent string, rules []rule, fallback string) string {
+	for _, rule := range rules {
+		for _, token := range rule.tokens {
+			if strings.Contains(userAgent, token) {
+				return rule.family
+			}
+		}
+	}
+
+	return fallback
+}
+
+func detectDevice(userAgent, os string) string {
+	switch {
+	case strings.Contains(userAgent, "ipad") || strings.Contains(userAgent, "tablet"):
+		return DeviceTablet
+	// android tablets leave the "mobile" token out.
+	case os == OSAndroid && !strings.Contains(userAgent, "mobile"):
+		return DeviceTablet
+	case strings.Contains(userAgent, "mobi") || os == OSIOS || os == OSAndroid:
+		return DeviceMobile
+	case os == OSWindows || os == OSMacOS || os == OSLinux || os == OSChromeOS:
+		return DeviceDesktop
+	}
+
+	return DeviceOther
+}
+
+// Parse classifies a User-Agent header into device class, browser family and OS family.
+// it only knows the common families and falls back to "other" for everything else.
+// telling bots apart is left to the caller, bot is whatever it decided.
+func Parse(userAgent string, bot bool) UserAgent {
+	userAgent = strings.ToLower(userAgent)
+
+	os := matchRules(userAgent, osRules, OSOther)
+	if bot {
+		return UserAgent{Device: DeviceBot, Browser: BrowserOther, OS: os}
+	}
+	if userAgent == "" {
+		return UserAgent{Device: DeviceOther, Browser: BrowserOther, OS: OSOther}
+	}
+
+	return UserAgent{
+		Device:  detectDevice(userAgent, os),
+		Browser: matchRules(userAgent, browserRules, BrowserOther),
+		OS:      os,
+	}
+}
@@ -0,0 +1,106 @@
+package useragent_test
+
+import (
+	"testing"
+
+	"github.com/h3isenbug/url-shortener/pkg/useragent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name      string
+		userAgent string
+		bot       bool
+		expected  useragent.UserAgent
+	}{
+		{
+			name:      "chrome on windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/94.0.4606.81 Safari/537.36",
+			expected:  useragent.UserAgent{Device: useragent.DeviceDesktop, Browser: useragent.BrowserChrome, OS: useragent.OSWindows},
+		},
+		{
+			name:      "edge on windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/94.0.4606.81 Safari/537.36 Edg/94.0.992.50",
+			expected:  useragent.UserAgent{Device: useragent.DeviceDesktop, Browser: useragent.BrowserEdge, OS: useragent.OSWindows},
+		},
+		{
+			name:      "safari on macos",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Safari/605.1.15",
+			expected:  useragent.UserAgent{Device: useragent.DeviceDesktop, Browser: useragent.BrowserSafari, OS: useragent.OSMacOS},
+		},
+		{
+			name:      "firefox on linux",
+			userAgent: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:93.0) Gecko/20100101 Firefox/93.0",
+			expected:  useragent.UserAgent{Device: useragent.DeviceDesktop, Browser: useragent.BrowserFirefox, OS: useragent.OSLinux},
+		},
+		{
+			name:      "chrome on chrome os",
+			userAgent: "Mozilla/5.0 (X11; CrOS x86_64 14150.74.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/94.0.4606.114 Safari/537.36",
+			expected:  useragent.UserAgent{Device: useragent.DeviceDesktop, Browser: useragent.BrowserChrome, OS: useragent.OSChromeOS},
+		},
+		{
+			name:      "safari on iphone",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Mobile/15E148 Safari/604.1",
+			expected:  useragent.UserAgent{Device: useragent.DeviceMobile, Browser: useragent.BrowserSafari, OS: useragent.OSIOS},
+		},
+		{
+			name:      "chrome on iphone",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/94.0.4606.76 Mobile/15E148 Safari/604.1",
+			expected:  useragent.UserAgent{Device: useragent.DeviceMobile, Browser: useragent.BrowserChrome, OS: useragent.OSIOS},
+		},
+		{
+			name:      "safari on ipad",
+			userAgent: "Mozilla/5.0 (iPad; CPU OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Mobile/15E148 Safari/604.1",
+			expected:  useragent.UserAgent{Device: useragent.DeviceTablet, Browser: useragent.BrowserSafari, OS: useragent.OSIOS},
+		},
+		{
+			name:      "samsung internet on android phone",
+			userAgent: "Mozilla/5.0 (Linux; Android 11; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/15.0 Chrome/90.0.4430.210 Mobile Safari/537.36",
+			expected:  useragent.UserAgent{Device: useragent.DeviceMobile, Browser: useragent.BrowserSamsungInternet, OS: useragent.OSAndroid},
+		},
+		{
+			name:      "chrome on android tablet",
+			userAgent: "Mozilla/5.0 (Linux; Android 11; SM-T870) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/94.0.4606.85 Safari/537.36",
+			expected:  useragent.UserAgent{Device: useragent.DeviceTablet, Browser: useragent.BrowserChrome, OS: useragent.OSAndroid},
+		},
+		{
+			name:      "opera on windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/94.0.4606.81 Safari/537.36 OPR/80.0.4170.40",
+			expected:  useragent.UserAgent{Device: useragent.DeviceDesktop, Browser: useragent.BrowserOpera, OS: useragent.OSWindows},
+		},
+		{
+			name:      "internet explorer",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; WOW64; Trident/7.0; rv:11.0) like Gecko",
+			expected:  useragent.UserAgent{Device: useragent.DeviceDesktop, Browser: useragent.BrowserInternetExplorer, OS: useragent.OSWindows},
+		},
+		{
+			name:      "googlebot",
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			bot:       true,
+			expected:  useragent.UserAgent{Device: useragent.DeviceBot, Browser: useragent.BrowserOther, OS: useragent.OSOther},
+		},
+		{
+			name:      "curl",
+			userAgent: "curl/7.79.1",
+			bot:       true,
+			expected:  useragent.UserAgent{Device: useragent.DeviceBot, Browser: useragent.BrowserOther, OS: useragent.OSOther},
+		},
+		{
+			name:      "bot the caller let through",
+			userAgent: "Twitterbot/1.0",
+			expected:  useragent.UserAgent{Device: useragent.DeviceOther, Browser: useragent.BrowserOther, OS: useragent.OSOther},
+		},
+		{
+			name:      "empty",
+			userAgent: "",
+			expected:  useragent.UserAgent{Device: useragent.DeviceOther, Browser: useragent.BrowserOther, OS: useragent.OSOther},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, useragent.Parse(testCase.userAgent, testCase.bot))
+		})
+	}
+}